@@ -1,20 +1,150 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
+	LogLevel          string
 	Port              string
 	AuthServiceURL    string
 	SchoolServiceURL  string
 	StudentServiceURL string
+	JWTSecret         string
+	IdentitySecret    string
+	TokenCacheTTL     time.Duration
+	// Per-upstream bulkhead limits
+	UpstreamMaxConcurrent int
+	UpstreamMaxQueue      int
+	UpstreamQueueTimeout  time.Duration
+	// HealthCheckTimeout bounds each upstream readiness probe and OpenAPI fetch
+	HealthCheckTimeout time.Duration
+	// Request validation against the services' OpenAPI documents
+	SpecRefreshInterval time.Duration
+	MaxRequestBodyBytes int64
+	// Cross-origin policy
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSExposedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+	// Response cache for the opted-in GET routes; a zero TTL disables it
+	ResponseCacheTTL           time.Duration
+	ResponseCacheMaxEntries    int
+	ResponseCacheMaxBytes      int64
+	ResponseCacheMaxEntryBytes int64
+
+	IdempotencyTTL        time.Duration
+	IdempotencyMaxEntries int
+	// Per-branch timeouts of the dashboard endpoints
+	DashboardSchoolTimeout   time.Duration
+	DashboardStudentsTimeout time.Duration
+	// GraphQL query limits; zero disables a limit
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+	// Change feed: events kept for resuming clients, stream liveness and
+	// how the services' streams are followed
+	EventLogSize             int
+	EventHeartbeat           time.Duration
+	EventWriteTimeout        time.Duration
+	EventReconnectDelay      time.Duration
+	EventMaxReconnectDelay   time.Duration
+	EventUpstreamIdleTimeout time.Duration
+	// API versions; deprecated versions map to their deprecation date and
+	// are refused from their sunset date
+	APIVersions               []string
+	APIDefaultVersion         string
+	APIDeprecatedVersions     map[string]time.Time
+	APISunsetDates            map[string]time.Time
+	APIDeprecationLink        string
+	APIDeprecationLogInterval time.Duration
+	// TLS for the service's listeners; a client CA turns on mutual TLS
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string
+	TLSReloadInterval time.Duration
+	// TLS for calls to internal services; the certificate is presented for mutual TLS
+	UpstreamTLSCAFile   string
+	UpstreamTLSCertFile string
+	UpstreamTLSKeyFile  string
+	// HTTP server timeouts and shutdown sequence
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	DrainDelay      time.Duration
 }
 
 func Load() *Config {
 	return &Config{
+		LogLevel:          getEnv("LOG_LEVEL", "info"),
 		Port:              getEnv("PORT", "8080"),
 		AuthServiceURL:    getEnv("AUTH_SERVICE_URL", "http://localhost:8081"),
 		SchoolServiceURL:  getEnv("SCHOOL_SERVICE_URL", "http://localhost:8082"),
 		StudentServiceURL: getEnv("STUDENT_SERVICE_URL", "http://localhost:8083"),
+		JWTSecret:         getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+		IdentitySecret:    getEnv("IDENTITY_SECRET", "your-super-secret-identity-key-change-in-production"),
+		TokenCacheTTL:     getDurationEnv("TOKEN_CACHE_TTL", 30*time.Second),
+
+		UpstreamMaxConcurrent: getIntEnv("UPSTREAM_MAX_CONCURRENT", 100),
+		UpstreamMaxQueue:      getIntEnv("UPSTREAM_MAX_QUEUE", 50),
+		UpstreamQueueTimeout:  getDurationEnv("UPSTREAM_QUEUE_TIMEOUT", time.Second),
+		HealthCheckTimeout:    getDurationEnv("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		SpecRefreshInterval:   getDurationEnv("SPEC_REFRESH_INTERVAL", time.Minute),
+		MaxRequestBodyBytes:   int64(getIntEnv("MAX_REQUEST_BODY_BYTES", 1<<20)),
+
+		CORSAllowedOrigins:   getListEnv("CORS_ALLOWED_ORIGINS", []string{"*"}),
+		CORSAllowedMethods:   getListEnv("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+		CORSAllowedHeaders:   getListEnv("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-Request-ID", "Accept-Version", "X-Client-ID", "Last-Event-ID", "Idempotency-Key"}),
+		CORSExposedHeaders:   getListEnv("CORS_EXPOSED_HEADERS", []string{"X-Request-ID", "X-Cache", "ETag", "X-API-Version", "Deprecation", "Sunset", "Link", "Idempotent-Replayed"}),
+		CORSAllowCredentials: getBoolEnv("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAge:           getDurationEnv("CORS_MAX_AGE", 10*time.Minute),
+
+		ResponseCacheTTL:           getDurationEnv("RESPONSE_CACHE_TTL", 30*time.Second),
+		ResponseCacheMaxEntries:    getIntEnv("RESPONSE_CACHE_MAX_ENTRIES", 10000),
+		ResponseCacheMaxBytes:      int64(getIntEnv("RESPONSE_CACHE_MAX_BYTES", 64<<20)),
+		ResponseCacheMaxEntryBytes: int64(getIntEnv("RESPONSE_CACHE_MAX_ENTRY_BYTES", 1<<20)),
+
+		IdempotencyTTL:        getDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyMaxEntries: getIntEnv("IDEMPOTENCY_MAX_ENTRIES", 10000),
+
+		DashboardSchoolTimeout:   getDurationEnv("DASHBOARD_SCHOOL_TIMEOUT", 2*time.Second),
+		DashboardStudentsTimeout: getDurationEnv("DASHBOARD_STUDENTS_TIMEOUT", 3*time.Second),
+
+		GraphQLMaxDepth:      getIntEnv("GRAPHQL_MAX_DEPTH", 8),
+		GraphQLMaxComplexity: getIntEnv("GRAPHQL_MAX_COMPLEXITY", 5000),
+
+		EventLogSize:             getIntEnv("EVENT_LOG_SIZE", 1000),
+		EventHeartbeat:           getDurationEnv("EVENT_HEARTBEAT_INTERVAL", 15*time.Second),
+		EventWriteTimeout:        getDurationEnv("EVENT_WRITE_TIMEOUT", 10*time.Second),
+		EventReconnectDelay:      getDurationEnv("EVENT_RECONNECT_DELAY", time.Second),
+		EventMaxReconnectDelay:   getDurationEnv("EVENT_MAX_RECONNECT_DELAY", 30*time.Second),
+		EventUpstreamIdleTimeout: getDurationEnv("EVENT_UPSTREAM_IDLE_TIMEOUT", 45*time.Second),
+
+		APIVersions:               getListEnv("API_VERSIONS", []string{"v1", "v2"}),
+		APIDefaultVersion:         getEnv("API_DEFAULT_VERSION", "v1"),
+		APIDeprecatedVersions:     getDateMapEnv("API_DEPRECATED_VERSIONS"),
+		APISunsetDates:            getDateMapEnv("API_SUNSET_DATES"),
+		APIDeprecationLink:        getEnv("API_DEPRECATION_LINK", ""),
+		APIDeprecationLogInterval: getDurationEnv("API_DEPRECATION_LOG_INTERVAL", time.Hour),
+
+		TLSCertFile:         getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:          getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile:     getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSReloadInterval:   getDurationEnv("TLS_RELOAD_INTERVAL", 30*time.Second),
+		UpstreamTLSCAFile:   getEnv("UPSTREAM_TLS_CA_FILE", ""),
+		UpstreamTLSCertFile: getEnv("UPSTREAM_TLS_CERT_FILE", ""),
+		UpstreamTLSKeyFile:  getEnv("UPSTREAM_TLS_KEY_FILE", ""),
+
+		ReadTimeout:     getDurationEnv("HTTP_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:    getDurationEnv("HTTP_WRITE_TIMEOUT", 35*time.Second),
+		IdleTimeout:     getDurationEnv("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 15*time.Second),
+		DrainDelay:      getDurationEnv("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
 	}
 }
 
@@ -24,3 +154,64 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getListEnv reads a comma separated list
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getDateMapEnv parses "v1=2026-01-31,v2" into keys and dates; a key without
+// a date maps to the zero time
+func getDateMapEnv(key string) map[string]time.Time {
+	dates := make(map[string]time.Time)
+	for _, item := range getListEnv(key, nil) {
+		name, value, _ := strings.Cut(item, "=")
+		var date time.Time
+		if value = strings.TrimSpace(value); value != "" {
+			parsed, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				continue
+			}
+			date = parsed
+		}
+		dates[strings.TrimSpace(name)] = date
+	}
+	return dates
+}
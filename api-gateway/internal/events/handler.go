@@ -0,0 +1,273 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"skool-management/shared"
+)
+
+// Control message types sent to WebSocket clients alongside events
+const (
+	MessageSubscribed = "subscribed"
+	MessageError      = "error"
+)
+
+// maxClientMessageBytes bounds the subscription messages WebSocket clients send
+const maxClientMessageBytes = 4096
+
+// Subscription selects the events a client receives. Empty fields select
+// everything the caller may see.
+type Subscription struct {
+	// Types are entities: school or student
+	Types []string `json:"types,omitempty"`
+	// SchoolIDs limits events to these schools
+	SchoolIDs []int `json:"school_ids,omitempty"`
+}
+
+// ControlMessage is sent to WebSocket clients to confirm a subscription or
+// reject a message. Events are sent as they are, and are told apart by
+// their dotted type.
+type ControlMessage struct {
+	Type         string        `json:"type"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	Message      string        `json:"message,omitempty"`
+}
+
+// ClientMessage changes the subscription of a WebSocket connection
+type ClientMessage struct {
+	// Type must be "subscribe"
+	Type string `json:"type"`
+	Subscription
+}
+
+// Handler serves /events as Server-Sent Events, or as a WebSocket when the
+// request asks for an upgrade
+type Handler struct {
+	hub      *Hub
+	stream   shared.EventStreamConfig
+	upgrader websocket.Upgrader
+}
+
+// NewHandler creates the events endpoint. checkOrigin decides which
+// browser origins may open WebSockets.
+func NewHandler(hub *Hub, stream shared.EventStreamConfig, checkOrigin func(*http.Request) bool) *Handler {
+	return &Handler{
+		hub:    hub,
+		stream: stream.WithDefaults(),
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: stream.WithDefaults().WriteTimeout,
+			CheckOrigin:      checkOrigin,
+		},
+	}
+}
+
+// ServeHTTP serves GET /events. The subscription comes from the types and
+// school_ids query parameters and is narrowed to the caller's schools.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	identity, ok := shared.IdentityFromContext(r.Context())
+	if !ok {
+		shared.WriteErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication is required")
+		return
+	}
+
+	query := r.URL.Query()
+	subscription := Subscription{}
+	if types := query.Get("types"); types != "" {
+		subscription.Types = strings.Split(types, ",")
+	}
+	var fields []shared.FieldError
+	subscription.SchoolIDs, fields = shared.ParseIDList(query, "school_ids")
+	if len(fields) > 0 {
+		shared.WriteValidationErrorResponse(w, fields)
+		return
+	}
+	if code, message := subscription.check(identity); code != "" {
+		status := http.StatusBadRequest
+		if code == "FORBIDDEN" {
+			status = http.StatusForbidden
+		}
+		shared.WriteErrorResponse(w, status, code, message)
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		h.serveWebSocket(w, r, identity, subscription)
+		return
+	}
+	shared.ServeEventStream(w, r, h.hub.Log(), shared.LastEventID(r), subscription.filter(identity), h.stream)
+}
+
+// check validates the subscription against what identity may see and
+// returns an error code and message when it is not acceptable
+func (s *Subscription) check(identity *shared.Identity) (code, message string) {
+	for _, entity := range s.Types {
+		if entity != shared.EventEntitySchool && entity != shared.EventEntityStudent {
+			return "VALIDATION_ERROR", fmt.Sprintf("Unknown event type %q; use school or student", entity)
+		}
+	}
+	for _, schoolID := range s.SchoolIDs {
+		if !identity.CanAccessSchool(strconv.Itoa(schoolID)) {
+			return "FORBIDDEN", fmt.Sprintf("You do not have access to school %d", schoolID)
+		}
+	}
+	return "", ""
+}
+
+// filter selects the events of the subscription that identity may see
+func (s Subscription) filter(identity *shared.Identity) func(*shared.Event) bool {
+	return func(event *shared.Event) bool {
+		if len(s.Types) > 0 && !slices.Contains(s.Types, event.Entity) {
+			return false
+		}
+		if len(s.SchoolIDs) > 0 && !slices.ContainsFunc(s.SchoolIDs, event.InSchool) {
+			return false
+		}
+		// Users scoped to schools see a student move if either school is theirs
+		return identity.CanAccessSchool(strconv.Itoa(event.SchoolID)) ||
+			(event.PreviousSchoolID != 0 && identity.CanAccessSchool(strconv.Itoa(event.PreviousSchoolID)))
+	}
+}
+
+// serveWebSocket streams events as JSON text messages. Clients change their
+// subscription by sending a ClientMessage; liveness is checked with pings.
+func (h *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request, identity *shared.Identity, subscription Subscription) {
+	replay, events, err := h.hub.Log().Subscribe(shared.LastEventID(r))
+	if err != nil {
+		shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Gateway is shutting down")
+		return
+	}
+	defer events.Close()
+
+	// The upgrade writes its own error response on failure
+	conn, err := h.upgrader.Upgrade(hijacker{w}, r, nil)
+	if err != nil {
+		slog.DebugContext(r.Context(), "websocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Only this goroutine writes; the reader hands messages over
+	messages := make(chan ClientMessage)
+	readerDone := make(chan struct{})
+	go h.readWebSocket(conn, messages, readerDone)
+
+	write := func(v any) bool {
+		conn.SetWriteDeadline(time.Now().Add(h.stream.WriteTimeout))
+		return conn.WriteJSON(v) == nil
+	}
+	filter := subscription.filter(identity)
+	send := func(event *shared.Event) bool {
+		if event.Type != shared.EventTypeReset && !filter(event) {
+			return true
+		}
+		return write(event)
+	}
+
+	if !write(ControlMessage{Type: MessageSubscribed, Subscription: &subscription}) {
+		return
+	}
+	for i := range replay {
+		if !send(&replay[i]) {
+			return
+		}
+	}
+
+	ping := time.NewTicker(h.stream.Heartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case event, ok := <-events.Events:
+			if !ok {
+				// Dropped for falling behind, or shutting down; the client
+				// reconnects with last_event_id
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "reconnect with last_event_id"),
+					time.Now().Add(h.stream.WriteTimeout))
+				return
+			}
+			if !send(&event) {
+				return
+			}
+		case message := <-messages:
+			next := message.Subscription
+			if message.Type != "subscribe" {
+				if !write(ControlMessage{Type: MessageError, Error: "INVALID_REQUEST", Message: `Message type must be "subscribe"`}) {
+					return
+				}
+				continue
+			}
+			if code, text := next.check(identity); code != "" {
+				if !write(ControlMessage{Type: MessageError, Error: code, Message: text}) {
+					return
+				}
+				continue
+			}
+			subscription, filter = next, next.filter(identity)
+			if !write(ControlMessage{Type: MessageSubscribed, Subscription: &subscription}) {
+				return
+			}
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.stream.WriteTimeout)) != nil {
+				return
+			}
+		case <-readerDone:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// readWebSocket reads client messages until the connection fails or the
+// client stops answering pings, then closes done
+func (h *Handler) readWebSocket(conn *websocket.Conn, messages chan<- ClientMessage, done chan<- struct{}) {
+	defer close(done)
+
+	// A client must answer at least one of two pings
+	deadline := 2 * h.stream.Heartbeat
+	conn.SetReadLimit(maxClientMessageBytes)
+	conn.SetReadDeadline(time.Now().Add(deadline))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var message ClientMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			message = ClientMessage{Type: "invalid"}
+		}
+		select {
+		case messages <- message:
+		case <-time.After(h.stream.WriteTimeout):
+			// The writer is gone
+			return
+		}
+	}
+}
+
+// hijacker exposes Hijack on writers wrapped by middleware, which the
+// WebSocket upgrade asserts directly; the wrappers support it through
+// http.ResponseController
+type hijacker struct {
+	http.ResponseWriter
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
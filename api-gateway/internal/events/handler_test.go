@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+var (
+	eventsTestAdmin = &shared.Identity{UserID: "admin", Roles: []string{shared.RoleAdmin}}
+	eventsTestUser  = &shared.Identity{UserID: "u1", Roles: []string{shared.RoleUser}, SchoolIDs: []string{"1"}}
+)
+
+// eventsTestLog is what the tests publish, in order
+var eventsTestLog = []shared.Event{
+	{Type: shared.EventSchoolUpdated, Entity: shared.EventEntitySchool, EntityID: 1, SchoolID: 1},
+	{Type: shared.EventSchoolUpdated, Entity: shared.EventEntitySchool, EntityID: 2, SchoolID: 2},
+	{Type: shared.EventStudentCreated, Entity: shared.EventEntityStudent, EntityID: 10, SchoolID: 1},
+	{Type: shared.EventStudentCreated, Entity: shared.EventEntityStudent, EntityID: 20, SchoolID: 2},
+	// Students moving into and out of school 1
+	{Type: shared.EventStudentUpdated, Entity: shared.EventEntityStudent, EntityID: 21, SchoolID: 1, PreviousSchoolID: 2},
+	{Type: shared.EventStudentUpdated, Entity: shared.EventEntityStudent, EntityID: 11, SchoolID: 3, PreviousSchoolID: 1},
+	{Type: shared.EventStudentUpdated, Entity: shared.EventEntityStudent, EntityID: 30, SchoolID: 3, PreviousSchoolID: 2},
+}
+
+func TestSubscriptionFilter(t *testing.T) {
+	tests := []struct {
+		name         string
+		identity     *shared.Identity
+		subscription Subscription
+		// want lists the EntityIDs of the events passed
+		want []int
+	}{
+		{"admin", eventsTestAdmin, Subscription{}, []int{1, 2, 10, 20, 21, 11, 30}},
+		{"scoped user", eventsTestUser, Subscription{}, []int{1, 10, 21, 11}},
+		{"no scope", &shared.Identity{UserID: "u2", Roles: []string{shared.RoleUser}}, Subscription{}, nil},
+		{"types", eventsTestUser, Subscription{Types: []string{shared.EventEntityStudent}}, []int{10, 21, 11}},
+		{"schools", eventsTestAdmin, Subscription{SchoolIDs: []int{2}}, []int{2, 20, 21, 30}},
+		{"types and schools", eventsTestAdmin, Subscription{Types: []string{shared.EventEntitySchool}, SchoolIDs: []int{2, 3}}, []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.subscription.filter(tt.identity)
+			var got []int
+			for i := range eventsTestLog {
+				if filter(&eventsTestLog[i]) {
+					got = append(got, eventsTestLog[i].EntityID)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("passed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		identity     *shared.Identity
+		subscription Subscription
+		wantCode     string
+	}{
+		{"everything", eventsTestUser, Subscription{}, ""},
+		{"own school", eventsTestUser, Subscription{Types: []string{shared.EventEntitySchool, shared.EventEntityStudent}, SchoolIDs: []int{1}}, ""},
+		{"unknown type", eventsTestUser, Subscription{Types: []string{"teacher"}}, "VALIDATION_ERROR"},
+		{"other school", eventsTestUser, Subscription{SchoolIDs: []int{1, 2}}, "FORBIDDEN"},
+		{"admin", eventsTestAdmin, Subscription{SchoolIDs: []int{2}}, ""},
+	}
+	for _, tt := range tests {
+		if code, message := tt.subscription.check(tt.identity); code != tt.wantCode {
+			t.Errorf("%s: check = %s %q, want %q", tt.name, code, message, tt.wantCode)
+		}
+	}
+}
+
+// newEventsTest returns a handler over a log holding eventsTestLog, and the
+// IDs the log gave those events
+func newEventsTest(t *testing.T) (*Handler, []string) {
+	gw := gateway.New("http://auth", "http://school", "http://student", shared.BulkheadConfig{MaxConcurrent: 1}, nil)
+	hub := NewHub(gw, nil, HubConfig{})
+	t.Cleanup(hub.CloseStreams)
+	var ids []string
+	for _, event := range eventsTestLog {
+		ids = append(ids, hub.Log().Append(event).ID)
+	}
+	return NewHandler(hub, shared.EventStreamConfig{}, nil), ids
+}
+
+// streamedIDs serves a request whose client has already gone, so the stream
+// ends after the replay, and returns the IDs of the events sent
+func streamedIDs(t *testing.T, h *Handler, identity *shared.Identity, target, lastEventID string) (*httptest.ResponseRecorder, []string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(shared.WithIdentity(context.Background(), identity))
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, target, nil).WithContext(ctx)
+	if lastEventID != "" {
+		req.Header.Set(shared.HeaderLastEventID, lastEventID)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var ids []string
+	for line := range strings.SplitSeq(rec.Body.String(), "\n") {
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return rec, ids
+}
+
+func TestServeHTTPResumesAfterLastEventID(t *testing.T) {
+	h, ids := newEventsTest(t)
+	tests := []struct {
+		name        string
+		identity    *shared.Identity
+		target      string
+		lastEventID string
+		want        []string
+	}{
+		// A resuming user gets the later events it may see, transfers included
+		{"scoped user", eventsTestUser, "/events", ids[1], []string{ids[2], ids[4], ids[5]}},
+		{"admin", eventsTestAdmin, "/events", ids[4], []string{ids[5], ids[6]}},
+		{"query parameter", eventsTestUser, "/events?last_event_id=" + ids[3], "", []string{ids[4], ids[5]}},
+		{"filtered", eventsTestAdmin, "/events?types=school&school_ids=2", ids[0], []string{ids[1]}},
+		{"latest", eventsTestUser, "/events", ids[6], nil},
+		{"no last event", eventsTestUser, "/events", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, got := streamedIDs(t, h, tt.identity, tt.target, tt.lastEventID)
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+				t.Fatalf("status = %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("replayed %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// An ID the log cannot resume from gets a reset, whatever the subscription
+	rec, got := streamedIDs(t, h, eventsTestUser, "/events?types=school", "old-5")
+	if len(got) != 1 || got[0] != ids[6] || !strings.Contains(rec.Body.String(), "event: "+shared.EventTypeReset) {
+		t.Errorf("unknown Last-Event-ID replayed %v: %s", got, rec.Body)
+	}
+}
+
+func TestServeHTTPRejectsSubscriptions(t *testing.T) {
+	h, _ := newEventsTest(t)
+	tests := []struct {
+		name     string
+		identity *shared.Identity
+		target   string
+		wantCode int
+		wantErr  string
+	}{
+		{"anonymous", nil, "/events", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"other school", eventsTestUser, "/events?school_ids=2", http.StatusForbidden, "FORBIDDEN"},
+		{"unknown type", eventsTestUser, "/events?types=teacher", http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"malformed school", eventsTestUser, "/events?school_ids=x", http.StatusBadRequest, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.identity != nil {
+				req = req.WithContext(shared.WithIdentity(req.Context(), tt.identity))
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode || !strings.Contains(rec.Body.String(), tt.wantErr) {
+				t.Errorf("status = %d %s, want %d %s", rec.Code, rec.Body, tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
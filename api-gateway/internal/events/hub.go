@@ -0,0 +1,218 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+// HubConfig configures the relay of the services' event streams
+type HubConfig struct {
+	// LogSize is how many events are kept for clients resuming
+	LogSize int
+	// ReconnectDelay is the first wait before reconnecting to a service;
+	// it doubles up to MaxReconnectDelay while the service stays down
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+	// IdleTimeout drops a service stream that sent nothing, not even a
+	// heartbeat, for this long
+	IdleTimeout time.Duration
+}
+
+// source is a service whose events the hub relays
+type source struct {
+	name string
+	url  string
+}
+
+// Hub follows the event streams of the school and student services and
+// re-publishes their events in a single log with gateway-wide IDs, which
+// clients subscribe to. A service that goes away is reconnected from its
+// last event, so short outages lose nothing; when the service cannot
+// replay, clients get a reset event.
+type Hub struct {
+	log     *shared.EventLog
+	client  *http.Client
+	signer  *shared.IdentitySigner
+	sources []source
+	config  HubConfig
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewHub creates a hub for the services behind gw. Call Start to begin relaying.
+func NewHub(gw *gateway.Gateway, signer *shared.IdentitySigner, config HubConfig) *Hub {
+	return &Hub{
+		log: shared.NewEventLog(shared.EventLogConfig{Name: "api-gateway", Size: config.LogSize}),
+		// Streams stay open indefinitely, so the client has no timeout;
+		// IdleTimeout detects dead connections instead
+		client: &http.Client{Transport: gw.Transport()},
+		signer: signer,
+		sources: []source{
+			{name: "school-service", url: gw.GetSchoolServiceURL()},
+			{name: "student-service", url: gw.GetStudentServiceURL()},
+		},
+		config: config,
+	}
+}
+
+// Log is the merged log clients subscribe to
+func (h *Hub) Log() *shared.EventLog {
+	return h.log
+}
+
+// Start follows every service stream in the background
+func (h *Hub) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	for _, source := range h.sources {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			h.relay(ctx, source)
+		}()
+	}
+}
+
+// CloseStreams ends every client subscription. Register it with
+// http.Server.RegisterOnShutdown so open streams do not hold up draining.
+func (h *Hub) CloseStreams() {
+	h.log.Close()
+}
+
+// Close stops following the services and ends every client subscription
+func (h *Hub) Close(ctx context.Context) error {
+	h.log.Close()
+	if h.cancel != nil {
+		h.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// relay follows one service until ctx is done, reconnecting with backoff
+func (h *Hub) relay(ctx context.Context, source source) {
+	var lastEventID string
+	delay := h.config.ReconnectDelay
+	for {
+		connected, err := h.follow(ctx, source, &lastEventID)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			// The connection worked; start the backoff over
+			delay = h.config.ReconnectDelay
+		}
+		slog.Warn("event stream from service lost", "service", source.name, "error", err, "retry_in", delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(delay*2, h.config.MaxReconnectDelay)
+	}
+}
+
+// follow reads one connection to a service's stream until it fails,
+// appending its events to the hub's log. It reports whether the service
+// accepted the connection, and keeps lastEventID at its last event.
+func (h *Hub) follow(ctx context.Context, source source, lastEventID *string) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.url+shared.EventStreamPath, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if *lastEventID != "" {
+		req.Header.Set(shared.HeaderLastEventID, *lastEventID)
+	}
+	// The services only stream to admins
+	h.signer.Sign(req.Header, &shared.Identity{UserID: "api-gateway", Roles: []string{shared.RoleAdmin}})
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("service returned status %d", resp.StatusCode)
+	}
+	slog.Info("following service events", "service", source.name, "last_event_id", *lastEventID)
+
+	// Heartbeats arrive well within the idle timeout; silence means the
+	// connection is gone without the TCP stack noticing
+	idle := time.AfterFunc(h.config.IdleTimeout, cancel)
+	defer idle.Stop()
+
+	err = readServerSentEvents(resp.Body, func() { idle.Reset(h.config.IdleTimeout) }, func(data []byte) error {
+		var event shared.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		*lastEventID = event.ID
+		h.log.Append(event)
+		return nil
+	})
+	if ctx.Err() != nil && err == nil {
+		err = ctx.Err()
+	}
+	return true, err
+}
+
+// readServerSentEvents parses a Server-Sent Events stream, calling onLine
+// for every line, comments included, and onEvent with the data of every
+// event. Only the data field is used, since events carry their ID and
+// type in their JSON.
+func readServerSentEvents(body io.Reader, onLine func(), onEvent func(data []byte) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+
+	var data []byte
+	for scanner.Scan() {
+		onLine()
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				if err := onEvent(data); err != nil {
+					return err
+				}
+				data = data[:0]
+			}
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, strings.TrimPrefix(value, " ")...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("stream ended")
+}
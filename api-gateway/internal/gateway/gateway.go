@@ -2,8 +2,14 @@ package gateway
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"skool-management/shared"
@@ -17,59 +23,114 @@ type Gateway struct {
 	authCircuitBreaker    *shared.CircuitBreaker
 	schoolCircuitBreaker  *shared.CircuitBreaker
 	studentCircuitBreaker *shared.CircuitBreaker
+	// Bulkheads isolate each service's concurrency from the others
+	authBulkhead    *shared.Bulkhead
+	schoolBulkhead  *shared.Bulkhead
+	studentBulkhead *shared.Bulkhead
+	// Shared client whose transport records client spans
+	httpClient *http.Client
+	transport  http.RoundTripper
 }
 
-func New(authURL, schoolURL, studentURL string) *Gateway {
+// New creates a gateway; bulkhead holds the concurrency limits applied to
+// each upstream service separately (its Name is ignored). A non-nil
+// upstreamTLS is used for https:// service URLs.
+func New(authURL, schoolURL, studentURL string, bulkhead shared.BulkheadConfig, upstreamTLS *tls.Config) *Gateway {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = upstreamTLS
+	transport := shared.TracingTransport(base)
+
 	return &Gateway{
 		authServiceURL:    authURL,
 		schoolServiceURL:  schoolURL,
 		studentServiceURL: studentURL,
 		// Initialize circuit breakers for each service
-		authCircuitBreaker: shared.NewCircuitBreaker(shared.CircuitBreakerConfig{
-			Name:         "auth-service",
-			MaxFailures:  5,
-			ResetTimeout: 30 * time.Second,
-		}),
-		schoolCircuitBreaker: shared.NewCircuitBreaker(shared.CircuitBreakerConfig{
-			Name:         "school-service",
-			MaxFailures:  5,
-			ResetTimeout: 30 * time.Second,
-		}),
-		studentCircuitBreaker: shared.NewCircuitBreaker(shared.CircuitBreakerConfig{
-			Name:         "student-service",
-			MaxFailures:  5,
-			ResetTimeout: 30 * time.Second,
-		}),
+		authCircuitBreaker:    newServiceCircuitBreaker("auth-service"),
+		schoolCircuitBreaker:  newServiceCircuitBreaker("school-service"),
+		studentCircuitBreaker: newServiceCircuitBreaker("student-service"),
+		authBulkhead:          newServiceBulkhead("auth-service", bulkhead),
+		schoolBulkhead:        newServiceBulkhead("school-service", bulkhead),
+		studentBulkhead:       newServiceBulkhead("student-service", bulkhead),
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+		transport: transport,
+	}
+}
+
+// newServiceCircuitBreaker creates the breaker guarding one upstream service.
+// It opens when half of the last 20 calls fail or 80% take longer than 5s.
+func newServiceCircuitBreaker(name string) *shared.CircuitBreaker {
+	return shared.NewCircuitBreaker(shared.CircuitBreakerConfig{
+		Name:                  name,
+		ResetTimeout:          30 * time.Second,
+		WindowType:            shared.CountBasedWindow,
+		WindowSize:            20,
+		MinimumCalls:          10,
+		FailureRateThreshold:  50,
+		SlowCallDuration:      5 * time.Second,
+		SlowCallRateThreshold: 80,
+		HalfOpenMaxCalls:      3,
+	})
+}
+
+// newServiceBulkhead creates the bulkhead limiting calls to one upstream service
+func newServiceBulkhead(name string, config shared.BulkheadConfig) *shared.Bulkhead {
+	config.Name = name
+	return shared.NewBulkhead(config)
+}
+
+// ErrUnknownService is returned for a target URL that is not one of the
+// gateway's upstream services
+var ErrUnknownService = errors.New("unknown target service")
+
+// guards returns the circuit breaker and bulkhead protecting a service. Only
+// the configured services are guarded; other targets are rejected.
+func (g *Gateway) guards(targetURL string) (*shared.CircuitBreaker, *shared.Bulkhead, error) {
+	switch targetURL {
+	case g.authServiceURL:
+		return g.authCircuitBreaker, g.authBulkhead, nil
+	case g.schoolServiceURL:
+		return g.schoolCircuitBreaker, g.schoolBulkhead, nil
+	case g.studentServiceURL:
+		return g.studentCircuitBreaker, g.studentBulkhead, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownService, targetURL)
 	}
 }
 
 // ProxyRequest proxies requests to target services with circuit breaker protection
 func (g *Gateway) ProxyRequest(targetURL string, w http.ResponseWriter, r *http.Request) {
-	// Determine which circuit breaker to use based on target URL
-	var circuitBreaker *shared.CircuitBreaker
-	switch {
-	case targetURL == g.authServiceURL:
-		circuitBreaker = g.authCircuitBreaker
-	case targetURL == g.schoolServiceURL:
-		circuitBreaker = g.schoolCircuitBreaker
-	case targetURL == g.studentServiceURL:
-		circuitBreaker = g.studentCircuitBreaker
-	default:
-		// Fallback for unknown services
-		circuitBreaker = shared.NewCircuitBreaker(shared.CircuitBreakerConfig{
-			Name:         "unknown-service",
-			MaxFailures:  3,
-			ResetTimeout: 30 * time.Second,
-		})
+	circuitBreaker, bulkhead, err := g.guards(targetURL)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "proxy target rejected", "error", err)
+		shared.WriteErrorResponse(w, http.StatusBadGateway, "SERVICE_UNAVAILABLE", "Target service is unavailable")
+		return
+	}
+
+	// Wait for a slot before the breaker so saturation is not counted as an
+	// upstream failure
+	release, err := bulkhead.Acquire(r.Context())
+	if err != nil {
+		if errors.Is(err, shared.ErrBulkheadFull) {
+			slog.WarnContext(r.Context(), "upstream bulkhead saturated", "bulkhead", bulkhead.Name(), "error", err)
+			shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "UPSTREAM_SATURATED",
+				"Service is at capacity, please retry shortly")
+		}
+		return
 	}
+	defer release()
 
-	// Execute request with circuit breaker protection
-	err := circuitBreaker.Execute(func() error {
-		return g.makeProxyRequest(targetURL, w, r)
+	// Execute request with circuit breaker protection under the client's
+	// context, so a client hanging up cancels the upstream call and the
+	// breaker does not count it
+	_, err = shared.ExecuteCtx(r.Context(), circuitBreaker, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, g.makeProxyRequest(targetURL, w, r.WithContext(ctx))
 	})
 
 	if err != nil {
-		if err.Error() == "circuit breaker is OPEN" {
+		if errors.Is(err, shared.ErrCircuitOpen) {
 			shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN",
 				"Service is temporarily unavailable due to circuit breaker")
 			return
@@ -79,6 +140,82 @@ func (g *Gateway) ProxyRequest(targetURL string, w http.ResponseWriter, r *http.
 	}
 }
 
+// ErrServiceError is returned by Get when the service answers with a 5xx
+var ErrServiceError = errors.New("target service returned an error")
+
+// UpstreamResponse is a service response read in full
+type UpstreamResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Get calls a service for endpoints that combine several services' data.
+// It goes through the same bulkhead and circuit breaker as proxied requests,
+// and like them counts 5xx responses as failures, returning the response
+// alongside the error. header is sent as is, so callers pass the client's
+// identity headers.
+func (g *Gateway) Get(ctx context.Context, targetURL, path string, header http.Header) (*UpstreamResponse, error) {
+	circuitBreaker, bulkhead, err := g.guards(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := bulkhead.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var resp *UpstreamResponse
+	_, err = shared.ExecuteCtx(ctx, circuitBreaker, func(ctx context.Context) (struct{}, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL+path, nil)
+		if err != nil {
+			return struct{}{}, err
+		}
+		for key, values := range header {
+			if key == "Accept-Encoding" {
+				continue
+			}
+			req.Header[key] = values
+		}
+
+		httpResp, err := g.httpClient.Do(req)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer httpResp.Body.Close()
+
+		body, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return struct{}{}, err
+		}
+		resp = &UpstreamResponse{StatusCode: httpResp.StatusCode, Header: httpResp.Header, Body: body}
+		if httpResp.StatusCode >= 500 {
+			return struct{}{}, fmt.Errorf("%w: status %d", ErrServiceError, httpResp.StatusCode)
+		}
+		return struct{}{}, nil
+	})
+	return resp, err
+}
+
+// UpstreamError describes an error returned by Get with the status, code and
+// message the proxy answers with for the same failure
+func UpstreamError(err error) (status int, code, message string) {
+	switch {
+	case errors.Is(err, shared.ErrCircuitOpen):
+		return http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN", "Service is temporarily unavailable due to circuit breaker"
+	case errors.Is(err, shared.ErrBulkheadFull):
+		return http.StatusServiceUnavailable, "UPSTREAM_SATURATED", "Service is at capacity, please retry shortly"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "UPSTREAM_TIMEOUT", "Service did not respond in time"
+	case errors.Is(err, ErrServiceError):
+		return http.StatusBadGateway, "SERVICE_ERROR", "Target service returned an error"
+	default:
+		return http.StatusBadGateway, "SERVICE_UNAVAILABLE", "Target service is unavailable"
+	}
+}
+
 // makeProxyRequest performs the actual HTTP request
 func (g *Gateway) makeProxyRequest(targetURL string, w http.ResponseWriter, r *http.Request) error {
 	// Read request body
@@ -94,24 +231,28 @@ func (g *Gateway) makeProxyRequest(targetURL string, w http.ResponseWriter, r *h
 		fullURL += "?" + r.URL.RawQuery
 	}
 
-	req, err := http.NewRequest(r.Method, fullURL, bytes.NewBuffer(bodyBytes))
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, fullURL, bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		shared.WriteErrorResponse(w, http.StatusInternalServerError, "PROXY_ERROR", "Failed to create proxy request")
 		return err
 	}
 
-	// Copy headers
+	// Copy headers. Accept-Encoding is left to the transport, which asks for
+	// gzip and decompresses, so the gateway always sees identity bodies and
+	// compresses for the client itself.
 	for key, values := range r.Header {
+		if key == "Accept-Encoding" {
+			continue
+		}
 		for _, value := range values {
 			req.Header.Add(key, value)
 		}
 	}
 
 	// Make request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := g.httpClient.Do(req)
 	if err != nil {
-		shared.LogError("API_GATEWAY", "proxy request", err)
+		slog.ErrorContext(r.Context(), "proxy request failed", "error", err)
 		shared.WriteErrorResponse(w, http.StatusBadGateway, "SERVICE_UNAVAILABLE", "Target service is unavailable")
 		return err
 	}
@@ -120,11 +261,15 @@ func (g *Gateway) makeProxyRequest(targetURL string, w http.ResponseWriter, r *h
 	// Check for server errors to trigger circuit breaker
 	if resp.StatusCode >= 500 {
 		shared.WriteErrorResponse(w, resp.StatusCode, "SERVICE_ERROR", "Target service returned an error")
-		return err
+		return fmt.Errorf("target service returned status %d", resp.StatusCode)
 	}
 
-	// Copy response headers
+	// Copy response headers; the request ID, CORS policy and API version are
+	// set by the gateway
 	for key, values := range resp.Header {
+		if key == shared.HeaderRequestID || key == shared.HeaderAPIVersion || strings.HasPrefix(key, "Access-Control-") {
+			continue
+		}
 		for _, value := range values {
 			w.Header().Add(key, value)
 		}
@@ -138,6 +283,12 @@ func (g *Gateway) makeProxyRequest(targetURL string, w http.ResponseWriter, r *h
 	return err
 }
 
+// Transport returns the round tripper used for upstream calls, for clients
+// with their own timeouts such as health probes
+func (g *Gateway) Transport() http.RoundTripper {
+	return g.transport
+}
+
 // Getter methods for service URLs
 func (g *Gateway) GetAuthServiceURL() string {
 	return g.authServiceURL
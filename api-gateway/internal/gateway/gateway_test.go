@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"skool-management/shared"
+)
+
+func TestUnknownTargetIsRejected(t *testing.T) {
+	g := New("http://auth", "http://school", "http://student", shared.BulkheadConfig{MaxConcurrent: 1}, nil)
+	breakers := len(shared.DefaultCircuitBreakerRegistry.List())
+
+	if _, err := g.Get(context.Background(), "http://elsewhere", "/", nil); !errors.Is(err, ErrUnknownService) {
+		t.Errorf("Get err = %v, want ErrUnknownService", err)
+	}
+
+	rec := httptest.NewRecorder()
+	g.ProxyRequest("http://elsewhere", rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("ProxyRequest status = %d, want 502", rec.Code)
+	}
+
+	if got := len(shared.DefaultCircuitBreakerRegistry.List()); got != breakers {
+		t.Errorf("registry grew from %d to %d breakers", breakers, got)
+	}
+}
+
+func TestProxyRequestCancelsUpstreamWithClient(t *testing.T) {
+	upstreamStarted := make(chan struct{})
+	upstreamCanceled := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(upstreamStarted)
+		<-r.Context().Done()
+		close(upstreamCanceled)
+	}))
+	defer upstream.Close()
+
+	g := New("http://auth", upstream.URL, "http://student", shared.BulkheadConfig{MaxConcurrent: 1}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/schools", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		g.ProxyRequest(upstream.URL, httptest.NewRecorder(), req)
+		close(done)
+	}()
+
+	<-upstreamStarted
+	cancel()
+	<-upstreamCanceled
+	<-done
+	if stats := g.GetSchoolCircuitBreaker().Stats(); stats.Calls != 0 {
+		t.Errorf("breaker recorded %d calls for a canceled request, want 0", stats.Calls)
+	}
+}
+
+func TestSaturatedUpstreamIsRejected(t *testing.T) {
+	upstreamStarted := make(chan struct{})
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(upstreamStarted)
+		<-release
+	}))
+	defer upstream.Close()
+
+	g := New("http://auth", "http://school", upstream.URL, shared.BulkheadConfig{MaxConcurrent: 1}, nil)
+	done := make(chan struct{})
+	go func() {
+		g.ProxyRequest(upstream.URL, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/students", nil))
+		close(done)
+	}()
+	<-upstreamStarted
+
+	rec := httptest.NewRecorder()
+	g.ProxyRequest(upstream.URL, rec, httptest.NewRequest(http.MethodGet, "/students", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "UPSTREAM_SATURATED") {
+		t.Errorf("saturated request = %d %s, want 503 UPSTREAM_SATURATED", rec.Code, rec.Body)
+	}
+	if _, err := g.Get(context.Background(), upstream.URL, "/students", nil); !errors.Is(err, shared.ErrBulkheadFull) {
+		t.Errorf("Get err = %v, want ErrBulkheadFull", err)
+	}
+
+	close(release)
+	<-done
+	// Saturation is not the service failing
+	if stats := g.GetStudentCircuitBreaker().Stats(); stats.Calls != 1 || stats.Failures != 0 {
+		t.Errorf("breaker stats = %+v, want only the proxied call", stats)
+	}
+}
@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"skool-management/shared"
+)
+
+// upstreamSpec is a service whose OpenAPI document is merged into the
+// gateway's under prefix, the path the gateway exposes the service at
+type upstreamSpec struct {
+	name   string
+	url    string
+	prefix string
+}
+
+// SpecAggregator builds the gateway's OpenAPI document from its own
+// operations and the documents served by each service. The last document
+// fetched from a service is kept, so a service being down does not remove
+// its operations from the docs or from request validation.
+type SpecAggregator struct {
+	client      *http.Client
+	timeout     time.Duration
+	gatewaySpec func() *shared.OpenAPIDocument
+	upstreams   []upstreamSpec
+
+	mutex     sync.Mutex
+	last      map[string]*shared.OpenAPIDocument
+	current   *shared.OpenAPIDocument
+	fetchedAt time.Time
+}
+
+// NewSpecAggregator creates an aggregator for the gateway's services.
+// gatewaySpec builds the document for the gateway's own endpoints and
+// timeout bounds each service fetch.
+func NewSpecAggregator(gw *Gateway, timeout time.Duration, gatewaySpec func() *shared.OpenAPIDocument) *SpecAggregator {
+	return &SpecAggregator{
+		client:      gw.httpClient,
+		timeout:     timeout,
+		gatewaySpec: gatewaySpec,
+		upstreams: []upstreamSpec{
+			{name: "auth", url: gw.authServiceURL, prefix: "/auth"},
+			{name: "school", url: gw.schoolServiceURL},
+			{name: "student", url: gw.studentServiceURL},
+		},
+		last: make(map[string]*shared.OpenAPIDocument),
+	}
+}
+
+// Document fetches the services' documents in parallel and merges them into
+// the gateway's. The result also becomes the Current document.
+func (a *SpecAggregator) Document(ctx context.Context) *shared.OpenAPIDocument {
+	docs := make([]*shared.OpenAPIDocument, len(a.upstreams))
+	var wg sync.WaitGroup
+	for i, upstream := range a.upstreams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			docs[i] = a.upstreamDocument(ctx, upstream)
+		}()
+	}
+	wg.Wait()
+
+	doc := a.gatewaySpec()
+	for i, upstream := range a.upstreams {
+		if docs[i] != nil {
+			doc.Merge(docs[i], upstream.prefix)
+		}
+	}
+
+	a.mutex.Lock()
+	a.current, a.fetchedAt = doc, time.Now()
+	a.mutex.Unlock()
+	return doc
+}
+
+// Current returns the last merged document and when it was built, or nil
+// if Document has not run yet
+func (a *SpecAggregator) Current() (*shared.OpenAPIDocument, time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.current, a.fetchedAt
+}
+
+// upstreamDocument fetches one service's document, falling back to the last
+// one fetched successfully
+func (a *SpecAggregator) upstreamDocument(ctx context.Context, upstream upstreamSpec) *shared.OpenAPIDocument {
+	doc, err := a.fetch(ctx, upstream.url+shared.OpenAPIPath)
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if err != nil {
+		slog.WarnContext(ctx, "failed to fetch service OpenAPI document", "upstream", upstream.name, "error", err)
+		return a.last[upstream.name]
+	}
+	a.last[upstream.name] = doc
+	return doc
+}
+
+func (a *SpecAggregator) fetch(ctx context.Context, url string) (*shared.OpenAPIDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAPI document returned %d", resp.StatusCode)
+	}
+	var doc shared.OpenAPIDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+// Request is a GraphQL request, sent as a JSON body with POST or as query
+// parameters with GET
+type Request struct {
+	Query         string                 `json:"query" jsonschema:"required"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response documents the body of every GraphQL response. Field errors come
+// with the data that could still be resolved.
+type Response struct {
+	Data   any             `json:"data,omitempty"`
+	Errors []ResponseError `json:"errors,omitempty"`
+}
+
+// ResponseError documents one GraphQL error; extensions.code holds the
+// error code a REST endpoint would have answered with
+type ResponseError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Handler serves the GraphQL endpoint
+type Handler struct {
+	schema       graphql.Schema
+	services     *Services
+	limits       Limits
+	maxBodyBytes int64
+}
+
+// NewHandler creates the GraphQL endpoint, resolving through gw
+func NewHandler(gw *gateway.Gateway, limits Limits, maxBodyBytes int64) (*Handler, error) {
+	services := NewServices(gw)
+	schema, err := NewSchema(services)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{schema: schema, services: services, limits: limits, maxBodyBytes: maxBodyBytes}, nil
+}
+
+// ServeHTTP parses, validates and checks the cost of the query before
+// running it. Requests that cannot run get 400 with the reasons in errors;
+// once a query runs the response is 200 and failed fields are null with an
+// entry in errors.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request, err := h.readRequest(r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			shared.WriteErrorResponse(w, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "Request body is too large")
+			return
+		}
+		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(request.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+		return
+	}
+	if request.OperationName == "" && countOperations(doc) > 1 {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(errors.New("Must provide operation name if query contains multiple operations."))})
+		return
+	}
+	if limitErr := h.limits.check(h.schema, doc, request.OperationName, request.Variables); limitErr != nil {
+		slog.WarnContext(r.Context(), "graphql query rejected", "error", limitErr.Code, "message", limitErr.Message)
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: []gqlerrors.FormattedError{
+			{Message: limitErr.Message, Extensions: limitErr.Extensions()},
+		}})
+		return
+	}
+
+	// Resolvers call the services with the caller's identity headers, as
+	// the proxy would
+	header := r.Header.Clone()
+	for _, name := range []string{"Content-Type", "Content-Length", "Accept-Encoding", "If-None-Match"} {
+		header.Del(name)
+	}
+	ctx := context.WithValue(r.Context(), requestStateKey{}, newRequestState(h.services, header))
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       ctx,
+	})
+	writeResult(w, http.StatusOK, result)
+}
+
+// readRequest reads the query from the body of a POST or the query string
+// of a GET
+func (h *Handler) readRequest(r *http.Request) (*Request, error) {
+	var request Request
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				return nil, errors.New("variables must be a JSON object")
+			}
+		}
+	} else {
+		body := http.MaxBytesReader(nil, r.Body, h.maxBodyBytes)
+		if err := json.NewDecoder(body).Decode(&request); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, err
+			}
+			return nil, errors.New("Invalid JSON format")
+		}
+	}
+
+	if request.Query == "" {
+		return nil, errors.New("query is required")
+	}
+	return &request, nil
+}
+
+func countOperations(doc *ast.Document) int {
+	count := 0
+	for _, definition := range doc.Definitions {
+		if _, ok := definition.(*ast.OperationDefinition); ok {
+			count++
+		}
+	}
+	return count
+}
+
+func writeResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	// Results depend on the caller's roles and change with any service data
+	w.Header().Set("Cache-Control", "no-store")
+	shared.WriteJSONResponse(w, status, result)
+}
@@ -0,0 +1,195 @@
+package graph
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+// graphTestServices fakes the school and student services, counting the
+// calls each endpoint receives
+type graphTestServices struct {
+	mutex sync.Mutex
+	calls map[string]int
+}
+
+func (s *graphTestServices) count(r *http.Request) {
+	name := r.URL.Path
+	for _, key := range []string{"ids", "school_ids", "page"} {
+		if r.URL.Query().Has(key) {
+			name += "?" + key
+			break
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls[name]++
+}
+
+func (s *graphTestServices) schools(w http.ResponseWriter, r *http.Request) {
+	s.count(r)
+	school := func(id int) School { return School{ID: id, Name: "School " + strconv.Itoa(id)} }
+	if r.URL.Query().Has("page") {
+		shared.WriteSuccessResponse(w, http.StatusOK, "", SchoolPage{Items: []School{school(1), school(2), school(3)}})
+		return
+	}
+	// Like the school service, unknown IDs are left out
+	var schools []School
+	for _, raw := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id, _ := strconv.Atoi(raw); id >= 1 && id <= 3 {
+			schools = append(schools, school(id))
+		}
+	}
+	shared.WriteSuccessResponse(w, http.StatusOK, "", schools)
+}
+
+func (s *graphTestServices) students(w http.ResponseWriter, r *http.Request) {
+	s.count(r)
+	student := func(id, schoolID int) Student {
+		return Student{ID: id, FirstName: "Student " + strconv.Itoa(id), SchoolID: schoolID, DateOfBirth: "2010-01-01", Status: "active"}
+	}
+	if id, ok := strings.CutPrefix(r.URL.Path, "/students/"); ok {
+		n, _ := strconv.Atoi(id)
+		shared.WriteSuccessResponse(w, http.StatusOK, "", student(n, 1))
+		return
+	}
+	var students []Student
+	for _, raw := range strings.Split(r.URL.Query().Get("school_ids"), ",") {
+		schoolID, _ := strconv.Atoi(raw)
+		students = append(students, student(schoolID*10+1, schoolID), student(schoolID*10+2, schoolID))
+	}
+	shared.WriteSuccessResponse(w, http.StatusOK, "", students)
+}
+
+func newGraphTest(t *testing.T) (*graphTestServices, func(identity *shared.Identity, query string) Response) {
+	services := &graphTestServices{calls: make(map[string]int)}
+	schoolService := httptest.NewServer(http.HandlerFunc(services.schools))
+	t.Cleanup(schoolService.Close)
+	studentService := httptest.NewServer(http.HandlerFunc(services.students))
+	t.Cleanup(studentService.Close)
+
+	gw := gateway.New("http://auth", schoolService.URL, studentService.URL, shared.BulkheadConfig{MaxConcurrent: 10}, nil)
+	handler, err := NewHandler(gw, Limits{}, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services, func(identity *shared.Identity, query string) Response {
+		body, _ := json.Marshal(Request{Query: query})
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		req = req.WithContext(shared.WithIdentity(req.Context(), identity))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		var resp Response
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+}
+
+var (
+	graphTestAdmin = &shared.Identity{UserID: "admin", Roles: []string{shared.RoleAdmin}}
+	graphTestUser  = &shared.Identity{UserID: "u1", Roles: []string{shared.RoleUser}, SchoolIDs: []string{"1", "99"}}
+)
+
+func TestQueriesBatchServiceCallsPerLevel(t *testing.T) {
+	services, query := newGraphTest(t)
+	resp := query(graphTestAdmin, `{ schools(pageSize: 3) { items { name students { firstName school { name } } } } }`)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors: %+v", resp.Errors)
+	}
+
+	// One call per level however many schools and students there are
+	want := map[string]int{"/schools?page": 1, "/students?school_ids": 1, "/schools?ids": 1}
+	if !reflect.DeepEqual(services.calls, want) {
+		t.Errorf("service calls = %v, want %v", services.calls, want)
+	}
+
+	data := resp.Data.(map[string]any)
+	items := data["schools"].(map[string]any)["items"].([]any)
+	if len(items) != 3 {
+		t.Fatalf("got %d schools, want 3", len(items))
+	}
+	students := items[1].(map[string]any)["students"].([]any)
+	if len(students) != 2 {
+		t.Fatalf("school 2 has %d students, want 2", len(students))
+	}
+	if name := students[0].(map[string]any)["school"].(map[string]any)["name"]; name != "School 2" {
+		t.Errorf("student's school = %v, want School 2", name)
+	}
+}
+
+func TestHasRoleNullsField(t *testing.T) {
+	tests := []struct {
+		name        string
+		identity    *shared.Identity
+		wantBirth   any
+		wantErrPath []any
+	}{
+		{"admin", graphTestAdmin, "2010-01-01", nil},
+		{"user", graphTestUser, nil, []any{"student", "dateOfBirth"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, query := newGraphTest(t)
+			resp := query(tt.identity, `{ student(id: 7) { firstName dateOfBirth } }`)
+
+			student := resp.Data.(map[string]any)["student"].(map[string]any)
+			if student["firstName"] != "Student 7" || student["dateOfBirth"] != tt.wantBirth {
+				t.Errorf("student = %v, want dateOfBirth %v", student, tt.wantBirth)
+			}
+			if tt.wantErrPath == nil {
+				if len(resp.Errors) > 0 {
+					t.Errorf("errors: %+v", resp.Errors)
+				}
+				return
+			}
+			if len(resp.Errors) != 1 || !reflect.DeepEqual(resp.Errors[0].Path, tt.wantErrPath) || resp.Errors[0].Extensions["code"] != "FORBIDDEN" {
+				t.Errorf("errors = %+v, want FORBIDDEN at %v", resp.Errors, tt.wantErrPath)
+			}
+		})
+	}
+
+	// A nullable root field is nulled the same way without calling the service
+	services, query := newGraphTest(t)
+	resp := query(graphTestUser, `{ students { items { id } } }`)
+	if resp.Data.(map[string]any)["students"] != nil || len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "FORBIDDEN" {
+		t.Errorf("students as user = %+v", resp)
+	}
+	if len(services.calls) != 0 {
+		t.Errorf("service calls = %v, want none", services.calls)
+	}
+}
+
+func TestMeSchoolsSkipsMissingSchools(t *testing.T) {
+	_, query := newGraphTest(t)
+	resp := query(graphTestUser, `{ me { schoolIds schools { name } } }`)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors: %+v", resp.Errors)
+	}
+	me := resp.Data.(map[string]any)["me"].(map[string]any)
+	if want := []any{float64(1), float64(99)}; !reflect.DeepEqual(me["schoolIds"], want) {
+		t.Errorf("schoolIds = %v, want %v", me["schoolIds"], want)
+	}
+	if want := []any{map[string]any{"name": "School 1"}}; !reflect.DeepEqual(me["schools"], want) {
+		t.Errorf("schools = %v, want %v", me["schools"], want)
+	}
+
+	// An admin without a scope lists no schools here, though it may access all
+	resp = query(graphTestAdmin, `{ me { schoolIds schools { name } } }`)
+	me = resp.Data.(map[string]any)["me"].(map[string]any)
+	if len(me["schoolIds"].([]any)) != 0 || len(me["schools"].([]any)) != 0 {
+		t.Errorf("admin me = %v, want empty lists", me)
+	}
+}
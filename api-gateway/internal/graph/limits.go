@@ -0,0 +1,168 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"skool-management/shared"
+)
+
+// listSizeEstimate is the number of items a list field is assumed to
+// return when the field holding it has no pageSize argument
+const listSizeEstimate = shared.DefaultPageSize
+
+// Limits bounds the cost of a query, checked before it runs
+type Limits struct {
+	// MaxDepth is the deepest field nesting allowed; introspection fields
+	// are not counted
+	MaxDepth int
+	// MaxComplexity bounds the estimated number of fields resolved: each
+	// field costs 1, and the fields below a list count once per expected item
+	MaxComplexity int
+}
+
+// check measures the operation that will run and returns an error when it
+// exceeds the limits
+func (l Limits) check(schema graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) *Error {
+	cost := queryCost{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+	}
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			cost.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil || operation.Operation != ast.OperationTypeQuery {
+		return nil
+	}
+
+	depth, complexity := cost.measure(operation.SelectionSet, schema.QueryType(), 0, make(map[string]bool))
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &Error{Code: "QUERY_TOO_DEEP", Message: fmt.Sprintf("Query depth %d exceeds the limit of %d", depth, l.MaxDepth)}
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return &Error{Code: "QUERY_TOO_COMPLEX", Message: fmt.Sprintf("Query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity)}
+	}
+	return nil
+}
+
+type queryCost struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// measure returns the depth and complexity of a selection set on parent.
+// pageSize is the pageSize argument of the field holding the set, if any,
+// and sizes the lists directly inside it.
+func (c *queryCost) measure(set *ast.SelectionSet, parent *graphql.Object, pageSize int, fragments map[string]bool) (depth, complexity int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var d, n int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, n = c.measureField(selection, parent, pageSize, fragments)
+		case *ast.InlineFragment:
+			d, n = c.measure(selection.SelectionSet, c.typeCondition(selection.TypeCondition, parent), pageSize, fragments)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := c.fragments[name]
+			if !ok || fragments[name] {
+				continue
+			}
+			fragments[name] = true
+			d, n = c.measure(fragment.SelectionSet, c.typeCondition(fragment.TypeCondition, parent), pageSize, fragments)
+			delete(fragments, name)
+		}
+		depth = max(depth, d)
+		complexity += n
+	}
+	return depth, complexity
+}
+
+func (c *queryCost) measureField(field *ast.Field, parent *graphql.Object, pageSize int, fragments map[string]bool) (depth, complexity int) {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		// Introspection is bounded by the schema itself
+		return 0, 0
+	}
+	definition, ok := parent.Fields()[name]
+	if !ok {
+		return 1, 1
+	}
+
+	fieldType := definition.Type
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+	_, isList := fieldType.(*graphql.List)
+	for {
+		switch wrapped := fieldType.(type) {
+		case *graphql.NonNull:
+			fieldType = wrapped.OfType
+			continue
+		case *graphql.List:
+			fieldType = wrapped.OfType
+			continue
+		}
+		break
+	}
+	object, _ := fieldType.(*graphql.Object)
+
+	childDepth, childComplexity := c.measure(field.SelectionSet, object, c.intArgument(field, "pageSize"), fragments)
+	if isList {
+		items := pageSize
+		if items <= 0 {
+			items = listSizeEstimate
+		}
+		childComplexity *= items
+	}
+	return 1 + childDepth, 1 + childComplexity
+}
+
+// typeCondition returns the object type a fragment applies to
+func (c *queryCost) typeCondition(condition *ast.Named, parent *graphql.Object) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := c.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
+
+// intArgument returns the value of an integer argument given as a literal
+// or a variable, or 0
+func (c *queryCost) intArgument(field *ast.Field, name string) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != name {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			n, _ := strconv.Atoi(value.Value)
+			return n
+		case *ast.Variable:
+			switch v := c.variables[value.Name.Value].(type) {
+			case float64:
+				return int(v)
+			case int:
+				return v
+			}
+		}
+	}
+	return 0
+}
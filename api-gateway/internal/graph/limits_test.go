@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestLimitsMeasureQueries(t *testing.T) {
+	schema, err := NewSchema(NewServices(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		operationName  string
+		variables      map[string]interface{}
+		wantDepth      int
+		wantComplexity int
+	}{
+		{"scalar fields", `{ me { id email } }`, "", nil, 2, 3},
+		{"introspection is free", `{ __schema { types { name fields { name } } } me { id } }`, "", nil, 2, 2},
+		{"page size literal", `{ schools(pageSize: 2) { items { name } } }`, "", nil, 3, 4},
+		{"page size variable", `query($n: Int) { schools(pageSize: $n) { items { name } } }`, "", map[string]interface{}{"n": float64(5)}, 3, 7},
+		{"default page size", `{ schools { items { name } } }`, "", nil, 3, 1 + 1 + listSizeEstimate},
+		// The students of each school have no pageSize and are estimated
+		{"nested lists", `{ schools(pageSize: 2) { items { students { id } } } }`, "", nil, 4, 1 + 1 + 2*(1+listSizeEstimate)},
+		{"non-list nesting", `{ student(id: 1) { school { name } } }`, "", nil, 3, 3},
+		{"fragment spread", `{ schools(pageSize: 2) { ...page } } fragment page on SchoolPage { items { name } }`, "", nil, 3, 4},
+		{"inline fragment", `{ schools(pageSize: 2) { ... on SchoolPage { items { name } } } }`, "", nil, 3, 4},
+		{"fragment spread twice", `{ a: student(id: 1) { ...s } b: student(id: 2) { ...s } } fragment s on Student { id }`, "", nil, 2, 4},
+		{"named operation", `query Small { me { id } } query Big { schools { items { name } } }`, "Small", nil, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := (Limits{MaxDepth: tt.wantDepth, MaxComplexity: tt.wantComplexity}).check(schema, doc, tt.operationName, tt.variables); err != nil {
+				t.Errorf("at the limits: %s", err.Message)
+			}
+			if err := (Limits{MaxDepth: tt.wantDepth - 1}).check(schema, doc, tt.operationName, tt.variables); err == nil || err.Code != "QUERY_TOO_DEEP" {
+				t.Errorf("depth limit %d: err = %v, want QUERY_TOO_DEEP", tt.wantDepth-1, err)
+			}
+			if err := (Limits{MaxComplexity: tt.wantComplexity - 1}).check(schema, doc, tt.operationName, tt.variables); err == nil || err.Code != "QUERY_TOO_COMPLEX" {
+				t.Errorf("complexity limit %d: err = %v, want QUERY_TOO_COMPLEX", tt.wantComplexity-1, err)
+			}
+		})
+	}
+}
+
+func TestLimitsTerminateOnFragmentCycles(t *testing.T) {
+	schema, err := NewSchema(NewServices(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Validation rejects cycles, but the check must not loop on them either
+	doc, err := parser.Parse(parser.ParseParams{Source: `{ student(id: 1) { ...s } } fragment s on Student { school { students { ...s } } }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (Limits{MaxDepth: 10, MaxComplexity: 1000}).check(schema, doc, "", nil); err != nil {
+		t.Errorf("check = %s", err.Message)
+	}
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// Loader batches the keys requested while one level of a query resolves
+// into as few calls of its batch function as possible, the dataloader
+// pattern. Resolvers call Load and return the thunk; the executor runs the
+// thunks only after every sibling resolver has registered its key, so the
+// first thunk fetches all of them at once. Results are cached for the
+// loader's lifetime, which is a single request.
+type Loader[K comparable, V any] struct {
+	batch    func(ctx context.Context, keys []K) (map[K]V, error)
+	maxBatch int
+
+	mutex   sync.Mutex
+	pending []K
+	results map[K]V
+	errs    map[K]error
+}
+
+// NewLoader creates a loader calling batch with at most maxBatch keys at a
+// time. Keys missing from a batch result load as the zero value.
+func NewLoader[K comparable, V any](maxBatch int, batch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		batch:    batch,
+		maxBatch: maxBatch,
+		results:  make(map[K]V),
+		errs:     make(map[K]error),
+	}
+}
+
+// Load registers key and returns a thunk resolving to its value
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mutex.Lock()
+	l.enqueue(key)
+	l.mutex.Unlock()
+
+	return func() (V, error) {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.dispatch(ctx)
+		return l.results[key], l.errs[key]
+	}
+}
+
+// LoadMany registers keys and returns a thunk resolving to their values in
+// order. It fails if any key fails.
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) func() ([]V, error) {
+	l.mutex.Lock()
+	for _, key := range keys {
+		l.enqueue(key)
+	}
+	l.mutex.Unlock()
+
+	return func() ([]V, error) {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.dispatch(ctx)
+		values := make([]V, 0, len(keys))
+		for _, key := range keys {
+			if err := l.errs[key]; err != nil {
+				return nil, err
+			}
+			values = append(values, l.results[key])
+		}
+		return values, nil
+	}
+}
+
+// enqueue adds key to the next batch unless it is loaded or already queued
+func (l *Loader[K, V]) enqueue(key K) {
+	if _, ok := l.results[key]; ok {
+		return
+	}
+	if _, ok := l.errs[key]; ok {
+		return
+	}
+	for _, queued := range l.pending {
+		if queued == key {
+			return
+		}
+	}
+	l.pending = append(l.pending, key)
+}
+
+// dispatch loads every pending key, maxBatch keys per call
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	for len(l.pending) > 0 {
+		keys := l.pending
+		if len(keys) > l.maxBatch {
+			keys = keys[:l.maxBatch]
+		}
+		l.pending = l.pending[len(keys):]
+
+		values, err := l.batch(ctx, keys)
+		for _, key := range keys {
+			if err != nil {
+				l.errs[key] = err
+				continue
+			}
+			l.results[key] = values[key]
+		}
+	}
+}
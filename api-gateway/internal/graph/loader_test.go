@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoaderBatchesAndCaches(t *testing.T) {
+	var batches [][]int
+	loader := NewLoader(2, func(_ context.Context, keys []int) (map[int]string, error) {
+		batches = append(batches, append([]int(nil), keys...))
+		values := make(map[int]string)
+		for _, key := range keys {
+			if key != 4 {
+				values[key] = string(rune('a' + key))
+			}
+		}
+		return values, nil
+	})
+	ctx := context.Background()
+
+	thunks := []func() (string, error){loader.Load(ctx, 1), loader.Load(ctx, 2), loader.Load(ctx, 1), loader.Load(ctx, 4)}
+	many := loader.LoadMany(ctx, []int{3, 2})
+	if len(batches) != 0 {
+		t.Fatalf("loaded before any thunk ran: %v", batches)
+	}
+
+	var got []string
+	for _, thunk := range thunks {
+		value, err := thunk()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, value)
+	}
+	values, err := many()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, values...)
+
+	// Missing keys load as the zero value
+	if want := []string{"b", "c", "b", "", "d", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values = %q, want %q", got, want)
+	}
+	if want := [][]int{{1, 2}, {4, 3}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches = %v, want %v", batches, want)
+	}
+
+	loader.Load(ctx, 2)()
+	if len(batches) != 2 {
+		t.Errorf("a cached key was loaded again: %v", batches)
+	}
+}
+
+func TestLoaderReportsBatchErrors(t *testing.T) {
+	errDown := errors.New("down")
+	loader := NewLoader(10, func(_ context.Context, keys []int) (map[int]string, error) {
+		return nil, errDown
+	})
+	ctx := context.Background()
+
+	one := loader.Load(ctx, 1)
+	many := loader.LoadMany(ctx, []int{1, 2})
+	if _, err := one(); !errors.Is(err, errDown) {
+		t.Errorf("Load err = %v, want the batch error", err)
+	}
+	if values, err := many(); !errors.Is(err, errDown) || values != nil {
+		t.Errorf("LoadMany = %v, %v; want the batch error", values, err)
+	}
+}
@@ -0,0 +1,365 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+
+	"skool-management/shared"
+)
+
+// requestState holds the per-request loaders, so batching and caching never
+// cross callers with different access
+type requestState struct {
+	header   http.Header
+	schools  *Loader[int, *School]
+	students *Loader[int, []Student]
+}
+
+type requestStateKey struct{}
+
+func newRequestState(services *Services, header http.Header) *requestState {
+	return &requestState{
+		header:   header,
+		schools:  NewLoader(shared.MaxPageSize, services.SchoolsByIDs),
+		students: NewLoader(shared.MaxPageSize, services.StudentsBySchoolIDs),
+	}
+}
+
+func requestFromContext(ctx context.Context) *requestState {
+	return ctx.Value(requestStateKey{}).(*requestState)
+}
+
+var roleEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "Role",
+	Values: graphql.EnumValueConfigMap{
+		"ADMIN": {Value: shared.RoleAdmin},
+		"USER":  {Value: "user"},
+	},
+})
+
+var studentStatusEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "StudentStatus",
+	Description: "Filters students by status",
+	Values: graphql.EnumValueConfigMap{
+		"ACTIVE":      {Value: "active"},
+		"INACTIVE":    {Value: "inactive"},
+		"GRADUATED":   {Value: "graduated"},
+		"TRANSFERRED": {Value: "transferred"},
+		"SUSPENDED":   {Value: "suspended"},
+	},
+})
+
+// hasRoleDirective documents the role checks applied by hasRole. graphql-go
+// cannot attach directives to field definitions, so the schema declares it
+// for introspection and hasRole enforces it.
+var hasRoleDirective = graphql.NewDirective(graphql.DirectiveConfig{
+	Name:        "hasRole",
+	Description: "Restricts a field to callers with the role; others get null and a FORBIDDEN error",
+	Locations:   []string{graphql.DirectiveLocationFieldDefinition},
+	Args: graphql.FieldConfigArgument{
+		"role": {Type: graphql.NewNonNull(roleEnum)},
+	},
+})
+
+// hasRole applies @hasRole(role:) to field
+func hasRole(role string, field *graphql.Field) *graphql.Field {
+	resolve := field.Resolve
+	if resolve == nil {
+		resolve = graphql.DefaultResolveFn
+	}
+	field.Description = strings.TrimSpace(field.Description + " Requires the " + strings.ToUpper(role) + " role.")
+	field.Resolve = func(p graphql.ResolveParams) (interface{}, error) {
+		identity, ok := shared.IdentityFromContext(p.Context)
+		if !ok {
+			return nil, &Error{Code: "UNAUTHORIZED", Message: "Authentication is required"}
+		}
+		if !identity.HasRole(role) {
+			return nil, &Error{Code: "FORBIDDEN", Message: "Insufficient permissions"}
+		}
+		return resolve(p)
+	}
+	return field
+}
+
+// pageArgs are the pagination arguments of list queries
+var pageArgs = graphql.FieldConfigArgument{
+	"page":     {Type: graphql.Int, DefaultValue: 1},
+	"pageSize": {Type: graphql.Int, DefaultValue: shared.DefaultPageSize},
+}
+
+// pageRequest validates the pagination arguments like the REST endpoints
+func pageRequest(args map[string]interface{}) (shared.PageRequest, error) {
+	page, _ := args["page"].(int)
+	pageSize, _ := args["pageSize"].(int)
+	if page < 1 {
+		return shared.PageRequest{}, &Error{Code: "VALIDATION_ERROR", Message: "page must be at least 1"}
+	}
+	if pageSize < 1 || pageSize > shared.MaxPageSize {
+		return shared.PageRequest{}, &Error{Code: "VALIDATION_ERROR", Message: "pageSize must be between 1 and " + strconv.Itoa(shared.MaxPageSize)}
+	}
+	return shared.PageRequest{Page: page, PageSize: pageSize}, nil
+}
+
+// NewSchema builds the GraphQL schema over the school and student services
+func NewSchema(services *Services) (graphql.Schema, error) {
+	pagination := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Pagination",
+		Fields: graphql.Fields{
+			"page":       {Type: graphql.NewNonNull(graphql.Int)},
+			"pageSize":   {Type: graphql.NewNonNull(graphql.Int)},
+			"total":      {Type: graphql.NewNonNull(graphql.Int)},
+			"totalPages": {Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	// School and Student refer to each other, so their fields are thunks
+	var school, student *graphql.Object
+
+	school = graphql.NewObject(graphql.ObjectConfig{
+		Name: "School",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":                 {Type: graphql.NewNonNull(graphql.Int)},
+				"registrationNumber": {Type: graphql.NewNonNull(graphql.String)},
+				"name":               {Type: graphql.NewNonNull(graphql.String)},
+				"address":            {Type: graphql.String},
+				"phone":              {Type: graphql.String},
+				"email":              {Type: graphql.String},
+				"createdAt":          {Type: graphql.NewNonNull(graphql.DateTime)},
+				"updatedAt":          {Type: graphql.NewNonNull(graphql.DateTime)},
+				"students": {
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(student))),
+					Description: "The school's students, newest first",
+					Args: graphql.FieldConfigArgument{
+						"status": {Type: studentStatusEnum},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						source := p.Source.(*School)
+						status, _ := p.Args["status"].(string)
+						load := requestFromContext(p.Context).students.Load(p.Context, source.ID)
+						return func() (interface{}, error) {
+							students, err := load()
+							if err != nil {
+								return nil, err
+							}
+							matched := make([]*Student, 0, len(students))
+							for i := range students {
+								if status == "" || strings.EqualFold(students[i].Status, status) {
+									matched = append(matched, &students[i])
+								}
+							}
+							return matched, nil
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	student = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Student",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":             {Type: graphql.NewNonNull(graphql.Int)},
+				"rollNumber":     {Type: graphql.NewNonNull(graphql.String)},
+				"firstName":      {Type: graphql.NewNonNull(graphql.String)},
+				"lastName":       {Type: graphql.NewNonNull(graphql.String)},
+				"email":          {Type: graphql.String},
+				"phone":          {Type: graphql.String},
+				"dateOfBirth":    hasRole(shared.RoleAdmin, &graphql.Field{Type: graphql.String}),
+				"address":        hasRole(shared.RoleAdmin, &graphql.Field{Type: graphql.String}),
+				"schoolId":       {Type: graphql.NewNonNull(graphql.Int)},
+				"enrollmentDate": {Type: graphql.String},
+				"status":         {Type: graphql.NewNonNull(graphql.String)},
+				"createdAt":      {Type: graphql.NewNonNull(graphql.DateTime)},
+				"updatedAt":      {Type: graphql.NewNonNull(graphql.DateTime)},
+				"school": {
+					Type: school,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						source := p.Source.(*Student)
+						return loadSchool(p.Context, source.SchoolID), nil
+					},
+				},
+			}
+		}),
+	})
+
+	user := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "User",
+		Description: "The authenticated caller",
+		Fields: graphql.Fields{
+			"id": {
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*shared.Identity).UserID, nil
+				},
+			},
+			"email": {Type: graphql.NewNonNull(graphql.String)},
+			"roles": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return append([]string{}, p.Source.(*shared.Identity).Roles...), nil
+				},
+			},
+			"schoolIds": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int))),
+				Description: "The schools the user is scoped to. Admins access every school whatever it lists; for anyone else an empty list grants none",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return schoolIDs(p.Source.(*shared.Identity)), nil
+				},
+			},
+			"schools": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(school))),
+				Description: "The schools in schoolIds that still exist, so empty when schoolIds is, admins included",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					load := requestFromContext(p.Context).schools.LoadMany(p.Context, schoolIDs(p.Source.(*shared.Identity)))
+					return func() (interface{}, error) {
+						schools, err := load()
+						if err != nil {
+							return nil, err
+						}
+						// Schools deleted since the token was issued are skipped
+						found := make([]*School, 0, len(schools))
+						for _, s := range schools {
+							if s != nil {
+								found = append(found, s)
+							}
+						}
+						return found, nil
+					}, nil
+				},
+			},
+		},
+	})
+
+	schoolPage := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SchoolPage",
+		Fields: graphql.Fields{
+			"items": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(school))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					page := p.Source.(*SchoolPage)
+					items := make([]*School, len(page.Items))
+					for i := range page.Items {
+						items[i] = &page.Items[i]
+					}
+					return items, nil
+				},
+			},
+			"pagination": {Type: graphql.NewNonNull(pagination)},
+		},
+	})
+
+	studentPage := graphql.NewObject(graphql.ObjectConfig{
+		Name: "StudentPage",
+		Fields: graphql.Fields{
+			"items": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(student))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					page := p.Source.(*StudentPage)
+					items := make([]*Student, len(page.Items))
+					for i := range page.Items {
+						items[i] = &page.Items[i]
+					}
+					return items, nil
+				},
+			},
+			"pagination": {Type: graphql.NewNonNull(pagination)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {
+				Type: graphql.NewNonNull(user),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					identity, ok := shared.IdentityFromContext(p.Context)
+					if !ok {
+						return nil, &Error{Code: "UNAUTHORIZED", Message: "Authentication is required"}
+					}
+					return identity, nil
+				},
+			},
+			"school": {
+				Type:        school,
+				Description: "A school in the caller's scope; null for unknown schools and schools outside it",
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadSchool(p.Context, p.Args["id"].(int)), nil
+				},
+			},
+			"schools": {
+				Type: graphql.NewNonNull(schoolPage),
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					page, err := pageRequest(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					return services.Schools(p.Context, page)
+				},
+			},
+			"student": {
+				Type: student,
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					student, err := services.Student(p.Context, p.Args["id"].(int))
+					if student == nil {
+						return nil, err
+					}
+					return student, nil
+				},
+			},
+			"students": hasRole(shared.RoleAdmin, &graphql.Field{
+				Type:        studentPage,
+				Description: "Students across every school.",
+				Args:        pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					page, err := pageRequest(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					return services.Students(p.Context, page)
+				},
+			}),
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:      query,
+		Directives: append(graphql.SpecifiedDirectives, hasRoleDirective),
+	})
+}
+
+// loadSchool returns a thunk resolving to the school with id, or null
+func loadSchool(ctx context.Context, id int) func() (interface{}, error) {
+	load := requestFromContext(ctx).schools.Load(ctx, id)
+	return func() (interface{}, error) {
+		school, err := load()
+		if school == nil {
+			return nil, err
+		}
+		return school, nil
+	}
+}
+
+// schoolIDs converts the identity's school scope to IDs, skipping any that
+// are not numeric
+func schoolIDs(identity *shared.Identity) []int {
+	ids := make([]int, 0, len(identity.SchoolIDs))
+	for _, raw := range identity.SchoolIDs {
+		if id, err := strconv.Atoi(raw); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,190 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+// School mirrors the school service's school
+type School struct {
+	ID                 int       `json:"id"`
+	RegistrationNumber string    `json:"registration_number"`
+	Name               string    `json:"name"`
+	Address            string    `json:"address"`
+	Phone              string    `json:"phone"`
+	Email              string    `json:"email"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Student mirrors the student service's student
+type Student struct {
+	ID             int       `json:"id"`
+	RollNumber     string    `json:"roll_number"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	Email          string    `json:"email"`
+	Phone          string    `json:"phone"`
+	DateOfBirth    string    `json:"date_of_birth"`
+	Address        string    `json:"address"`
+	SchoolID       int       `json:"school_id"`
+	EnrollmentDate string    `json:"enrollment_date"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SchoolPage is a page of the v2 school list
+type SchoolPage struct {
+	Items      []School          `json:"items"`
+	Pagination shared.Pagination `json:"pagination"`
+}
+
+// StudentPage is a page of the v2 student list
+type StudentPage struct {
+	Items      []Student         `json:"items"`
+	Pagination shared.Pagination `json:"pagination"`
+}
+
+// Error is a failed service call. Its code is reported in the extensions of
+// the GraphQL error, like the error field of a REST error response.
+type Error struct {
+	Code    string
+	Message string
+	status  int
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions implements gqlerrors.ExtendedError
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
+
+// Services fetches resolver data from the services through the gateway, so
+// calls carry the caller's identity and share the circuit breakers and
+// bulkheads of proxied requests
+type Services struct {
+	gateway *gateway.Gateway
+}
+
+// NewServices creates the resolvers' data source
+func NewServices(gw *gateway.Gateway) *Services {
+	return &Services{gateway: gw}
+}
+
+// SchoolsByIDs fetches several schools in one call, keyed by ID
+func (s *Services) SchoolsByIDs(ctx context.Context, ids []int) (map[int]*School, error) {
+	var schools []School
+	if err := s.get(ctx, s.gateway.GetSchoolServiceURL(), shared.APIVersion1, "/schools?ids="+joinIDs(ids), &schools); err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*School, len(schools))
+	for i := range schools {
+		byID[schools[i].ID] = &schools[i]
+	}
+	return byID, nil
+}
+
+// StudentsBySchoolIDs fetches the students of several schools in one call,
+// keyed by school ID
+func (s *Services) StudentsBySchoolIDs(ctx context.Context, schoolIDs []int) (map[int][]Student, error) {
+	var students []Student
+	if err := s.get(ctx, s.gateway.GetStudentServiceURL(), shared.APIVersion1, "/students?school_ids="+joinIDs(schoolIDs), &students); err != nil {
+		return nil, err
+	}
+	bySchool := make(map[int][]Student, len(schoolIDs))
+	for _, student := range students {
+		bySchool[student.SchoolID] = append(bySchool[student.SchoolID], student)
+	}
+	return bySchool, nil
+}
+
+// Schools fetches a page of schools
+func (s *Services) Schools(ctx context.Context, page shared.PageRequest) (*SchoolPage, error) {
+	var result SchoolPage
+	if err := s.get(ctx, s.gateway.GetSchoolServiceURL(), shared.APIVersion2, "/schools?"+pageQuery(page), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Students fetches a page of students across every school
+func (s *Services) Students(ctx context.Context, page shared.PageRequest) (*StudentPage, error) {
+	var result StudentPage
+	if err := s.get(ctx, s.gateway.GetStudentServiceURL(), shared.APIVersion2, "/students?"+pageQuery(page), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Student fetches one student, or nil if there is none with the ID
+func (s *Services) Student(ctx context.Context, id int) (*Student, error) {
+	var student Student
+	err := s.get(ctx, s.gateway.GetStudentServiceURL(), shared.APIVersion1, "/students/"+strconv.Itoa(id), &student)
+	if err != nil {
+		if e, ok := err.(*Error); ok && e.status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &student, nil
+}
+
+// get calls a service with the request's identity headers and decodes the
+// data field of its success envelope into out
+func (s *Services) get(ctx context.Context, serviceURL, version, path string, out any) error {
+	header := requestFromContext(ctx).header.Clone()
+	header.Set(shared.HeaderAcceptVersion, version)
+
+	resp, err := s.gateway.Get(ctx, serviceURL, path, header)
+	if err != nil {
+		status, code, message := gateway.UpstreamError(err)
+		slog.WarnContext(ctx, "graphql service call failed", "path", path, "error", err)
+		return &Error{Code: code, Message: message, status: status}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var upstream shared.ErrorResponse
+		if json.Unmarshal(resp.Body, &upstream) != nil || upstream.Error == "" {
+			upstream = shared.ErrorResponse{Error: "SERVICE_ERROR", Message: fmt.Sprintf("Service returned status %d", resp.StatusCode)}
+		}
+		return &Error{Code: upstream.Error, Message: upstream.Message, status: resp.StatusCode}
+	}
+
+	envelope := struct {
+		Data any `json:"data"`
+	}{Data: out}
+	if err := json.Unmarshal(resp.Body, &envelope); err != nil {
+		slog.WarnContext(ctx, "graphql service call returned invalid JSON", "path", path, "error", err)
+		return &Error{Code: "SERVICE_ERROR", Message: "Service returned an invalid response", status: http.StatusBadGateway}
+	}
+	return nil
+}
+
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+func pageQuery(page shared.PageRequest) string {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page.Page))
+	query.Set("page_size", strconv.Itoa(page.PageSize))
+	return query.Encode()
+}
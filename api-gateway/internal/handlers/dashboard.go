@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+// DashboardConfig bounds each branch of the dashboard endpoints. A branch
+// that runs out of time is reported as missing instead of holding up the
+// others.
+type DashboardConfig struct {
+	SchoolTimeout   time.Duration
+	StudentsTimeout time.Duration
+}
+
+// SchoolDashboard merges a school with the first page of its students. A
+// branch whose service failed is null and explained in Errors.
+type SchoolDashboard struct {
+	// School is the school service's school
+	School any `json:"school"`
+	// Students is a page of the student service's students with pagination
+	Students any                             `json:"students"`
+	Partial  bool                            `json:"partial"`
+	Errors   map[string]DashboardBranchError `json:"errors,omitempty"`
+}
+
+// DashboardBranchError explains why part of a dashboard is missing
+type DashboardBranchError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// dashboardBranch is one upstream call of a dashboard
+type dashboardBranch struct {
+	name    string
+	url     string
+	path    string
+	timeout time.Duration
+
+	data   json.RawMessage
+	status int
+	err    *DashboardBranchError
+}
+
+// HandleSchoolDashboard serves GET /dashboard/schools/{id}. It fetches the
+// school and a page of its students concurrently; when one service is down
+// or slow the other's data is still returned with "partial": true.
+func (h *Handlers) HandleSchoolDashboard(w http.ResponseWriter, r *http.Request) {
+	schoolID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/dashboard/schools/"))
+	if err != nil || schoolID <= 0 {
+		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid school ID")
+		return
+	}
+	page, fields := shared.ParsePageRequest(r.URL.Query())
+	if len(fields) > 0 {
+		shared.WriteValidationErrorResponse(w, fields)
+		return
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page.Page))
+	query.Set("page_size", strconv.Itoa(page.PageSize))
+	school := &dashboardBranch{
+		name:    "school",
+		url:     h.gateway.GetSchoolServiceURL(),
+		path:    fmt.Sprintf("/schools/%d", schoolID),
+		timeout: h.dashboard.SchoolTimeout,
+	}
+	students := &dashboardBranch{
+		name:    "students",
+		url:     h.gateway.GetStudentServiceURL(),
+		path:    fmt.Sprintf("/students/school/%d?%s", schoolID, query.Encode()),
+		timeout: h.dashboard.StudentsTimeout,
+	}
+	branches := []*dashboardBranch{school, students}
+
+	// The students branch needs the paginated v2 list whatever version the
+	// dashboard was called with
+	header := r.Header.Clone()
+	header.Set(shared.HeaderAcceptVersion, shared.APIVersion2)
+	header.Del("If-None-Match")
+
+	var wg sync.WaitGroup
+	for _, branch := range branches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.fetchDashboardBranch(r.Context(), branch, header)
+		}()
+	}
+	wg.Wait()
+
+	// A client error, such as an unknown school or a forbidden scope, is the
+	// answer to the whole request
+	for _, branch := range branches {
+		if branch.err != nil && branch.status >= 400 && branch.status < 500 {
+			shared.WriteErrorResponse(w, branch.status, branch.err.Error, branch.err.Message)
+			return
+		}
+	}
+
+	dashboard := SchoolDashboard{}
+	for _, branch := range branches {
+		if branch.err != nil {
+			if dashboard.Errors == nil {
+				dashboard.Errors = make(map[string]DashboardBranchError)
+			}
+			dashboard.Errors[branch.name] = *branch.err
+			dashboard.Partial = true
+		}
+	}
+	if len(dashboard.Errors) == len(branches) {
+		shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "DASHBOARD_UNAVAILABLE", "No dashboard data is available right now")
+		return
+	}
+	if school.err == nil {
+		dashboard.School = school.data
+	}
+	if students.err == nil {
+		dashboard.Students = students.data
+	}
+
+	if dashboard.Partial {
+		// Degraded responses must not outlive the outage in any cache
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	shared.WriteSuccessResponse(w, http.StatusOK, "Dashboard retrieved successfully", dashboard)
+}
+
+// fetchDashboardBranch calls the branch's service within its timeout and
+// records either the response data or why it is missing
+func (h *Handlers) fetchDashboardBranch(ctx context.Context, branch *dashboardBranch, header http.Header) {
+	ctx, cancel := context.WithTimeout(ctx, branch.timeout)
+	defer cancel()
+
+	resp, err := h.gateway.Get(ctx, branch.url, branch.path, header)
+	if err != nil {
+		branch.status, branch.err = dashboardBranchError(err, branch.timeout)
+		slog.WarnContext(ctx, "dashboard branch failed", "branch", branch.name, "error", err)
+		return
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var upstream shared.ErrorResponse
+		if json.Unmarshal(resp.Body, &upstream) != nil || upstream.Error == "" {
+			upstream = shared.ErrorResponse{Error: "SERVICE_ERROR", Message: fmt.Sprintf("Service returned status %d", resp.StatusCode)}
+		}
+		branch.status = resp.StatusCode
+		branch.err = &DashboardBranchError{Error: upstream.Error, Message: upstream.Message}
+		return
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body, &envelope); err != nil {
+		slog.WarnContext(ctx, "dashboard branch returned invalid JSON", "branch", branch.name, "error", err)
+		branch.status = http.StatusBadGateway
+		branch.err = &DashboardBranchError{Error: "SERVICE_ERROR", Message: "Service returned an invalid response"}
+		return
+	}
+	branch.status, branch.data = resp.StatusCode, envelope.Data
+}
+
+// dashboardBranchError maps a failed upstream call to the error reported in
+// the dashboard, using the codes the proxy would have answered with
+func dashboardBranchError(err error, timeout time.Duration) (int, *DashboardBranchError) {
+	status, code, message := gateway.UpstreamError(err)
+	if code == "UPSTREAM_TIMEOUT" {
+		message = fmt.Sprintf("Service did not respond within %s", timeout)
+	}
+	return status, &DashboardBranchError{Error: code, Message: message}
+}
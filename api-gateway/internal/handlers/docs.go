@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"skool-management/shared"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// HandleOpenAPI serves the aggregated OpenAPI document
+func (h *Handlers) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	shared.WriteJSONResponse(w, http.StatusOK, h.specs.Document(r.Context()))
+}
+
+// HandleDocs serves the interactive API documentation, rendered in the
+// browser from the aggregated OpenAPI document
+func (h *Handlers) HandleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(docsPage)
+}
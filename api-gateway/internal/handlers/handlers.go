@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"path"
 	"strings"
 	"time"
 
@@ -11,21 +14,45 @@ import (
 )
 
 type Handlers struct {
-	gateway *gateway.Gateway
+	gateway            *gateway.Gateway
+	healthClient       *http.Client
+	healthCheckTimeout time.Duration
+	specs              *gateway.SpecAggregator
+	dashboard          DashboardConfig
 }
 
-func New(gw *gateway.Gateway) *Handlers {
+func New(gw *gateway.Gateway, healthCheckTimeout time.Duration, specs *gateway.SpecAggregator, dashboard DashboardConfig) *Handlers {
 	return &Handlers{
-		gateway: gw,
+		gateway:            gw,
+		healthClient:       &http.Client{Transport: gw.Transport()},
+		healthCheckTimeout: healthCheckTimeout,
+		specs:              specs,
+		dashboard:          dashboard,
 	}
 }
 
+// publicAuthPaths are the auth service routes the gateway forwards. The
+// service's probes, metrics, spec and breaker admin stay internal.
+var publicAuthPaths = []string{"/signup", "/login", "/refresh", "/validate", "/users"}
+
+func isPublicAuthPath(p string) bool {
+	for _, public := range publicAuthPaths {
+		if p == public || strings.HasPrefix(p, public+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // Auth service routes
 func (h *Handlers) HandleAuth(w http.ResponseWriter, r *http.Request) {
-	// Remove /auth prefix from path
-	r.URL.Path = strings.TrimPrefix(r.URL.Path, "/auth")
-	if r.URL.Path == "" {
-		r.URL.Path = "/"
+	// Remove /auth prefix from path; cleaning it keeps dot segments from
+	// reaching the internal routes
+	r.URL.Path = path.Clean("/" + strings.TrimPrefix(r.URL.Path, "/auth"))
+	r.URL.RawPath = ""
+	if !isPublicAuthPath(r.URL.Path) {
+		shared.WriteErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Endpoint not found")
+		return
 	}
 	h.gateway.ProxyRequest(h.gateway.GetAuthServiceURL(), w, r)
 }
@@ -42,185 +69,68 @@ func (h *Handlers) HandleStudents(w http.ResponseWriter, r *http.Request) {
 	h.gateway.ProxyRequest(h.gateway.GetStudentServiceURL(), w, r)
 }
 
-// Health check endpoint
+// Health check endpoint. Probes every service's /readyz in parallel and
+// returns a single aggregated report.
 func (h *Handlers) HandleHealth(w http.ResponseWriter, r *http.Request) {
-	// Check health of all services
-	services := map[string]string{
-		"auth":    h.gateway.GetAuthServiceURL() + "/health",
-		"school":  h.gateway.GetSchoolServiceURL() + "/health",
-		"student": h.gateway.GetStudentServiceURL() + "/health",
+	services := []struct {
+		name           string
+		url            string
+		circuitBreaker *shared.CircuitBreaker
+	}{
+		{"auth", h.gateway.GetAuthServiceURL(), h.gateway.GetAuthCircuitBreaker()},
+		{"school", h.gateway.GetSchoolServiceURL(), h.gateway.GetSchoolCircuitBreaker()},
+		{"student", h.gateway.GetStudentServiceURL(), h.gateway.GetStudentCircuitBreaker()},
 	}
 
-	healthStatus := make(map[string]interface{})
-	allHealthy := true
-
-	client := &http.Client{Timeout: 5 * time.Second}
-
-	for service, url := range services {
-		var serviceHealth map[string]interface{}
-
-		resp, err := client.Get(url)
-		if err != nil || resp.StatusCode != http.StatusOK {
-			serviceHealth = map[string]interface{}{
-				"status": "unhealthy",
-				"error":  err.Error(),
-			}
-			allHealthy = false
-		} else {
-			serviceHealth = map[string]interface{}{
-				"status": "healthy",
-			}
-		}
-
-		// Add circuit breaker status
-		var circuitBreaker *shared.CircuitBreaker
-		switch service {
-		case "auth":
-			circuitBreaker = h.gateway.GetAuthCircuitBreaker()
-		case "school":
-			circuitBreaker = h.gateway.GetSchoolCircuitBreaker()
-		case "student":
-			circuitBreaker = h.gateway.GetStudentCircuitBreaker()
-		}
-
-		if circuitBreaker != nil {
-			serviceHealth["circuit_breaker"] = map[string]interface{}{
-				"state":         circuitBreaker.GetState().String(),
-				"failure_count": circuitBreaker.GetFailureCount(),
-			}
-		}
+	checks := make([]shared.HealthCheck, 0, len(services))
+	for _, service := range services {
+		checks = append(checks, shared.HealthCheck{
+			Name:    service.name,
+			Timeout: h.healthCheckTimeout,
+			Check: func(ctx context.Context) error {
+				return h.probeReadiness(ctx, service.url+"/readyz")
+			},
+		})
+	}
 
-		healthStatus[service] = serviceHealth
+	report := shared.RunHealthChecks(r.Context(), checks)
 
-		if resp != nil {
-			resp.Body.Close()
+	// Add circuit breaker status
+	for _, service := range services {
+		result := report.Checks[service.name]
+		result.Details = map[string]interface{}{
+			"circuit_breaker": shared.NewCircuitBreakerView(service.circuitBreaker),
 		}
+		report.Checks[service.name] = result
 	}
 
-	healthStatus["gateway"] = map[string]interface{}{
-		"status": "healthy",
-	}
-
-	if allHealthy {
-		shared.WriteSuccessResponse(w, http.StatusOK, "All services are healthy", healthStatus)
-	} else {
-		shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "PARTIAL_OUTAGE", "Some services are unhealthy")
-		// Still include the health status in the response
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"error":   "PARTIAL_OUTAGE",
-			"message": "Some services are unhealthy",
-			"data":    healthStatus,
-		})
-	}
+	shared.WriteHealthReport(w, report)
 }
 
-// API documentation endpoint
-func (h *Handlers) HandleDocs(w http.ResponseWriter, r *http.Request) {
-	docs := map[string]interface{}{
-		"title":       "School Management API Gateway",
-		"version":     "1.0.0",
-		"description": "API Gateway for School Management Microservices",
-		"endpoints": map[string]interface{}{
-			"auth": map[string]interface{}{
-				"signup": map[string]string{
-					"method":      "POST",
-					"path":        "/auth/signup",
-					"description": "Register a new user",
-				},
-				"login": map[string]string{
-					"method":      "POST",
-					"path":        "/auth/login",
-					"description": "Login user and get JWT tokens",
-				},
-				"refresh": map[string]string{
-					"method":      "POST",
-					"path":        "/auth/refresh",
-					"description": "Refresh JWT access token",
-				},
-				"validate": map[string]string{
-					"method":      "POST",
-					"path":        "/auth/validate",
-					"description": "Validate JWT token",
-				},
-			},
-			"schools": map[string]interface{}{
-				"list": map[string]string{
-					"method":      "GET",
-					"path":        "/schools",
-					"description": "Get all schools",
-					"auth":        "required",
-				},
-				"create": map[string]string{
-					"method":      "POST",
-					"path":        "/schools",
-					"description": "Create new school",
-					"auth":        "required",
-				},
-				"get": map[string]string{
-					"method":      "GET",
-					"path":        "/schools/{id}",
-					"description": "Get school by ID",
-					"auth":        "required",
-				},
-				"update": map[string]string{
-					"method":      "PUT",
-					"path":        "/schools/{id}",
-					"description": "Update school",
-					"auth":        "required",
-				},
-				"delete": map[string]string{
-					"method":      "DELETE",
-					"path":        "/schools/{id}",
-					"description": "Delete school",
-					"auth":        "required",
-				},
-			},
-			"students": map[string]interface{}{
-				"list": map[string]string{
-					"method":      "GET",
-					"path":        "/students",
-					"description": "Get all students",
-					"auth":        "required",
-				},
-				"create": map[string]string{
-					"method":      "POST",
-					"path":        "/students",
-					"description": "Create new student",
-					"auth":        "required",
-				},
-				"get": map[string]string{
-					"method":      "GET",
-					"path":        "/students/{id}",
-					"description": "Get student by ID",
-					"auth":        "required",
-				},
-				"update": map[string]string{
-					"method":      "PUT",
-					"path":        "/students/{id}",
-					"description": "Update student",
-					"auth":        "required",
-				},
-				"delete": map[string]string{
-					"method":      "DELETE",
-					"path":        "/students/{id}",
-					"description": "Delete student",
-					"auth":        "required",
-				},
-				"by_school": map[string]string{
-					"method":      "GET",
-					"path":        "/students/school/{school_id}",
-					"description": "Get students by school ID",
-					"auth":        "required",
-				},
-			},
-		},
-		"authentication": map[string]interface{}{
-			"type":        "Bearer Token (JWT)",
-			"header":      "Authorization: Bearer <token>",
-			"description": "Include JWT token in Authorization header for protected endpoints",
-		},
+// probeReadiness fails unless the service answers its readiness endpoint with 200
+func (h *Handlers) probeReadiness(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
 	}
 
-	shared.WriteSuccessResponse(w, http.StatusOK, "API Documentation", docs)
+	resp, err := h.healthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Surface the failing dependencies reported by the service
+		var report shared.HealthReport
+		if json.NewDecoder(resp.Body).Decode(&report) == nil {
+			for name, check := range report.Checks {
+				if check.Status != shared.StatusHealthy {
+					return fmt.Errorf("readiness returned %d: %s: %s", resp.StatusCode, name, check.Error)
+				}
+			}
+		}
+		return fmt.Errorf("readiness returned %d", resp.StatusCode)
+	}
+	return nil
 }
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+func TestHandleAuthForwardsPublicRoutesOnly(t *testing.T) {
+	var forwarded []string
+	authService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = append(forwarded, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer authService.Close()
+
+	gw := gateway.New(authService.URL, "http://school", "http://student", shared.BulkheadConfig{MaxConcurrent: 1}, nil)
+	h := New(gw, time.Second, nil, DashboardConfig{})
+
+	tests := []struct {
+		method   string
+		path     string
+		wantCode int
+	}{
+		{http.MethodPost, "/auth/signup", http.StatusOK},
+		{http.MethodPost, "/auth/login", http.StatusOK},
+		{http.MethodPost, "/auth/refresh", http.StatusOK},
+		{http.MethodGet, "/auth/validate", http.StatusOK},
+		{http.MethodGet, "/auth/users", http.StatusOK},
+		{http.MethodDelete, "/auth/users/7", http.StatusOK},
+		{http.MethodGet, "/auth/metrics", http.StatusNotFound},
+		{http.MethodGet, "/auth/readyz", http.StatusNotFound},
+		{http.MethodGet, "/auth/livez", http.StatusNotFound},
+		{http.MethodGet, "/auth/health", http.StatusNotFound},
+		{http.MethodGet, "/auth" + shared.OpenAPIPath, http.StatusNotFound},
+		{http.MethodPost, "/auth" + shared.CircuitBreakerAdminPath + "/auth-service/reset", http.StatusNotFound},
+		{http.MethodGet, "/auth/", http.StatusNotFound},
+		{http.MethodGet, "/auth/users/../metrics", http.StatusNotFound},
+		{http.MethodGet, "/auth/signups", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		forwarded = nil
+		rec := httptest.NewRecorder()
+		h.HandleAuth(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, rec.Code, tt.wantCode)
+		}
+		if wantForwarded := tt.wantCode == http.StatusOK; (len(forwarded) == 1) != wantForwarded {
+			t.Errorf("%s %s forwarded %v", tt.method, tt.path, forwarded)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"skool-management/shared"
+)
+
+// Cache result values reported in X-Cache and the response cache metrics
+const (
+	cacheHit         = "HIT"
+	cacheMiss        = "MISS"
+	cacheRevalidated = "REVALIDATED"
+	cacheBypass      = "BYPASS"
+)
+
+// Headers that describe one exchange rather than the resource, so a stored
+// entry never repeats them. Hop-by-hop headers named in Connection are
+// dropped as well.
+var cacheSkippedHeaders = []string{
+	"Age", "Date", "Set-Cookie", "X-Cache", "X-Request-Id",
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// CacheRule opts a GET route into the response cache
+type CacheRule struct {
+	// Path is a template such as "/schools/{id}"
+	Path string
+	// TTL caps how long a response is served without asking the service
+	// again; a shorter max-age from the service wins
+	TTL time.Duration
+	// PerUser keys entries by user instead of by role and school scope and
+	// allows responses the service marks private to be stored
+	PerUser bool
+}
+
+// ResponseCache serves GET responses for the routes in its rules from a
+// shared.ResponseCache. Entries are keyed by the caller's scope so users of
+// one school never see another school's responses, honour the service's
+// Cache-Control and ETag headers, and are dropped when a write succeeds on
+// the same resource. Wrap it inside Auth so the identity is known.
+type ResponseCache struct {
+	store *shared.ResponseCache
+	rules []CacheRule
+}
+
+// NewResponseCache creates the middleware for rules backed by store
+func NewResponseCache(store *shared.ResponseCache, rules []CacheRule) *ResponseCache {
+	return &ResponseCache{store: store, rules: rules}
+}
+
+// Cache middleware
+func (c *ResponseCache) Cache(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			recorder := &writeStatusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next(recorder, r)
+			if recorder.status < 400 {
+				if removed := c.store.InvalidatePath(r.URL.Path); removed > 0 {
+					slog.DebugContext(r.Context(), "response cache invalidated", "path", r.URL.Path, "entries", removed)
+				}
+			}
+			return
+		default:
+			next(w, r)
+			return
+		}
+
+		rule, ok := c.match(r.URL.Path)
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		requestDirectives := parseCacheControl(r.Header.Get("Cache-Control"))
+		if requestDirectives.has("no-store") {
+			c.store.RecordResult("bypass")
+			w.Header().Set("X-Cache", cacheBypass)
+			next(w, r)
+			return
+		}
+
+		identity, _ := shared.IdentityFromContext(r.Context())
+		key := responseCacheKey(r, identity, rule)
+		clientETag := r.Header.Get("If-None-Match")
+		now := time.Now()
+
+		entry, found := c.store.Get(key)
+		revalidate := requestDirectives.has("no-cache") || r.Header.Get("Pragma") == "no-cache"
+		if found && entry.Fresh(now) && !revalidate {
+			c.store.RecordResult("hit")
+			writeCachedResponse(w, entry, cacheHit, clientETag, now)
+			return
+		}
+
+		// Conditional headers from the client are answered here; upstream is
+		// only asked to confirm the entry the gateway already holds
+		r.Header.Del("If-None-Match")
+		r.Header.Del("If-Modified-Since")
+		if found && entry.ETag != "" {
+			r.Header.Set("If-None-Match", entry.ETag)
+		}
+
+		recorder := newBufferedResponse()
+		next(recorder, r)
+
+		if found && entry.ETag != "" && recorder.status == http.StatusNotModified {
+			refreshed := *entry
+			refreshed.StoredAt = now
+			refreshed.Expires = now.Add(freshnessLifetime(recorder.header, rule))
+			c.store.Set(key, r.URL.Path, &refreshed)
+			c.store.RecordResult("revalidated")
+			writeCachedResponse(w, &refreshed, cacheRevalidated, clientETag, now)
+			return
+		}
+
+		result := cacheBypass
+		if recorder.status == http.StatusOK && storable(recorder.header, rule) {
+			resp := &shared.CachedResponse{
+				Status:   recorder.status,
+				Header:   storedHeader(recorder.header),
+				Body:     recorder.body.Bytes(),
+				ETag:     recorder.header.Get("ETag"),
+				StoredAt: now,
+				Expires:  now.Add(freshnessLifetime(recorder.header, rule)),
+			}
+			// An entry that is already stale is only useful when it can be revalidated
+			if resp.Fresh(now) || resp.ETag != "" {
+				if c.store.Set(key, r.URL.Path, resp) {
+					result = cacheMiss
+				}
+			}
+		}
+		c.store.RecordResult(strings.ToLower(result))
+
+		for name, values := range recorder.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("X-Cache", result)
+		if recorder.status == http.StatusOK && clientETag != "" && shared.ETagMatches(clientETag, recorder.header.Get("ETag")) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(recorder.status)
+		w.Write(recorder.body.Bytes())
+	}
+}
+
+func (c *ResponseCache) match(path string) (CacheRule, bool) {
+	for _, rule := range c.rules {
+		if rule.TTL <= 0 {
+			continue
+		}
+		if _, ok := shared.MatchPath(rule.Path, path); ok {
+			return rule, true
+		}
+	}
+	return CacheRule{}, false
+}
+
+// responseCacheKey identifies a response by path, normalised query and the
+// scope the services authorise on: the user for per-user rules, otherwise the
+// roles and schools of the caller
+func responseCacheKey(r *http.Request, identity *shared.Identity, rule CacheRule) string {
+	var key strings.Builder
+	// Versions can answer the same path with different shapes
+	key.WriteString(shared.APIVersionFromContext(r.Context()))
+	key.WriteString(" ")
+	key.WriteString(r.URL.Path)
+	if query := r.URL.Query(); len(query) > 0 {
+		key.WriteString("?")
+		key.WriteString(query.Encode())
+	}
+	key.WriteString("|")
+
+	switch {
+	case identity == nil:
+		key.WriteString("anonymous")
+	case rule.PerUser:
+		key.WriteString("user=")
+		key.WriteString(url.QueryEscape(identity.UserID))
+	default:
+		roles := append([]string(nil), identity.Roles...)
+		schools := append([]string(nil), identity.SchoolIDs...)
+		sort.Strings(roles)
+		sort.Strings(schools)
+		key.WriteString("roles=")
+		key.WriteString(strings.Join(roles, ","))
+		key.WriteString("&schools=")
+		key.WriteString(strings.Join(schools, ","))
+	}
+	return key.String()
+}
+
+// storable reports whether the service allows the gateway to keep a response
+func storable(header http.Header, rule CacheRule) bool {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if directives.has("no-store") || (directives.has("private") && !rule.PerUser) {
+		return false
+	}
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	for _, vary := range header.Values("Vary") {
+		if strings.Contains(vary, "*") {
+			return false
+		}
+	}
+	return true
+}
+
+// storedHeader copies the headers of a response worth keeping in an entry
+func storedHeader(header http.Header) http.Header {
+	stored := header.Clone()
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			stored.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range cacheSkippedHeaders {
+		stored.Del(name)
+	}
+	return stored
+}
+
+// freshnessLifetime is the rule TTL shortened by the service's s-maxage or
+// max-age. no-cache makes every use of the entry a revalidation.
+func freshnessLifetime(header http.Header, rule CacheRule) time.Duration {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if directives.has("no-cache") {
+		return 0
+	}
+	ttl := rule.TTL
+	for _, name := range []string{"s-maxage", "max-age"} {
+		value, ok := directives[name]
+		if !ok {
+			continue
+		}
+		if seconds, err := strconv.Atoi(value); err == nil {
+			if maxAge := time.Duration(seconds) * time.Second; maxAge < ttl {
+				ttl = maxAge
+			}
+		}
+		break
+	}
+	return ttl
+}
+
+func writeCachedResponse(w http.ResponseWriter, entry *shared.CachedResponse, result, clientETag string, now time.Time) {
+	h := w.Header()
+	for name, values := range entry.Header {
+		h[name] = append([]string(nil), values...)
+	}
+	h.Set("X-Cache", result)
+	h.Set("Age", strconv.Itoa(int(now.Sub(entry.StoredAt).Seconds())))
+	if clientETag != "" && shared.ETagMatches(clientETag, entry.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(entry.Status)
+	w.Write(entry.Body)
+}
+
+type cacheControl map[string]string
+
+func parseCacheControl(value string) cacheControl {
+	directives := make(cacheControl)
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return directives
+}
+
+func (c cacheControl) has(name string) bool {
+	_, ok := c[name]
+	return ok
+}
+
+// bufferedResponse holds a response until the cache decides what to do with it
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	b.status = code
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// writeStatusRecorder passes a response through while noting its status
+type writeStatusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *writeStatusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *writeStatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
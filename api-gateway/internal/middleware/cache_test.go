@@ -0,0 +1,245 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"skool-management/shared"
+)
+
+// cacheTestUpstream answers GETs with the path, the caller and a call count,
+// tagging each body with an ETag, and counts the requests it receives
+type cacheTestUpstream struct {
+	calls       int
+	header      http.Header
+	notModified bool
+	lastINM     string
+}
+
+func (u *cacheTestUpstream) serve(w http.ResponseWriter, r *http.Request) {
+	u.calls++
+	if r.Method != http.MethodGet {
+		if r.Header.Get("X-Fail") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	u.lastINM = r.Header.Get("If-None-Match")
+	for name, values := range u.header {
+		w.Header()[name] = values
+	}
+	identity, _ := shared.IdentityFromContext(r.Context())
+	etag := `"` + r.URL.Path + `"`
+	w.Header().Set("ETag", etag)
+	if u.notModified && u.lastINM == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	fmt.Fprintf(w, "%s for %s #%d", r.URL.Path, identity.UserID, u.calls)
+}
+
+func newCacheTest(t *testing.T, rules ...CacheRule) (*cacheTestUpstream, func(method, path string, identity *shared.Identity, headers ...string) *httptest.ResponseRecorder) {
+	if len(rules) == 0 {
+		rules = []CacheRule{{Path: "/schools", TTL: time.Minute}, {Path: "/schools/{id}", TTL: time.Minute}}
+	}
+	upstream := &cacheTestUpstream{}
+	cache := NewResponseCache(shared.NewResponseCache(shared.ResponseCacheConfig{Name: "test-" + t.Name()}), rules)
+	handler := cache.Cache(upstream.serve)
+	return upstream, func(method, path string, identity *shared.Identity, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		req = req.WithContext(shared.WithIdentity(req.Context(), identity))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+}
+
+var (
+	cacheTestAdmin   = &shared.Identity{UserID: "admin", Roles: []string{shared.RoleAdmin}}
+	cacheTestSchool1 = &shared.Identity{UserID: "u1", Roles: []string{shared.RoleUser}, SchoolIDs: []string{"1"}}
+	cacheTestSchool2 = &shared.Identity{UserID: "u2", Roles: []string{shared.RoleUser}, SchoolIDs: []string{"2"}}
+)
+
+func TestResponseCacheIsolatesScopes(t *testing.T) {
+	upstream, get := newCacheTest(t)
+
+	tests := []struct {
+		name     string
+		identity *shared.Identity
+		want     string
+		wantHit  string
+	}{
+		{"first school", cacheTestSchool1, "/schools for u1 #1", cacheMiss},
+		{"other school", cacheTestSchool2, "/schools for u2 #2", cacheMiss},
+		{"admin", cacheTestAdmin, "/schools for admin #3", cacheMiss},
+		// Users with the same roles and schools share entries
+		{"same scope", &shared.Identity{UserID: "u3", Roles: []string{shared.RoleUser}, SchoolIDs: []string{"1"}}, "/schools for u1 #1", cacheHit},
+		{"other school again", cacheTestSchool2, "/schools for u2 #2", cacheHit},
+	}
+	for _, tt := range tests {
+		rec := get(http.MethodGet, "/schools", tt.identity)
+		if rec.Body.String() != tt.want || rec.Header().Get("X-Cache") != tt.wantHit {
+			t.Errorf("%s: got %q (%s), want %q (%s)", tt.name, rec.Body, rec.Header().Get("X-Cache"), tt.want, tt.wantHit)
+		}
+	}
+	if upstream.calls != 3 {
+		t.Errorf("upstream called %d times, want 3", upstream.calls)
+	}
+}
+
+func TestResponseCachePerUserRules(t *testing.T) {
+	_, get := newCacheTest(t, CacheRule{Path: "/schools", TTL: time.Minute, PerUser: true})
+	get(http.MethodGet, "/schools", cacheTestSchool1)
+	sameScope := &shared.Identity{UserID: "u3", Roles: []string{shared.RoleUser}, SchoolIDs: []string{"1"}}
+	if rec := get(http.MethodGet, "/schools", sameScope); rec.Header().Get("X-Cache") != cacheMiss {
+		t.Errorf("another user with the same scope got %s, want MISS", rec.Header().Get("X-Cache"))
+	}
+}
+
+func TestResponseCacheInvalidatesOnWrites(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		fail        bool
+		wantEvicted []string
+		wantKept    []string
+	}{
+		{"create", http.MethodPost, "/schools", false, []string{"/schools", "/schools/5"}, nil},
+		{"update", http.MethodPut, "/schools/5", false, []string{"/schools", "/schools/5"}, []string{"/schools/6"}},
+		{"delete", http.MethodDelete, "/schools/6", false, []string{"/schools", "/schools/6"}, []string{"/schools/5"}},
+		{"failed write", http.MethodPut, "/schools/5", true, nil, []string{"/schools", "/schools/5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, get := newCacheTest(t)
+			for _, path := range []string{"/schools", "/schools/5", "/schools/6"} {
+				get(http.MethodGet, path, cacheTestSchool1)
+			}
+
+			var headers []string
+			if tt.fail {
+				headers = []string{"X-Fail", "1"}
+			}
+			get(tt.method, tt.path, cacheTestSchool1, headers...)
+
+			for _, path := range tt.wantEvicted {
+				if got := get(http.MethodGet, path, cacheTestSchool1).Header().Get("X-Cache"); got != cacheMiss {
+					t.Errorf("GET %s after %s %s: %s, want MISS", path, tt.method, tt.path, got)
+				}
+			}
+			for _, path := range tt.wantKept {
+				if got := get(http.MethodGet, path, cacheTestSchool1).Header().Get("X-Cache"); got != cacheHit {
+					t.Errorf("GET %s after %s %s: %s, want HIT", path, tt.method, tt.path, got)
+				}
+			}
+		})
+	}
+
+	// Writes evict the entries of every scope
+	_, get := newCacheTest(t)
+	get(http.MethodGet, "/schools/5", cacheTestSchool1)
+	get(http.MethodGet, "/schools/5", cacheTestAdmin)
+	get(http.MethodPut, "/schools/5", cacheTestAdmin)
+	for _, identity := range []*shared.Identity{cacheTestSchool1, cacheTestAdmin} {
+		if got := get(http.MethodGet, "/schools/5", identity).Header().Get("X-Cache"); got != cacheMiss {
+			t.Errorf("%s after another user's write: %s, want MISS", identity.UserID, got)
+		}
+	}
+}
+
+func TestResponseCacheRevalidates(t *testing.T) {
+	upstream, get := newCacheTest(t)
+	// max-age=0 keeps the entry only for revalidation
+	upstream.header = http.Header{"Cache-Control": {"max-age=0"}}
+	upstream.notModified = true
+
+	first := get(http.MethodGet, "/schools/5", cacheTestSchool1)
+	if first.Header().Get("X-Cache") != cacheMiss {
+		t.Fatalf("first request: %s", first.Header().Get("X-Cache"))
+	}
+
+	// The client's own validator is not forwarded; the gateway asks about its entry
+	rec := get(http.MethodGet, "/schools/5", cacheTestSchool1, "If-None-Match", `"other"`)
+	if upstream.lastINM != `"/schools/5"` {
+		t.Errorf("upstream If-None-Match = %q, want the entry's ETag", upstream.lastINM)
+	}
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != cacheRevalidated || rec.Body.String() != first.Body.String() {
+		t.Errorf("revalidated = %d %s %q, want the stored body", rec.Code, rec.Header().Get("X-Cache"), rec.Body)
+	}
+
+	// A client holding the current version gets 304 without a body
+	rec = get(http.MethodGet, "/schools/5", cacheTestSchool1, "If-None-Match", `W/"/schools/5"`)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("conditional request = %d with %d bytes, want an empty 304", rec.Code, rec.Body.Len())
+	}
+
+	// Once the resource changes the service answers in full again
+	upstream.notModified = false
+	if rec := get(http.MethodGet, "/schools/5", cacheTestSchool1); rec.Header().Get("X-Cache") != cacheMiss || rec.Body.String() == first.Body.String() {
+		t.Errorf("changed resource = %s %q, want a fresh MISS", rec.Header().Get("X-Cache"), rec.Body)
+	}
+}
+
+func TestResponseCacheStoresResourceHeadersOnly(t *testing.T) {
+	upstream, get := newCacheTest(t)
+	upstream.header = http.Header{
+		"Date":         {"Mon, 01 Jan 2024 00:00:00 GMT"},
+		"X-Request-Id": {"first-request"},
+		"Connection":   {"X-Hop"},
+		"X-Hop":        {"1"},
+		"Content-Type": {"application/json"},
+	}
+	get(http.MethodGet, "/schools", cacheTestSchool1)
+
+	rec := get(http.MethodGet, "/schools", cacheTestSchool1)
+	if rec.Header().Get("X-Cache") != cacheHit {
+		t.Fatalf("second request: %s", rec.Header().Get("X-Cache"))
+	}
+	for _, name := range []string{"Date", "X-Request-Id", "Connection", "X-Hop"} {
+		if value := rec.Header().Get(name); value != "" {
+			t.Errorf("hit repeated %s: %q", name, value)
+		}
+	}
+	if rec.Header().Get("Content-Type") != "application/json" || rec.Header().Get("ETag") == "" {
+		t.Errorf("hit lost the resource headers: %v", rec.Header())
+	}
+}
+
+func TestResponseCacheBypass(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		requestCC    string
+	}{
+		{"service no-store", "no-store", ""},
+		{"service private", "private", ""},
+		{"client no-store", "", "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, get := newCacheTest(t)
+			if tt.cacheControl != "" {
+				upstream.header = http.Header{"Cache-Control": {tt.cacheControl}}
+			}
+			var headers []string
+			if tt.requestCC != "" {
+				headers = []string{"Cache-Control", tt.requestCC}
+			}
+			get(http.MethodGet, "/schools", cacheTestSchool1, headers...)
+			rec := get(http.MethodGet, "/schools", cacheTestSchool1, headers...)
+			if got := rec.Header().Get("X-Cache"); got != cacheBypass || !strings.HasSuffix(rec.Body.String(), "#2") {
+				t.Errorf("second request = %s %q, want BYPASS from the service", got, rec.Body)
+			}
+		})
+	}
+}
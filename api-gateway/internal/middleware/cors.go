@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"skool-management/shared"
+)
+
+// CORSConfig is the gateway's cross-origin resource sharing policy
+type CORSConfig struct {
+	// AllowedOrigins lists origins such as "https://portal.example.com".
+	// "*" allows any origin and "https://*.example.com" any subdomain.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers browsers may send; "*"
+	// allows whatever a preflight asks for
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and Authorization headers.
+	// The matching origin is echoed instead of "*", as browsers require.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight result
+	MaxAge time.Duration
+}
+
+type wildcardOrigin struct {
+	prefix string
+	suffix string
+}
+
+type corsPolicy struct {
+	allowAllOrigins bool
+	origins         map[string]bool
+	wildcards       []wildcardOrigin
+	methods         map[string]bool
+	allowMethods    string
+	allowAllHeaders bool
+	headers         map[string]bool
+	allowHeaders    string
+	exposeHeaders   string
+	credentials     bool
+	maxAge          string
+}
+
+func newCORSPolicy(config CORSConfig) *corsPolicy {
+	p := &corsPolicy{
+		origins:       make(map[string]bool),
+		methods:       make(map[string]bool),
+		headers:       make(map[string]bool),
+		exposeHeaders: strings.Join(config.ExposedHeaders, ", "),
+		credentials:   config.AllowCredentials,
+	}
+
+	for _, origin := range config.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			p.allowAllOrigins = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			p.wildcards = append(p.wildcards, wildcardOrigin{prefix: prefix, suffix: suffix})
+		default:
+			p.origins[origin] = true
+		}
+	}
+	if p.allowAllOrigins && p.credentials {
+		slog.Warn("CORS allows credentials from any origin; set CORS_ALLOWED_ORIGINS to the sites that need them")
+	}
+
+	methods := make([]string, 0, len(config.AllowedMethods))
+	for _, method := range config.AllowedMethods {
+		method = strings.ToUpper(method)
+		p.methods[method] = true
+		methods = append(methods, method)
+	}
+	p.allowMethods = strings.Join(methods, ", ")
+
+	headers := make([]string, 0, len(config.AllowedHeaders))
+	for _, header := range config.AllowedHeaders {
+		if header == "*" {
+			p.allowAllHeaders = true
+			continue
+		}
+		p.headers[strings.ToLower(header)] = true
+		headers = append(headers, header)
+	}
+	p.allowHeaders = strings.Join(headers, ", ")
+
+	if config.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+	return p
+}
+
+func (p *corsPolicy) allowsOrigin(origin string) bool {
+	if p.allowAllOrigins {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if len(origin) > len(w.prefix)+len(w.suffix) &&
+			strings.HasPrefix(origin, w.prefix) && strings.HasSuffix(origin, w.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders reports whether every header in a preflight's
+// Access-Control-Request-Headers list is allowed
+func (p *corsPolicy) allowsHeaders(requested string) bool {
+	if p.allowAllHeaders {
+		return true
+	}
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if header != "" && !p.headers[header] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *corsPolicy) setAllowOrigin(h http.Header, origin string) {
+	if p.allowAllOrigins && !p.credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// AllowsOrigin reports whether the CORS policy admits requests from the
+// Origin header of r. Requests without one, from non-browser clients, are
+// admitted. WebSocket handshakes are not subject to CORS, so the events
+// endpoint checks them with this.
+func (m *Middleware) AllowsOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || m.cors.allowsOrigin(origin)
+}
+
+// CORS middleware. Wrap the whole mux with it so every response, errors and
+// 404s included, carries the same policy. Preflights are answered here and
+// never reach the routes.
+func (m *Middleware) CORS(next http.HandlerFunc) http.HandlerFunc {
+	p := m.cors
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next(w, r)
+			return
+		}
+
+		h := w.Header()
+		// The response depends on the origin unless every origin gets "*"
+		if !p.allowAllOrigins || p.credentials {
+			h.Add("Vary", "Origin")
+		}
+		allowed := p.allowsOrigin(origin)
+
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method != http.MethodOptions || requestMethod == "" {
+			if allowed {
+				p.setAllowOrigin(h, origin)
+				if p.exposeHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", p.exposeHeaders)
+				}
+			}
+			next(w, r)
+			return
+		}
+
+		// Preflight
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		requestHeaders := r.Header.Get("Access-Control-Request-Headers")
+		switch {
+		case !allowed:
+			shared.WriteErrorResponse(w, http.StatusForbidden, "CORS_ORIGIN_NOT_ALLOWED", "Origin is not allowed")
+			return
+		case !p.methods[requestMethod]:
+			shared.WriteErrorResponse(w, http.StatusForbidden, "CORS_METHOD_NOT_ALLOWED", "Method is not allowed for cross-origin requests")
+			return
+		case !p.allowsHeaders(requestHeaders):
+			shared.WriteErrorResponse(w, http.StatusForbidden, "CORS_HEADER_NOT_ALLOWED", "Request headers are not allowed for cross-origin requests")
+			return
+		}
+
+		p.setAllowOrigin(h, origin)
+		h.Set("Access-Control-Allow-Methods", p.allowMethods)
+		if p.allowAllHeaders {
+			if requestHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", requestHeaders)
+			}
+		} else if p.allowHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", p.allowHeaders)
+		}
+		if p.maxAge != "" {
+			h.Set("Access-Control-Max-Age", p.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
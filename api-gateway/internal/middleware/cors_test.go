@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCORSTest(config CORSConfig) func(method string, headers ...string) (*httptest.ResponseRecorder, bool) {
+	m := New("secret", nil, time.Minute, config)
+	return func(method string, headers ...string) (*httptest.ResponseRecorder, bool) {
+		req := httptest.NewRequest(method, "/schools", nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		reached := false
+		rec := httptest.NewRecorder()
+		m.CORS(func(w http.ResponseWriter, r *http.Request) {
+			reached = true
+			w.WriteHeader(http.StatusOK)
+		})(rec, req)
+		return rec, reached
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          CORSConfig
+		origin          string
+		wantOrigin      string
+		wantCredentials bool
+		wantVary        bool
+	}{
+		{"listed", CORSConfig{AllowedOrigins: []string{"https://portal.example.com"}}, "https://portal.example.com", "https://portal.example.com", false, true},
+		{"listed in other case", CORSConfig{AllowedOrigins: []string{"https://Portal.example.com"}}, "https://portal.EXAMPLE.com", "https://portal.EXAMPLE.com", false, true},
+		{"not listed", CORSConfig{AllowedOrigins: []string{"https://portal.example.com"}}, "https://evil.example.org", "", false, true},
+		{"subdomain", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://a.b.example.com", "https://a.b.example.com", false, true},
+		{"bare domain", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://.example.com", "", false, true},
+		{"lookalike domain", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://evilexample.com", "", false, true},
+		{"other scheme", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "http://a.example.com", "", false, true},
+		// Only a "*" the same for every origin can be cached without Vary
+		{"any", CORSConfig{AllowedOrigins: []string{"*"}}, "https://a.example.org", "*", false, false},
+		{"any with credentials", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, "https://a.example.org", "https://a.example.org", true, true},
+		{"listed with credentials", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true}, "https://a.example.com", "https://a.example.com", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := newCORSTest(tt.config)
+			rec, reached := request(http.MethodGet, "Origin", tt.origin)
+			// Disallowed origins still reach the route; the browser withholds the response
+			if !reached {
+				t.Error("request did not reach the route")
+			}
+			h := rec.Header()
+			if got := h.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := h.Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("Allow-Credentials = %q, want credentials %v", h.Get("Access-Control-Allow-Credentials"), tt.wantCredentials)
+			}
+			if got := slices.Contains(h.Values("Vary"), "Origin"); got != tt.wantVary {
+				t.Errorf("Vary = %v, want Origin %v", h.Values("Vary"), tt.wantVary)
+			}
+		})
+	}
+
+	// Requests without an Origin are not cross-origin and get no CORS headers
+	rec, reached := newCORSTest(CORSConfig{AllowedOrigins: []string{"*"}})(http.MethodGet)
+	if !reached || len(rec.Header()) != 0 {
+		t.Errorf("same-origin request: reached %v, headers %v", reached, rec.Header())
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	config := CORSConfig{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowedMethods:   []string{"get", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	tests := []struct {
+		name     string
+		config   CORSConfig
+		origin   string
+		method   string
+		headers  string
+		wantCode int
+		wantErr  string
+	}{
+		{"allowed", config, "https://a.example.com", "POST", "content-type, Authorization", http.StatusNoContent, ""},
+		{"no headers", config, "https://a.example.com", "GET", "", http.StatusNoContent, ""},
+		{"origin", config, "https://evil.example.org", "POST", "", http.StatusForbidden, "CORS_ORIGIN_NOT_ALLOWED"},
+		{"method", config, "https://a.example.com", "DELETE", "", http.StatusForbidden, "CORS_METHOD_NOT_ALLOWED"},
+		{"header", config, "https://a.example.com", "POST", "Authorization, X-Debug", http.StatusForbidden, "CORS_HEADER_NOT_ALLOWED"},
+		{"any header", CORSConfig{AllowedOrigins: []string{"https://*.example.org"}, AllowedMethods: []string{"POST"}, AllowedHeaders: []string{"*"}}, "https://a.example.org", "POST", "X-Debug", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := newCORSTest(tt.config)
+			rec, reached := request(http.MethodOptions,
+				"Origin", tt.origin,
+				"Access-Control-Request-Method", tt.method,
+				"Access-Control-Request-Headers", tt.headers)
+			if reached {
+				t.Error("preflight reached the route")
+			}
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			h := rec.Header()
+			for _, vary := range []string{"Access-Control-Request-Method", "Access-Control-Request-Headers"} {
+				if !slices.Contains(h.Values("Vary"), vary) {
+					t.Errorf("Vary = %v, missing %s", h.Values("Vary"), vary)
+				}
+			}
+			if tt.wantErr != "" {
+				if !strings.Contains(rec.Body.String(), tt.wantErr) || h.Get("Access-Control-Allow-Origin") != "" {
+					t.Errorf("rejection = %s with Allow-Origin %q, want %s and no Allow-Origin", rec.Body, h.Get("Access-Control-Allow-Origin"), tt.wantErr)
+				}
+				return
+			}
+			if h.Get("Access-Control-Allow-Origin") != tt.origin {
+				t.Errorf("Allow-Origin = %q, want %q", h.Get("Access-Control-Allow-Origin"), tt.origin)
+			}
+		})
+	}
+
+	// An allowed preflight lists the whole policy
+	rec, _ := newCORSTest(config)(http.MethodOptions, "Origin", "https://a.example.com", "Access-Control-Request-Method", "POST")
+	want := map[string]string{
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	// Allowing any header echoes the requested ones
+	rec, _ = newCORSTest(tests[len(tests)-1].config)(http.MethodOptions, "Origin", "https://a.example.org",
+		"Access-Control-Request-Method", "POST", "Access-Control-Request-Headers", "X-Debug")
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "X-Debug" {
+		t.Errorf("Allow-Headers = %q, want the requested X-Debug", got)
+	}
+}
+
+func TestCORSExposesHeaders(t *testing.T) {
+	request := newCORSTest(CORSConfig{AllowedOrigins: []string{"https://portal.example.com"}, ExposedHeaders: []string{"X-Request-Id", "ETag"}})
+	rec, _ := request(http.MethodGet, "Origin", "https://portal.example.com")
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-Id, ETag" {
+		t.Errorf("Expose-Headers = %q", got)
+	}
+	rec, _ = request(http.MethodGet, "Origin", "https://evil.example.org")
+	if got := rec.Header().Get("Access-Control-Expose-Headers"); got != "" {
+		t.Errorf("disallowed origin got Expose-Headers %q", got)
+	}
+}
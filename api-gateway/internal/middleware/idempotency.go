@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"skool-management/shared"
+)
+
+// Headers a replay does not repeat from the first response
+var idempotencySkippedHeaders = []string{"X-Request-Id", "Date", "Set-Cookie"}
+
+// Idempotency lets clients retry POST requests to its paths safely. A
+// request with an Idempotency-Key header runs once per user and key; repeats
+// with the same body get the first response back, and a key reused with a
+// different body is refused. Responses of 5xx status are not kept, so the
+// request can be retried. Wrap it inside Auth so the identity is known.
+type Idempotency struct {
+	store        *shared.IdempotencyStore
+	paths        []string
+	maxBodyBytes int64
+}
+
+// NewIdempotency creates the middleware for POST requests to paths, which
+// are templates such as "/schools", with bodies up to maxBodyBytes
+func NewIdempotency(store *shared.IdempotencyStore, paths []string, maxBodyBytes int64) *Idempotency {
+	return &Idempotency{store: store, paths: paths, maxBodyBytes: maxBodyBytes}
+}
+
+// Guard middleware
+func (i *Idempotency) Guard(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(shared.HeaderIdempotencyKey)
+		if key == "" || r.Method != http.MethodPost || !i.match(r.URL.Path) {
+			next(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY",
+				fmt.Sprintf("Idempotency-Key must be 1 to %d printable ASCII characters", shared.MaxIdempotencyKeyLength))
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, i.maxBodyBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					shared.WriteErrorResponse(w, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "Request body is too large")
+					return
+				}
+				shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		identity, _ := shared.IdentityFromContext(r.Context())
+		storeKey := idempotencyStoreKey(r, identity, key)
+		fingerprint := requestFingerprint(body)
+
+		state, stored := i.store.Begin(storeKey, fingerprint)
+		switch state {
+		case shared.IdempotencyReplay:
+			i.store.RecordResult("replayed")
+			slog.InfoContext(r.Context(), "idempotent request replayed", "path", r.URL.Path)
+			writeIdempotentReplay(w, stored)
+			return
+		case shared.IdempotencyInProgress:
+			i.store.RecordResult("in_progress")
+			w.Header().Set("Retry-After", "1")
+			shared.WriteErrorResponse(w, http.StatusConflict, "IDEMPOTENCY_KEY_IN_USE", "A request with this Idempotency-Key is still being processed")
+			return
+		case shared.IdempotencyMismatch:
+			i.store.RecordResult("mismatch")
+			shared.WriteErrorResponse(w, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for a different request")
+			return
+		}
+		i.store.RecordResult("new")
+
+		// The key is released if the handler panics, so retries are not
+		// refused until it expires
+		completed := false
+		defer func() {
+			if !completed {
+				i.store.Release(storeKey)
+			}
+		}()
+
+		recorder := newBufferedResponse()
+		next(recorder, r)
+
+		if recorder.status < http.StatusInternalServerError {
+			header := recorder.header.Clone()
+			for _, name := range idempotencySkippedHeaders {
+				header.Del(name)
+			}
+			i.store.Complete(storeKey, fingerprint, &shared.CachedResponse{
+				Status:   recorder.status,
+				Header:   header,
+				Body:     bytes.Clone(recorder.body.Bytes()),
+				StoredAt: time.Now(),
+			})
+			completed = true
+		}
+
+		for name, values := range recorder.header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(recorder.status)
+		w.Write(recorder.body.Bytes())
+	}
+}
+
+func (i *Idempotency) match(path string) bool {
+	for _, template := range i.paths {
+		if _, ok := shared.MatchPath(template, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validIdempotencyKey accepts the printable ASCII keys clients generate,
+// usually UUIDs
+func validIdempotencyKey(key string) bool {
+	if len(key) > shared.MaxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyStoreKey scopes a key to the user and route, so users cannot
+// see each other's responses by guessing keys
+func idempotencyStoreKey(r *http.Request, identity *shared.Identity, key string) string {
+	user := "anonymous"
+	if identity != nil {
+		user = identity.UserID
+	}
+	var storeKey strings.Builder
+	storeKey.WriteString(shared.APIVersionFromContext(r.Context()))
+	storeKey.WriteString(" ")
+	storeKey.WriteString(r.Method)
+	storeKey.WriteString(" ")
+	storeKey.WriteString(r.URL.Path)
+	storeKey.WriteString("|user=")
+	storeKey.WriteString(url.QueryEscape(user))
+	storeKey.WriteString("|key=")
+	storeKey.WriteString(key)
+	return storeKey.String()
+}
+
+// requestFingerprint hashes a request body. JSON is compacted first so a
+// retry that only differs in whitespace still matches.
+func requestFingerprint(body []byte) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err == nil {
+		body = compacted.Bytes()
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeIdempotentReplay(w http.ResponseWriter, resp *shared.CachedResponse) {
+	h := w.Header()
+	for name, values := range resp.Header {
+		h[name] = append([]string(nil), values...)
+	}
+	h.Set(shared.HeaderIdempotentReplayed, "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
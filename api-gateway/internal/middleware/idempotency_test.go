@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"skool-management/shared"
+)
+
+func TestIdempotencyGuard(t *testing.T) {
+	store := shared.NewIdempotencyStore(shared.IdempotencyConfig{Name: "test-guard"})
+	guard := NewIdempotency(store, []string{"/auth/signup", "/students"}, 1024)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	handler := guard.Guard(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if r.Header.Get("X-Block") != "" {
+			started <- struct{}{}
+			<-release
+		}
+		if r.Header.Get("X-Fail") != "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Date", "Mon, 01 Jan 2024 00:00:00 GMT")
+		w.Header().Set("Location", "/students/1")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call":%d}`, n)
+	})
+
+	post := func(path, key, body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(shared.HeaderIdempotencyKey, key)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		req = req.WithContext(shared.WithIdentity(req.Context(), &shared.Identity{UserID: "u1"}))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	first := post("/students", "k1", `{"name": "Ann"}`)
+	if first.Code != http.StatusCreated || first.Header().Get(shared.HeaderIdempotentReplayed) != "" {
+		t.Fatalf("first request: status %d, replayed %q", first.Code, first.Header().Get(shared.HeaderIdempotentReplayed))
+	}
+
+	t.Run("replays the stored response", func(t *testing.T) {
+		// Whitespace differences do not change the fingerprint
+		rec := post("/students", "k1", `{"name":"Ann"}`)
+		if rec.Code != http.StatusCreated || rec.Body.String() != first.Body.String() {
+			t.Errorf("replay = %d %s, want %d %s", rec.Code, rec.Body, first.Code, first.Body)
+		}
+		if rec.Header().Get(shared.HeaderIdempotentReplayed) != "true" || rec.Header().Get("Location") != "/students/1" {
+			t.Errorf("replay headers = %v", rec.Header())
+		}
+		if rec.Header().Get("Date") != "" {
+			t.Error("replay repeated the first response's Date")
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("handler ran %d times, want 1", got)
+		}
+	})
+
+	t.Run("refuses a different body", func(t *testing.T) {
+		rec := post("/students", "k1", `{"name":"Bob"}`)
+		if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "IDEMPOTENCY_KEY_REUSED") {
+			t.Errorf("reuse = %d %s, want 422 IDEMPOTENCY_KEY_REUSED", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("keys are per user", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/students", strings.NewReader(`{"name":"Ann"}`))
+		req.Header.Set(shared.HeaderIdempotencyKey, "k1")
+		req = req.WithContext(shared.WithIdentity(req.Context(), &shared.Identity{UserID: "u2"}))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Header().Get(shared.HeaderIdempotentReplayed) != "" {
+			t.Error("another user got the first user's response")
+		}
+	})
+
+	t.Run("refuses a key in progress", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- post("/auth/signup", "k2", `{}`, "X-Block", "1") }()
+		<-started
+
+		rec := post("/auth/signup", "k2", `{}`)
+		if rec.Code != http.StatusConflict || rec.Header().Get("Retry-After") != "1" {
+			t.Errorf("concurrent = %d, Retry-After %q; want 409 with Retry-After 1", rec.Code, rec.Header().Get("Retry-After"))
+		}
+		close(release)
+		if rec := <-done; rec.Code != http.StatusCreated {
+			t.Errorf("blocked request status = %d, want 201", rec.Code)
+		}
+	})
+
+	t.Run("server errors are not kept", func(t *testing.T) {
+		if rec := post("/students", "k3", `{}`, "X-Fail", "1"); rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("failing request status = %d", rec.Code)
+		}
+		if rec := post("/students", "k3", `{}`); rec.Code != http.StatusCreated || rec.Header().Get(shared.HeaderIdempotentReplayed) != "" {
+			t.Errorf("retry = %d replayed %q, want a fresh 201", rec.Code, rec.Header().Get(shared.HeaderIdempotentReplayed))
+		}
+	})
+
+	t.Run("invalid keys and other paths", func(t *testing.T) {
+		if rec := post("/students", strings.Repeat("k", shared.MaxIdempotencyKeyLength+1), `{}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("long key status = %d, want 400", rec.Code)
+		}
+		before := calls.Load()
+		post("/schools", "k1", `{}`)
+		post("/schools", "k1", `{}`)
+		if got := calls.Load() - before; got != 2 {
+			t.Errorf("unguarded path ran %d times, want 2", got)
+		}
+	})
+}
@@ -1,9 +1,6 @@
 package middleware
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -11,41 +8,30 @@ import (
 )
 
 type Middleware struct {
-	authServiceURL string
+	jwtManager     *shared.JWTManager
+	identitySigner *shared.IdentitySigner
+	tokenCache     *tokenCache
+	cors           *corsPolicy
 }
 
-func New(authServiceURL string) *Middleware {
+func New(jwtSecret string, identitySigner *shared.IdentitySigner, tokenCacheTTL time.Duration, cors CORSConfig) *Middleware {
 	return &Middleware{
-		authServiceURL: authServiceURL,
+		jwtManager:     shared.NewJWTManager(jwtSecret, "", time.Hour, time.Hour),
+		identitySigner: identitySigner,
+		tokenCache:     newTokenCache(tokenCacheTTL),
+		cors:           newCORSPolicy(cors),
 	}
 }
 
-// CORS middleware
-func (m *Middleware) CORS(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next(w, r)
-	}
+// Request ID middleware. Accepts a well-formed X-Request-ID from the client or
+// generates one, and makes it available to every later middleware and the proxy.
+func (m *Middleware) RequestID(next http.HandlerFunc) http.HandlerFunc {
+	return shared.RequestIDHandler(next).ServeHTTP
 }
 
-// Logging middleware
+// Logging middleware. Writes one structured access log line per request.
 func (m *Middleware) Logging(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		shared.LogInfo("API_GATEWAY", fmt.Sprintf("%s %s - Started", r.Method, r.URL.Path))
-
-		next(w, r)
-
-		shared.LogInfo("API_GATEWAY", fmt.Sprintf("%s %s - Completed in %v", r.Method, r.URL.Path, time.Since(start)))
-	}
+	return shared.AccessLogHandler(next).ServeHTTP
 }
 
 // Rate limiting middleware (basic implementation)
@@ -57,46 +43,54 @@ func (m *Middleware) RateLimit(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// JWT authentication middleware
+// QueryToken moves an access_token query parameter into the Authorization
+// header for Auth, for clients that cannot set headers such as browser
+// EventSource and WebSocket. The parameter is removed from the URL so it is
+// never forwarded.
+func (m *Middleware) QueryToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if token := query.Get("access_token"); token != "" {
+			if r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			query.Del("access_token")
+			r.URL.RawQuery = query.Encode()
+		}
+		next(w, r)
+	}
+}
+
+// JWT authentication middleware. Tokens are verified locally and the caller
+// identity is forwarded to services as signed headers.
 func (m *Middleware) Auth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Never let clients supply their own identity headers
+		shared.StripIdentityHeaders(r.Header)
+
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			shared.WriteErrorResponse(w, http.StatusUnauthorized, "MISSING_TOKEN", "Authorization header is required")
 			return
 		}
 
-		// Validate token with auth service
-		validateReq, err := http.NewRequest("GET", m.authServiceURL+"/validate", nil)
-		if err != nil {
-			shared.WriteErrorResponse(w, http.StatusInternalServerError, "VALIDATION_ERROR", "Failed to create validation request")
-			return
-		}
-		validateReq.Header.Set("Authorization", authHeader)
-
-		client := &http.Client{Timeout: 5 * time.Second}
-		resp, err := client.Do(validateReq)
-		if err != nil {
-			shared.WriteErrorResponse(w, http.StatusInternalServerError, "VALIDATION_ERROR", "Failed to validate token")
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			// Token is invalid
-			body, _ := io.ReadAll(resp.Body)
-			var errorResp map[string]interface{}
-			json.Unmarshal(body, &errorResp)
-
-			if errorResp["error"] != nil {
-				shared.WriteErrorResponse(w, http.StatusUnauthorized, errorResp["error"].(string), errorResp["message"].(string))
-			} else {
+		tokenString := shared.ExtractBearerToken(authHeader)
+		claims, ok := m.tokenCache.get(tokenString)
+		if !ok {
+			var err error
+			claims, err = m.jwtManager.VerifyToken(tokenString)
+			if err != nil {
 				shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token")
+				return
 			}
-			return
+			m.tokenCache.put(tokenString, claims)
 		}
 
+		identity := shared.IdentityFromClaims(claims)
+		identity.RequestID = shared.RequestIDFromContext(r.Context())
+		m.identitySigner.Sign(r.Header, identity)
+
 		// Token is valid, proceed to next handler
-		next(w, r)
+		next(w, r.WithContext(shared.WithIdentity(r.Context(), identity)))
 	}
 }
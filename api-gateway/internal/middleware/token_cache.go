@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"skool-management/shared"
+)
+
+const maxCachedTokens = 10000
+
+type cachedToken struct {
+	claims    *shared.JWTClaims
+	expiresAt time.Time
+}
+
+// tokenCache keeps recently verified tokens so hot clients skip signature checks
+type tokenCache struct {
+	ttl     time.Duration
+	entries map[string]cachedToken
+	mutex   sync.RWMutex
+}
+
+func newTokenCache(ttl time.Duration) *tokenCache {
+	return &tokenCache{
+		ttl:     ttl,
+		entries: make(map[string]cachedToken),
+	}
+}
+
+func (c *tokenCache) get(token string) (*shared.JWTClaims, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mutex.RLock()
+	entry, ok := c.entries[cacheKey(token)]
+	c.mutex.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.claims, true
+}
+
+func (c *tokenCache) put(token string, claims *shared.JWTClaims) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	// Never cache a token beyond its own expiry
+	expiresAt := time.Now().Add(c.ttl)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.entries) >= maxCachedTokens {
+		c.evictExpired()
+	}
+	if len(c.entries) >= maxCachedTokens {
+		// Still full of live entries, start over rather than grow unbounded
+		c.entries = make(map[string]cachedToken)
+	}
+	c.entries[cacheKey(token)] = cachedToken{claims: claims, expiresAt: expiresAt}
+}
+
+func (c *tokenCache) evictExpired() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func cacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
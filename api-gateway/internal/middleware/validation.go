@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+// Validator rejects requests that do not match the aggregated OpenAPI
+// document before they are proxied: path parameters, query strings and JSON
+// bodies are checked against the operation's schemas and every problem is
+// reported at once. Requests for operations the document does not describe
+// pass through unchecked.
+type Validator struct {
+	specs           *gateway.SpecAggregator
+	refreshInterval time.Duration
+	refreshTimeout  time.Duration
+	maxBodyBytes    int64
+	refreshing      atomic.Bool
+}
+
+// NewValidator creates a validator that rebuilds its document from specs
+// every refreshInterval and refuses bodies larger than maxBodyBytes
+func NewValidator(specs *gateway.SpecAggregator, refreshInterval, refreshTimeout time.Duration, maxBodyBytes int64) *Validator {
+	return &Validator{
+		specs:           specs,
+		refreshInterval: refreshInterval,
+		refreshTimeout:  refreshTimeout,
+		maxBodyBytes:    maxBodyBytes,
+	}
+}
+
+// Validate middleware
+func (v *Validator) Validate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc := v.document(r.Context())
+		// Operations a version changes are documented under its prefix
+		op, pathParams, ok := doc.FindOperation(r.Method, "/"+shared.APIVersionFromContext(r.Context())+r.URL.Path)
+		if !ok {
+			op, pathParams, ok = doc.FindOperation(r.Method, r.URL.Path)
+		}
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		var body []byte
+		if op.RequestBody != nil && r.Body != nil {
+			if contentType := r.Header.Get("Content-Type"); contentType != "" {
+				mediaType, _, err := mime.ParseMediaType(contentType)
+				if err != nil || mediaType != "application/json" {
+					shared.WriteErrorResponse(w, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", "Request body must be application/json")
+					return
+				}
+			}
+
+			var err error
+			body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, v.maxBodyBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					shared.WriteErrorResponse(w, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "Request body is too large")
+					return
+				}
+				shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+				return
+			}
+			// The proxy reads the body again
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+
+		if fields := doc.ValidateRequest(op, pathParams, r.URL.Query(), body); len(fields) > 0 {
+			slog.DebugContext(r.Context(), "request failed validation", "operation", op.OperationID, "fields", len(fields))
+			shared.WriteValidationErrorResponse(w, fields)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// document returns the current aggregated document. The first call builds
+// it; afterwards a stale document is refreshed in the background so requests
+// never wait on the services' OpenAPI endpoints.
+func (v *Validator) document(ctx context.Context) *shared.OpenAPIDocument {
+	doc, fetchedAt := v.specs.Current()
+	if doc == nil {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), v.refreshTimeout)
+		defer cancel()
+		return v.specs.Document(ctx)
+	}
+
+	if time.Since(fetchedAt) > v.refreshInterval && v.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer v.refreshing.Store(false)
+			ctx, cancel := context.WithTimeout(context.Background(), v.refreshTimeout)
+			defer cancel()
+			v.specs.Document(ctx)
+		}()
+	}
+	return doc
+}
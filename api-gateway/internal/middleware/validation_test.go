@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"skool-management/api-gateway/internal/gateway"
+	"skool-management/shared"
+)
+
+type validationTestRequest struct {
+	Name string `json:"name" jsonschema:"required,minLength=1"`
+}
+
+func TestValidatorRejectsBodies(t *testing.T) {
+	// Services without documents leave only the gateway's own operations
+	services := httptest.NewServer(http.NotFoundHandler())
+	defer services.Close()
+	gw := gateway.New(services.URL, services.URL, services.URL, shared.BulkheadConfig{MaxConcurrent: 1}, nil)
+	spec := func() *shared.OpenAPIDocument {
+		return shared.NewOpenAPIDocument("test", "1", "").AddEndpoints(
+			shared.Endpoint{Method: http.MethodPost, Path: "/items", Request: validationTestRequest{}},
+		)
+	}
+	validator := NewValidator(gateway.NewSpecAggregator(gw, time.Second, spec), time.Hour, time.Second, 32)
+
+	var proxied string
+	handler := validator.Validate(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		proxied = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+	}{
+		{"valid", "/items", "application/json; charset=utf-8", `{"name":"Ann"}`, http.StatusNoContent, ""},
+		{"no content type", "/items", "", `{"name":"Ann"}`, http.StatusNoContent, ""},
+		{"invalid", "/items", "application/json", `{"name":""}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"wrong content type", "/items", "text/plain", `{"name":"Ann"}`, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"},
+		{"malformed content type", "/items", "application/", `{"name":"Ann"}`, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"},
+		{"too large", "/items", "application/json", `{"name":"` + strings.Repeat("a", 32) + `"}`, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE"},
+		{"undocumented operation", "/other", "text/plain", "anything", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxied = ""
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want error %s", rec.Body, tt.wantCode)
+			}
+			// The body read for validation is handed on to the proxy intact
+			if tt.wantStatus == http.StatusNoContent && proxied != tt.body {
+				t.Errorf("proxied body = %q, want %q", proxied, tt.body)
+			}
+		})
+	}
+}
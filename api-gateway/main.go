@@ -1,12 +1,15 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
 
 	"skool-management/api-gateway/internal/config"
+	"skool-management/api-gateway/internal/events"
 	"skool-management/api-gateway/internal/gateway"
+	"skool-management/api-gateway/internal/graph"
 	"skool-management/api-gateway/internal/handlers"
 	"skool-management/api-gateway/internal/middleware"
 	"skool-management/shared"
@@ -15,32 +18,156 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	shared.InitLogger("api-gateway", cfg.LogLevel)
+	lifecycle := shared.NewLifecycle(shared.ServerTimeouts{
+		Read:       cfg.ReadTimeout,
+		Write:      cfg.WriteTimeout,
+		Idle:       cfg.IdleTimeout,
+		Shutdown:   cfg.ShutdownTimeout,
+		DrainDelay: cfg.DrainDelay,
+	})
+
+	// Initialize tracing
+	shutdownTracing, err := shared.InitTracing(context.Background(), "api-gateway")
+	if err != nil {
+		log.Fatal("Failed to initialize tracing:", err)
+	}
+	lifecycle.OnShutdown("tracing", shutdownTracing)
+
+	// TLS for the public listener, and mutual TLS for https:// service URLs
+	serverTLS, err := shared.NewServerTLSConfig("api-gateway", shared.TLSFiles{
+		CertFile: cfg.TLSCertFile,
+		KeyFile:  cfg.TLSKeyFile,
+		CAFile:   cfg.TLSClientCAFile,
+	}, cfg.TLSReloadInterval)
+	if err != nil {
+		log.Fatal("Failed to load TLS certificates:", err)
+	}
+	upstreamTLS, err := shared.NewClientTLSConfig("api-gateway-upstream", shared.TLSFiles{
+		CertFile: cfg.UpstreamTLSCertFile,
+		KeyFile:  cfg.UpstreamTLSKeyFile,
+		CAFile:   cfg.UpstreamTLSCAFile,
+	}, cfg.TLSReloadInterval)
+	if err != nil {
+		log.Fatal("Failed to load upstream TLS certificates:", err)
+	}
 
 	// Create gateway
-	gw := gateway.New(cfg.AuthServiceURL, cfg.SchoolServiceURL, cfg.StudentServiceURL)
+	gw := gateway.New(cfg.AuthServiceURL, cfg.SchoolServiceURL, cfg.StudentServiceURL, shared.BulkheadConfig{
+		MaxConcurrent: cfg.UpstreamMaxConcurrent,
+		MaxQueue:      cfg.UpstreamMaxQueue,
+		QueueTimeout:  cfg.UpstreamQueueTimeout,
+	}, upstreamTLS)
 
 	// Create middleware
-	mw := middleware.New(cfg.AuthServiceURL)
+	identitySigner := shared.NewIdentitySigner(cfg.IdentitySecret, shared.DefaultIdentityMaxSkew)
+	mw := middleware.New(cfg.JWTSecret, identitySigner, cfg.TokenCacheTTL, middleware.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	})
+
+	// Aggregate the services' OpenAPI documents for the docs and request validation
+	specs := gateway.NewSpecAggregator(gw, cfg.HealthCheckTimeout, openAPISpec)
+	validator := middleware.NewValidator(specs, cfg.SpecRefreshInterval, cfg.HealthCheckTimeout, cfg.MaxRequestBodyBytes)
+
+	// Cache reads of the slow-changing resources per caller scope. Writes
+	// through the gateway drop the affected entries; changes made elsewhere
+	// show up within the TTL.
+	responseCache := middleware.NewResponseCache(shared.NewResponseCache(shared.ResponseCacheConfig{
+		Name:          "api-gateway",
+		MaxEntries:    cfg.ResponseCacheMaxEntries,
+		MaxBytes:      cfg.ResponseCacheMaxBytes,
+		MaxEntryBytes: cfg.ResponseCacheMaxEntryBytes,
+	}), []middleware.CacheRule{
+		{Path: "/schools", TTL: cfg.ResponseCacheTTL},
+		{Path: "/schools/{id}", TTL: cfg.ResponseCacheTTL},
+		{Path: "/students/{id}", TTL: cfg.ResponseCacheTTL},
+	})
+
+	// Let clients retry creates safely with an Idempotency-Key. Signups are
+	// anonymous, so their keys are shared by every caller, but a replay still
+	// needs the exact body, password included.
+	idempotency := middleware.NewIdempotency(shared.NewIdempotencyStore(shared.IdempotencyConfig{
+		Name:       "api-gateway",
+		TTL:        cfg.IdempotencyTTL,
+		MaxEntries: cfg.IdempotencyMaxEntries,
+	}), []string{"/auth/signup", "/schools", "/students"}, cfg.MaxRequestBodyBytes)
+
+	// Resolve the API version of every request. A sunset date implies the
+	// version is deprecated.
+	deprecated := make(map[string]shared.APIDeprecation)
+	for version, since := range cfg.APIDeprecatedVersions {
+		deprecated[version] = shared.APIDeprecation{Since: since, Sunset: cfg.APISunsetDates[version], Link: cfg.APIDeprecationLink}
+	}
+	for version, sunset := range cfg.APISunsetDates {
+		if _, ok := deprecated[version]; !ok {
+			deprecated[version] = shared.APIDeprecation{Sunset: sunset, Link: cfg.APIDeprecationLink}
+		}
+	}
+	versioning := shared.NewAPIVersioning(shared.APIVersionConfig{
+		Supported:   cfg.APIVersions,
+		Default:     cfg.APIDefaultVersion,
+		Deprecated:  deprecated,
+		LogInterval: cfg.APIDeprecationLogInterval,
+	})
 
 	// Create handlers
-	h := handlers.New(gw)
+	h := handlers.New(gw, cfg.HealthCheckTimeout, specs, handlers.DashboardConfig{
+		SchoolTimeout:   cfg.DashboardSchoolTimeout,
+		StudentsTimeout: cfg.DashboardStudentsTimeout,
+	})
+	graphQL, err := graph.NewHandler(gw, graph.Limits{
+		MaxDepth:      cfg.GraphQLMaxDepth,
+		MaxComplexity: cfg.GraphQLMaxComplexity,
+	}, cfg.MaxRequestBodyBytes)
+	if err != nil {
+		log.Fatal("Failed to build GraphQL schema:", err)
+	}
+
+	// Relay the services' change events to /events subscribers
+	hub := events.NewHub(gw, identitySigner, events.HubConfig{
+		LogSize:           cfg.EventLogSize,
+		ReconnectDelay:    cfg.EventReconnectDelay,
+		MaxReconnectDelay: cfg.EventMaxReconnectDelay,
+		IdleTimeout:       cfg.EventUpstreamIdleTimeout,
+	})
+	hub.Start()
+	lifecycle.OnShutdown("event hub", hub.Close)
+	eventsHandler := events.NewHandler(hub, shared.EventStreamConfig{
+		Heartbeat:    cfg.EventHeartbeat,
+		WriteTimeout: cfg.EventWriteTimeout,
+	}, mw.AllowsOrigin)
 
-	// Setup routes with middleware chain
+	// Setup routes with middleware chain. CORS wraps the whole mux so every
+	// response, 404s included, carries the policy. Versioning strips the /v1
+	// or /v2 prefix before routing and forwards the version in Accept-Version.
 	mux := http.NewServeMux()
 
-	// Health and documentation endpoints
-	mux.HandleFunc("/health", mw.CORS(mw.Logging(h.HandleHealth)))
-	mux.HandleFunc("/docs", mw.CORS(mw.Logging(h.HandleDocs)))
+	// Health, documentation and admin endpoints. The gateway stays ready
+	// while upstreams fail; /health reports them and the circuit breakers
+	// protect each request
+	public := func(next http.HandlerFunc) http.HandlerFunc { return mw.RequestID(mw.Logging(next)) }
+	protected := func(next http.HandlerFunc) http.HandlerFunc { return public(mw.Auth(mw.RateLimit(next))) }
+	readiness := shared.ReadinessHandler(lifecycle.ReadinessCheck())
+	breakerAdmin := public(mw.Auth(shared.RequireRole(shared.RoleAdmin, shared.CircuitBreakerAdminHandler(shared.DefaultCircuitBreakerRegistry))))
+	// Browsers cannot set headers on EventSource and WebSocket requests, so
+	// the event stream also takes the token as a query parameter
+	eventStream := public(mw.QueryToken(mw.Auth(mw.RateLimit(eventsHandler.ServeHTTP))))
+	shared.RegisterRoutes(mux, routes(h, public, protected, readiness, breakerAdmin, graphQL.ServeHTTP, eventStream))
 
 	// Service routes
-	mux.HandleFunc("/auth/", mw.CORS(mw.Logging(mw.RateLimit(h.HandleAuth))))
-	mux.HandleFunc("/schools", mw.CORS(mw.Logging(mw.Auth(mw.RateLimit(h.HandleSchools)))))
-	mux.HandleFunc("/schools/", mw.CORS(mw.Logging(mw.Auth(mw.RateLimit(h.HandleSchools)))))
-	mux.HandleFunc("/students", mw.CORS(mw.Logging(mw.Auth(mw.RateLimit(h.HandleStudents)))))
-	mux.HandleFunc("/students/", mw.CORS(mw.Logging(mw.Auth(mw.RateLimit(h.HandleStudents)))))
+	mux.HandleFunc("/auth/", mw.RequestID(mw.Logging(mw.RateLimit(validator.Validate(idempotency.Guard(h.HandleAuth))))))
+	mux.HandleFunc("/schools", mw.RequestID(mw.Logging(mw.Auth(mw.RateLimit(responseCache.Cache(validator.Validate(idempotency.Guard(h.HandleSchools))))))))
+	mux.HandleFunc("/schools/", mw.RequestID(mw.Logging(mw.Auth(mw.RateLimit(responseCache.Cache(validator.Validate(idempotency.Guard(h.HandleSchools))))))))
+	mux.HandleFunc("/students", mw.RequestID(mw.Logging(mw.Auth(mw.RateLimit(responseCache.Cache(validator.Validate(idempotency.Guard(h.HandleStudents))))))))
+	mux.HandleFunc("/students/", mw.RequestID(mw.Logging(mw.Auth(mw.RateLimit(responseCache.Cache(validator.Validate(idempotency.Guard(h.HandleStudents))))))))
 
 	// Root endpoint
-	mux.HandleFunc("/", mw.CORS(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/", mw.RequestID(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
 			h.HandleDocs(w, r)
 		} else {
@@ -48,19 +175,45 @@ func main() {
 		}
 	}))
 
-	shared.LogInfo("API_GATEWAY", fmt.Sprintf("Starting API Gateway on port %s", cfg.Port))
-	shared.LogInfo("API_GATEWAY", fmt.Sprintf("Auth Service: %s", cfg.AuthServiceURL))
-	shared.LogInfo("API_GATEWAY", fmt.Sprintf("School Service: %s", cfg.SchoolServiceURL))
-	shared.LogInfo("API_GATEWAY", fmt.Sprintf("Student Service: %s", cfg.StudentServiceURL))
-	shared.LogInfo("API_GATEWAY", "Available endpoints:")
-	shared.LogInfo("API_GATEWAY", "  GET  / - API Documentation")
-	shared.LogInfo("API_GATEWAY", "  GET  /docs - API Documentation")
-	shared.LogInfo("API_GATEWAY", "  GET  /health - Health Check")
-	shared.LogInfo("API_GATEWAY", "  POST /auth/signup - User Registration")
-	shared.LogInfo("API_GATEWAY", "  POST /auth/login - User Login")
-	shared.LogInfo("API_GATEWAY", "  POST /auth/refresh - Refresh Token")
-	shared.LogInfo("API_GATEWAY", "  *    /schools/* - School Management")
-	shared.LogInfo("API_GATEWAY", "  *    /students/* - Student Management")
-
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, mux))
+	slog.Info("starting API gateway",
+		"port", cfg.Port,
+		"tls", serverTLS != nil,
+		"auth_service_url", cfg.AuthServiceURL,
+		"school_service_url", cfg.SchoolServiceURL,
+		"student_service_url", cfg.StudentServiceURL,
+	)
+	slog.Info("available endpoints", "endpoints", []string{
+		"GET / - Interactive API Documentation",
+		"GET /docs - Interactive API Documentation",
+		"GET /openapi.json - OpenAPI 3.1 Document",
+		"GET /health - Aggregated Health Check",
+		"GET /livez - Liveness Probe",
+		"GET /readyz - Readiness Probe",
+		"GET /metrics - Prometheus Metrics",
+		"GET|POST /admin/breakers/* - Circuit Breaker Admin (admin role)",
+		"POST /auth/signup - User Registration",
+		"POST /auth/login - User Login",
+		"POST /auth/refresh - Refresh Token",
+		"GET /auth/validate - Validate Token",
+		"GET|PUT|DELETE /auth/users/* - User Management (admin role)",
+		"* /v1/*, /v2/* - Versioned API (or Accept-Version header)",
+		"GET /dashboard/schools/{id} - School Dashboard (school and students)",
+		"GET|POST /graphql - GraphQL API (schools, students, current user)",
+		"GET /events - Change Feed (Server-Sent Events or WebSocket)",
+		"* /schools/* - School Management",
+		"* /students/* - Student Management",
+	})
+
+	httpServer := lifecycle.NewHTTPServer(":"+cfg.Port, shared.TraceHandler(shared.InstrumentHandler(shared.CompressHandler(mw.CORS(versioning.Handler(shared.RouteHandler(mux)).ServeHTTP))), "api-gateway"))
+	httpServer.TLSConfig = serverTLS
+	// Server shutdown waits for open requests, so end the event streams as
+	// soon as draining starts; clients reconnect to another instance
+	httpServer.RegisterOnShutdown(hub.CloseStreams)
+	lifecycle.ServeHTTP(httpServer)
+
+	// Block until SIGINT/SIGTERM, then drain servers and close resources
+	if err := lifecycle.Wait(); err != nil {
+		log.Fatal("Server failed:", err)
+	}
+	slog.Info("API gateway stopped")
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+
+	"skool-management/api-gateway/internal/graph"
+	"skool-management/api-gateway/internal/handlers"
+	"skool-management/shared"
+)
+
+// openAPISpec describes the gateway's own endpoints. The handlers merge the
+// services' documents into it to produce the document served at /openapi.json.
+func openAPISpec() *shared.OpenAPIDocument {
+	doc := shared.NewOpenAPIDocument("School Management API", "1.0.0",
+		"API Gateway for School Management Microservices. Endpoints marked with a lock require an access token from /auth/login.")
+
+	for _, e := range shared.OperationalEndpoints() {
+		// Served by the gateway itself, so part of its public document
+		e.Internal = false
+		if e.Path == "/health" {
+			e.Summary = "Aggregated readiness of every service"
+			e.Description = "Probes each service's /readyz in parallel and includes the state of the gateway's circuit breaker for it."
+		}
+		doc.AddEndpoints(e)
+	}
+
+	return doc.AddEndpoints(shared.Endpoint{
+		Method: http.MethodGet, Path: "/docs", Tag: "operations",
+		Summary:     "Interactive API documentation",
+		RawResponse: "text/html",
+	}, shared.Endpoint{
+		Method: http.MethodGet, Path: "/dashboard/schools/{id}", Tag: "dashboard", Authenticated: true,
+		Summary:     "A school with the first page of its students",
+		Description: "Fetches the school and its students concurrently. If one service is unavailable the other's data is returned with partial set and the failure listed in errors.",
+		PathParams:  map[string]string{"id": "integer"},
+		QueryParams: shared.PaginationQueryParams(),
+		Response:    handlers.SchoolDashboard{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusServiceUnavailable},
+	}, shared.Endpoint{
+		Method: http.MethodPost, Path: "/graphql", Tag: "graphql", Authenticated: true,
+		Summary:     "Run a GraphQL query",
+		Description: graphQLDescription,
+		Request:     graph.Request{},
+		Response:    graph.Response{},
+		Unwrapped:   true,
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusRequestEntityTooLarge},
+	}, shared.Endpoint{
+		Method: http.MethodGet, Path: "/graphql", Tag: "graphql", Authenticated: true,
+		Summary:     "Run a GraphQL query from the query string",
+		Description: graphQLDescription,
+		QueryParams: map[string]string{"query": "string", "operationName": "string", "variables": "string"},
+		Response:    graph.Response{},
+		Unwrapped:   true,
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized},
+	}, shared.Endpoint{
+		Method: http.MethodGet, Path: "/events", Tag: "events", Authenticated: true,
+		Summary: "Follow changes to schools and students",
+		Description: "Streams change events as Server-Sent Events, or as WebSocket text messages when the request is a WebSocket upgrade. " +
+			"types (school, student) and school_ids narrow the subscription, which never includes schools outside the caller's scope. " +
+			"Send Last-Event-ID, or last_event_id, to resume; a reset event means events were missed and state should be refetched. " +
+			"Clients that cannot set headers may pass the token as access_token. " +
+			`WebSocket clients change their subscription by sending {"type": "subscribe", "types": [...], "school_ids": [...]}.`,
+		QueryParams: map[string]string{"types": "string", "school_ids": "string", "last_event_id": "string", "access_token": "string"},
+		RawResponse: "text/event-stream",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusServiceUnavailable},
+	})
+}
+
+// graphQLDescription explains the status codes of the GraphQL endpoint
+const graphQLDescription = "Queries that fail to parse or validate, or exceed the depth or complexity limits, get 400 with the reasons in errors. " +
+	"Once a query runs the status is 200; fields that failed are null and explained in errors, with the error code in extensions.code."
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+
+	"skool-management/api-gateway/internal/handlers"
+	"skool-management/shared"
+)
+
+// routes lists the endpoints the gateway serves itself; everything under
+// /auth, /schools and /students is proxied and documented by the services.
+// Each route must be described by openAPISpec, which routes_test.go enforces.
+// protected adds authentication and rate limiting to public.
+func routes(h *handlers.Handlers, public, protected func(http.HandlerFunc) http.HandlerFunc, readiness, breakerAdmin, graphQL, events http.HandlerFunc) []shared.Route {
+	return []shared.Route{
+		{Method: http.MethodGet, Path: "/health", Handler: public(h.HandleHealth)},
+		{Method: http.MethodGet, Path: "/livez", Handler: shared.LivenessHandler()},
+		{Method: http.MethodGet, Path: "/readyz", Handler: readiness},
+		{Method: http.MethodGet, Path: "/metrics", Handler: shared.MetricsHandler().ServeHTTP},
+		{Method: http.MethodGet, Path: "/docs", Handler: public(h.HandleDocs)},
+		{Method: http.MethodGet, Path: shared.OpenAPIPath, Handler: public(h.HandleOpenAPI)},
+		{Method: http.MethodGet, Path: shared.CircuitBreakerAdminPath, Handler: breakerAdmin},
+		{Method: http.MethodGet, Path: shared.CircuitBreakerAdminPath + "/{name}", Handler: breakerAdmin},
+		{Method: http.MethodPost, Path: shared.CircuitBreakerAdminPath + "/{name}/{action}", Handler: breakerAdmin},
+		{Method: http.MethodGet, Path: "/dashboard/schools/{id}", Handler: protected(h.HandleSchoolDashboard)},
+		{Method: http.MethodGet, Path: "/graphql", Handler: protected(graphQL)},
+		{Method: http.MethodPost, Path: "/graphql", Handler: protected(graphQL)},
+		{Method: http.MethodGet, Path: "/events", Handler: events},
+	}
+}
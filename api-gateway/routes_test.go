@@ -0,0 +1,15 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"skool-management/shared"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	passthrough := func(next http.HandlerFunc) http.HandlerFunc { return next }
+	for _, problem := range shared.CheckRouteCoverage(routes(nil, passthrough, passthrough, nil, nil, nil, nil), openAPISpec()) {
+		t.Error(problem)
+	}
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"skool-management/auth-service/internal/models"
 	"skool-management/auth-service/internal/service"
@@ -26,7 +28,7 @@ func (h *AuthHandlers) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.authService.Signup(&req)
+	user, err := h.authService.Signup(r.Context(), &req)
 	if err != nil {
 		switch err.Error() {
 		case "all fields are required":
@@ -34,7 +36,7 @@ func (h *AuthHandlers) Signup(w http.ResponseWriter, r *http.Request) {
 		case "user with this email already exists":
 			shared.WriteErrorResponse(w, http.StatusConflict, "USER_EXISTS", err.Error())
 		default:
-			shared.LogError("AUTH_SERVICE", "signup", err)
+			slog.ErrorContext(r.Context(), "signup failed", "error", err)
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create user")
 		}
 		return
@@ -50,12 +52,12 @@ func (h *AuthHandlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.authService.Login(&req)
+	response, err := h.authService.Login(r.Context(), &req)
 	if err != nil {
 		if err.Error() == "invalid email or password" {
 			shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", err.Error())
 		} else {
-			shared.LogError("AUTH_SERVICE", "login", err)
+			slog.ErrorContext(r.Context(), "login failed", "error", err)
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Login failed")
 		}
 		return
@@ -71,7 +73,7 @@ func (h *AuthHandlers) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, err := h.authService.RefreshToken(&req)
+	accessToken, err := h.authService.RefreshToken(r.Context(), &req)
 	if err != nil {
 		shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_TOKEN", err.Error())
 		return
@@ -91,23 +93,87 @@ func (h *AuthHandlers) ValidateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenString := authHeader
-	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		tokenString = authHeader[7:]
-	}
-
-	claims, err := h.authService.ValidateToken(tokenString)
+	claims, err := h.authService.ValidateToken(shared.ExtractBearerToken(authHeader))
 	if err != nil {
 		shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token")
 		return
 	}
 
 	shared.WriteSuccessResponse(w, http.StatusOK, "Token is valid", map[string]interface{}{
-		"user_id": claims.UserID,
-		"email":   claims.Email,
+		"user_id":    claims.UserID,
+		"email":      claims.Email,
+		"role":       claims.Role,
+		"school_ids": claims.SchoolIDs,
 	})
 }
 
-func (h *AuthHandlers) Health(w http.ResponseWriter, r *http.Request) {
-	shared.WriteSuccessResponse(w, http.StatusOK, "Auth service is healthy", nil)
+// ListUsers returns a page of users; admin only
+func (h *AuthHandlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, fields := shared.ParsePageRequest(r.URL.Query())
+	if len(fields) > 0 {
+		shared.WriteValidationErrorResponse(w, fields)
+		return
+	}
+
+	users, total, err := h.authService.ListUsers(r.Context(), page)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "list users failed", "error", err)
+		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get users")
+		return
+	}
+
+	shared.WriteSuccessResponse(w, http.StatusOK, "Users retrieved successfully", models.UserPage{Items: users, Pagination: shared.NewPagination(page, total)})
+}
+
+// GetUser returns one user; admin only
+func (h *AuthHandlers) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authService.GetUser(r.Context(), strings.TrimPrefix(r.URL.Path, "/users/"))
+	if err != nil {
+		h.writeUserError(w, r, "get user failed", "Failed to get user", err)
+		return
+	}
+
+	shared.WriteSuccessResponse(w, http.StatusOK, "User retrieved successfully", user)
+}
+
+// UpdateUser replaces a user's name, role and schools; admin only
+func (h *AuthHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	user, err := h.authService.UpdateUser(r.Context(), strings.TrimPrefix(r.URL.Path, "/users/"), &req)
+	if err != nil {
+		h.writeUserError(w, r, "update user failed", "Failed to update user", err)
+		return
+	}
+
+	shared.WriteSuccessResponse(w, http.StatusOK, "User updated successfully", user)
+}
+
+// DeleteUser removes a user; admin only
+func (h *AuthHandlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	err := h.authService.DeleteUser(r.Context(), strings.TrimPrefix(r.URL.Path, "/users/"))
+	if err != nil {
+		h.writeUserError(w, r, "delete user failed", "Failed to delete user", err)
+		return
+	}
+
+	shared.WriteSuccessResponse(w, http.StatusOK, "User deleted successfully", nil)
+}
+
+func (h *AuthHandlers) writeUserError(w http.ResponseWriter, r *http.Request, logMessage, message string, err error) {
+	switch err.Error() {
+	case "invalid user ID":
+		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid user ID")
+	case "first name, last name and role are required":
+		shared.WriteErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	case "user not found":
+		shared.WriteErrorResponse(w, http.StatusNotFound, "USER_NOT_FOUND", err.Error())
+	default:
+		slog.ErrorContext(r.Context(), logMessage, "error", err)
+		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"skool-management/shared"
+)
+
+// AuthMiddleware verifies the bearer token with the service's own JWT manager
+// and stores the caller identity in the request context
+func AuthMiddleware(jwtManager *shared.JWTManager) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				shared.WriteErrorResponse(w, http.StatusUnauthorized, "MISSING_TOKEN", "Authorization header is required")
+				return
+			}
+
+			claims, err := jwtManager.VerifyToken(shared.ExtractBearerToken(authHeader))
+			if err != nil {
+				shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token")
+				return
+			}
+
+			identity := shared.IdentityFromClaims(claims)
+			identity.RequestID = shared.RequestIDFromContext(r.Context())
+			next(w, r.WithContext(shared.WithIdentity(r.Context(), identity)))
+		}
+	}
+}
@@ -3,32 +3,36 @@ package models
 import (
 	"time"
 
+	"skool-management/shared"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type User struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Email        string             `bson:"email" json:"email"`
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id" jsonschema:"readonly"`
+	Email        string             `bson:"email" json:"email" jsonschema:"format=email"`
 	Password     string             `bson:"password" json:"-"`
 	FirstName    string             `bson:"first_name" json:"first_name"`
 	LastName     string             `bson:"last_name" json:"last_name"`
 	Role         string             `bson:"role" json:"role"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	SchoolIDs    []string           `bson:"school_ids,omitempty" json:"school_ids,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at" jsonschema:"readonly"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at" jsonschema:"readonly"`
 	RefreshToken string             `bson:"refresh_token" json:"-"`
 }
 
+// SignupRequest registers a user with the user role and no schools. Only an
+// admin can change the role and schools afterwards with UpdateUserRequest.
 type SignupRequest struct {
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Role      string `json:"role"`
+	Email     string `json:"email" jsonschema:"required,format=email"`
+	Password  string `json:"password" jsonschema:"required,minLength=1"`
+	FirstName string `json:"first_name" jsonschema:"required,minLength=1"`
+	LastName  string `json:"last_name" jsonschema:"required,minLength=1"`
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" jsonschema:"required,format=email"`
+	Password string `json:"password" jsonschema:"required,minLength=1"`
 }
 
 type LoginResponse struct {
@@ -38,5 +42,19 @@ type LoginResponse struct {
 }
 
 type RefreshRequest struct {
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token" jsonschema:"required,minLength=1"`
+}
+
+// UpdateUserRequest replaces a user's name, role and schools
+type UpdateUserRequest struct {
+	FirstName string   `json:"first_name" jsonschema:"required,minLength=1"`
+	LastName  string   `json:"last_name" jsonschema:"required,minLength=1"`
+	Role      string   `json:"role" jsonschema:"required,minLength=1"`
+	SchoolIDs []string `json:"school_ids,omitempty"`
+}
+
+// UserPage is the response of the user list
+type UserPage struct {
+	Items      []User            `json:"items"`
+	Pagination shared.Pagination `json:"pagination"`
 }
@@ -5,10 +5,12 @@ import (
 	"time"
 
 	"skool-management/auth-service/internal/models"
+	"skool-management/shared"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -21,47 +23,108 @@ func NewUserRepository(db *mongo.Database) *UserRepository {
 	}
 }
 
-func (r *UserRepository) Create(user *models.User) error {
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	result, err := r.collection.InsertOne(context.Background(), user)
-	if err != nil {
+	return shared.TraceDB(ctx, shared.DBSystemMongoDB, "UserRepository.Create", "users.insertOne", func(ctx context.Context) error {
+		result, err := r.collection.InsertOne(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		user.ID = result.InsertedID.(primitive.ObjectID)
+		return nil
+	})
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, "UserRepository.GetByEmail", bson.M{"email": email})
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return r.findOne(ctx, "UserRepository.GetByID", bson.M{"_id": id})
+}
+
+func (r *UserRepository) UpdateRefreshToken(ctx context.Context, userID primitive.ObjectID, refreshToken string) error {
+	filter := bson.M{"_id": userID}
+	update := bson.M{"$set": bson.M{"refresh_token": refreshToken, "updated_at": time.Now()}}
+	return shared.TraceDB(ctx, shared.DBSystemMongoDB, "UserRepository.UpdateRefreshToken", "users.updateOne", func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, filter, update)
 		return err
-	}
+	})
+}
 
-	user.ID = result.InsertedID.(primitive.ObjectID)
-	return nil
+func (r *UserRepository) GetByRefreshToken(ctx context.Context, refreshToken string) (*models.User, error) {
+	return r.findOne(ctx, "UserRepository.GetByRefreshToken", bson.M{"refresh_token": refreshToken})
 }
 
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
-	var user models.User
-	err := r.collection.FindOne(context.Background(), bson.M{"email": email}).Decode(&user)
+// GetPage returns one page of users, oldest first, and the total number of users
+func (r *UserRepository) GetPage(ctx context.Context, page shared.PageRequest) ([]models.User, int, error) {
+	users := []models.User{}
+	var total int64
+	err := shared.TraceDB(ctx, shared.DBSystemMongoDB, "UserRepository.GetPage", "users.find", func(ctx context.Context) error {
+		var err error
+		total, err = r.collection.CountDocuments(ctx, bson.M{})
+		if err != nil {
+			return err
+		}
+
+		opts := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+			SetSkip(int64(page.Offset())).
+			SetLimit(int64(page.PageSize))
+		cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &users)
+	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return &user, nil
+	return users, int(total), nil
 }
 
-func (r *UserRepository) GetByID(id primitive.ObjectID) (*models.User, error) {
+// Update replaces the name, role and schools of a user and returns the
+// updated user, or mongo.ErrNoDocuments when there is no such user
+func (r *UserRepository) Update(ctx context.Context, id primitive.ObjectID, req *models.UpdateUserRequest) (*models.User, error) {
+	update := bson.M{"$set": bson.M{
+		"first_name": req.FirstName,
+		"last_name":  req.LastName,
+		"role":       req.Role,
+		"school_ids": req.SchoolIDs,
+		"updated_at": time.Now(),
+	}}
 	var user models.User
-	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&user)
+	err := shared.TraceDB(ctx, shared.DBSystemMongoDB, "UserRepository.Update", "users.findOneAndUpdate", func(ctx context.Context) error {
+		return r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) UpdateRefreshToken(userID primitive.ObjectID, refreshToken string) error {
-	filter := bson.M{"_id": userID}
-	update := bson.M{"$set": bson.M{"refresh_token": refreshToken, "updated_at": time.Now()}}
-	_, err := r.collection.UpdateOne(context.Background(), filter, update)
-	return err
+// Delete removes a user, returning mongo.ErrNoDocuments when there is no such user
+func (r *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return shared.TraceDB(ctx, shared.DBSystemMongoDB, "UserRepository.Delete", "users.deleteOne", func(ctx context.Context) error {
+		result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+		return nil
+	})
 }
 
-func (r *UserRepository) GetByRefreshToken(refreshToken string) (*models.User, error) {
+func (r *UserRepository) findOne(ctx context.Context, operation string, filter bson.M) (*models.User, error) {
 	var user models.User
-	err := r.collection.FindOne(context.Background(), bson.M{"refresh_token": refreshToken}).Decode(&user)
+	err := shared.TraceDB(ctx, shared.DBSystemMongoDB, operation, "users.findOne", func(ctx context.Context) error {
+		return r.collection.FindOne(ctx, filter).Decode(&user)
+	})
 	if err != nil {
 		return nil, err
 	}
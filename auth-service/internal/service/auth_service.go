@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"time"
 
 	"skool-management/auth-service/internal/models"
@@ -25,26 +27,28 @@ func NewAuthService(userRepo *repository.UserRepository, jwtManager *shared.JWTM
 		jwtManager: jwtManager,
 		// Initialize circuit breaker for database operations
 		dbCircuitBreaker: shared.NewCircuitBreaker(shared.CircuitBreakerConfig{
-			Name:         "auth-database",
-			MaxFailures:  5,
-			ResetTimeout: 60 * time.Second,
+			Name:                 "auth-database",
+			ResetTimeout:         60 * time.Second,
+			WindowType:           shared.TimeBasedWindow,
+			WindowDuration:       60 * time.Second,
+			MinimumCalls:         10,
+			FailureRateThreshold: 50,
+			SlowCallDuration:     2 * time.Second,
+			HalfOpenMaxCalls:     2,
+			// Unknown emails are a normal outcome, not a database failure
+			IsFailure: shared.IgnoreErrors(mongo.ErrNoDocuments),
 		}),
 	}
 }
 
-func (s *AuthService) Signup(req *models.SignupRequest) (*models.User, error) {
+func (s *AuthService) Signup(ctx context.Context, req *models.SignupRequest) (*models.User, error) {
 	// Validate required fields
 	if req.Email == "" || req.Password == "" || req.FirstName == "" || req.LastName == "" {
 		return nil, errors.New("all fields are required")
 	}
 
-	// Set default role if not provided
-	if req.Role == "" {
-		req.Role = "user"
-	}
-
 	// Check if user already exists
-	_, err := s.userRepo.GetByEmail(req.Email)
+	_, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err == nil {
 		return nil, errors.New("user with this email already exists")
 	}
@@ -55,16 +59,17 @@ func (s *AuthService) Signup(req *models.SignupRequest) (*models.User, error) {
 		return nil, errors.New("failed to process password")
 	}
 
-	// Create user
+	// Self-registered users never choose their role or schools; an admin
+	// grants them with UpdateUser
 	user := &models.User{
 		Email:     req.Email,
 		Password:  string(hashedPassword),
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
-		Role:      req.Role,
+		Role:      shared.RoleUser,
 	}
 
-	err = s.userRepo.Create(user)
+	err = s.userRepo.Create(ctx, user)
 	if err != nil {
 		return nil, errors.New("failed to create user")
 	}
@@ -72,27 +77,44 @@ func (s *AuthService) Signup(req *models.SignupRequest) (*models.User, error) {
 	return user, nil
 }
 
-func (s *AuthService) Login(req *models.LoginRequest) (*models.LoginResponse, error) {
-	var user *models.User
-	var err error
-
-	// Use circuit breaker for database operations
-	dbErr := s.dbCircuitBreaker.Execute(func() error {
-		// Find user by email
-		user, err = s.userRepo.GetByEmail(req.Email)
+// EnsureAdmin creates an admin account with the given email and password
+// unless a user with that email exists. It bootstraps the first admin, who
+// can then grant roles to other users.
+func (s *AuthService) EnsureAdmin(ctx context.Context, email, password string) error {
+	_, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
 		return err
-	})
+	}
 
-	if dbErr != nil {
-		if dbErr.Error() == "circuit breaker is OPEN" {
-			return nil, errors.New("authentication service temporarily unavailable")
-		}
-		return nil, dbErr
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
 	}
+	return s.userRepo.Create(ctx, &models.User{
+		Email:     email,
+		Password:  string(hashedPassword),
+		FirstName: "Admin",
+		LastName:  "User",
+		Role:      shared.RoleAdmin,
+	})
+}
 
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest) (*models.LoginResponse, error) {
+	// Use circuit breaker for database operations
+	user, err := shared.ExecuteCtx(ctx, s.dbCircuitBreaker, func(ctx context.Context) (*models.User, error) {
+		return s.userRepo.GetByEmail(ctx, req.Email)
+	})
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		switch {
+		case errors.Is(err, shared.ErrCircuitOpen):
+			return nil, errors.New("authentication service temporarily unavailable")
+		case errors.Is(err, mongo.ErrNoDocuments):
 			return nil, errors.New("invalid email or password")
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return nil, err
 		}
 		return nil, errors.New("failed to find user")
 	}
@@ -104,20 +126,20 @@ func (s *AuthService) Login(req *models.LoginRequest) (*models.LoginResponse, er
 	}
 
 	// Generate tokens
-	accessToken, err := s.jwtManager.GenerateToken(user.ID.Hex(), user.Email)
+	accessToken, err := s.jwtManager.GenerateToken(user.ID.Hex(), user.Email, user.Role, user.SchoolIDs)
 	if err != nil {
 		return nil, errors.New("failed to generate access token")
 	}
 
-	refreshToken, err := s.jwtManager.GenerateRefreshToken(user.ID.Hex(), user.Email)
+	refreshToken, err := s.jwtManager.GenerateRefreshToken(user.ID.Hex(), user.Email, user.Role, user.SchoolIDs)
 	if err != nil {
 		return nil, errors.New("failed to generate refresh token")
 	}
 
 	// Store refresh token in database
-	err = s.userRepo.UpdateRefreshToken(user.ID, refreshToken)
+	err = s.userRepo.UpdateRefreshToken(ctx, user.ID, refreshToken)
 	if err != nil {
-		shared.LogError("AUTH_SERVICE", "refresh token storage", err)
+		slog.ErrorContext(ctx, "refresh token storage failed", "error", err)
 	}
 
 	response := &models.LoginResponse{
@@ -129,7 +151,7 @@ func (s *AuthService) Login(req *models.LoginRequest) (*models.LoginResponse, er
 	return response, nil
 }
 
-func (s *AuthService) RefreshToken(req *models.RefreshRequest) (string, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, req *models.RefreshRequest) (string, error) {
 	// Verify refresh token
 	claims, err := s.jwtManager.VerifyRefreshToken(req.RefreshToken)
 	if err != nil {
@@ -138,13 +160,13 @@ func (s *AuthService) RefreshToken(req *models.RefreshRequest) (string, error) {
 
 	// Find user and verify refresh token in database
 	userID, _ := primitive.ObjectIDFromHex(claims.UserID)
-	user, err := s.userRepo.GetByRefreshToken(req.RefreshToken)
+	user, err := s.userRepo.GetByRefreshToken(ctx, req.RefreshToken)
 	if err != nil || user.ID != userID {
 		return "", errors.New("invalid refresh token")
 	}
 
 	// Generate new access token
-	accessToken, err := s.jwtManager.GenerateToken(user.ID.Hex(), user.Email)
+	accessToken, err := s.jwtManager.GenerateToken(user.ID.Hex(), user.Email, user.Role, user.SchoolIDs)
 	if err != nil {
 		return "", errors.New("failed to generate access token")
 	}
@@ -155,3 +177,62 @@ func (s *AuthService) RefreshToken(req *models.RefreshRequest) (string, error) {
 func (s *AuthService) ValidateToken(token string) (*shared.JWTClaims, error) {
 	return s.jwtManager.VerifyToken(token)
 }
+
+// ListUsers returns one page of users and the total number of users
+func (s *AuthService) ListUsers(ctx context.Context, page shared.PageRequest) ([]models.User, int, error) {
+	return s.userRepo.GetPage(ctx, page)
+}
+
+func (s *AuthService) GetUser(ctx context.Context, id string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, objectID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser replaces a user's name, role and schools. Tokens already issued
+// keep the old claims until they are refreshed.
+func (s *AuthService) UpdateUser(ctx context.Context, id string, req *models.UpdateUserRequest) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	if req.FirstName == "" || req.LastName == "" || req.Role == "" {
+		return nil, errors.New("first name, last name and role are required")
+	}
+
+	user, err := s.userRepo.Update(ctx, objectID, req)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser removes a user. Their refresh token stops working at once; an
+// access token already issued stays valid until it expires.
+func (s *AuthService) DeleteUser(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	if err := s.userRepo.Delete(ctx, objectID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("user not found")
+		}
+		return err
+	}
+	return nil
+}
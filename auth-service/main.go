@@ -2,8 +2,8 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 
 	"skool-management/auth-service/internal/config"
@@ -19,13 +19,28 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	shared.InitLogger("auth-service", cfg.LogLevel)
+	lifecycle := shared.NewLifecycle(shared.ServerTimeouts{
+		Read:       cfg.ReadTimeout,
+		Write:      cfg.WriteTimeout,
+		Idle:       cfg.IdleTimeout,
+		Shutdown:   cfg.ShutdownTimeout,
+		DrainDelay: cfg.DrainDelay,
+	})
+
+	// Initialize tracing
+	shutdownTracing, err := shared.InitTracing(context.Background(), "auth-service")
+	if err != nil {
+		log.Fatal("Failed to initialize tracing:", err)
+	}
+	lifecycle.OnShutdown("tracing", shutdownTracing)
 
 	// Connect to MongoDB
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.MongoURI))
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.MongoURI).SetPoolMonitor(shared.MongoPoolMonitor()))
 	if err != nil {
 		log.Fatal("Failed to connect to MongoDB:", err)
 	}
-	defer client.Disconnect(context.Background())
+	lifecycle.OnShutdown("mongodb", client.Disconnect)
 
 	// Test connection
 	err = client.Ping(context.Background(), nil)
@@ -43,18 +58,45 @@ func main() {
 		cfg.RefreshTokenTTL,
 	)
 
+	// TLS for the HTTP listener; it stays plain text without a certificate
+	serverTLS, err := shared.NewServerTLSConfig("auth-service", shared.TLSFiles{
+		CertFile: cfg.TLSCertFile,
+		KeyFile:  cfg.TLSKeyFile,
+		CAFile:   cfg.TLSClientCAFile,
+	}, cfg.TLSReloadInterval)
+	if err != nil {
+		log.Fatal("Failed to load TLS certificates:", err)
+	}
+
 	// Initialize layers
 	userRepo := repository.NewUserRepository(db)
 	authService := service.NewAuthService(userRepo, jwtManager)
 	authHandlers := handlers.NewAuthHandlers(authService)
 
+	// Bootstrap the first admin; nobody can become one through signup
+	if cfg.AdminEmail != "" && cfg.AdminPassword != "" {
+		if err := authService.EnsureAdmin(context.Background(), cfg.AdminEmail, cfg.AdminPassword); err != nil {
+			log.Fatal("Failed to create admin user:", err)
+		}
+	}
+
+	// Health checks: /livez only reports the process is up, /readyz and
+	// /health also verify dependencies
+	readiness := shared.ReadinessHandler(lifecycle.ReadinessCheck(), shared.MongoPingCheck("mongodb", client))
+	admin := requireAdmin(jwtManager)
+	breakerAdmin := admin(shared.CircuitBreakerAdminHandler(shared.DefaultCircuitBreakerRegistry))
+
 	// Setup routes
-	http.HandleFunc("/signup", authHandlers.Signup)
-	http.HandleFunc("/login", authHandlers.Login)
-	http.HandleFunc("/refresh", authHandlers.Refresh)
-	http.HandleFunc("/validate", authHandlers.ValidateToken)
-	http.HandleFunc("/health", authHandlers.Health)
-
-	shared.LogInfo("AUTH_SERVICE", fmt.Sprintf("Starting auth service on port %s", cfg.Port))
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, nil))
+	shared.RegisterRoutes(http.DefaultServeMux, routes(authHandlers, admin, readiness, breakerAdmin))
+
+	slog.Info("starting auth service", "port", cfg.Port, "tls", serverTLS != nil)
+	httpServer := lifecycle.NewHTTPServer(":"+cfg.Port, shared.TraceHandler(shared.InstrumentHandler(shared.CompressHandler(shared.RequestIDHandler(shared.AccessLogHandler(shared.NewAPIVersioning(shared.APIVersionConfig{}).Handler(shared.RouteHandler(http.DefaultServeMux)))))), "auth-service"))
+	httpServer.TLSConfig = serverTLS
+	lifecycle.ServeHTTP(httpServer)
+
+	// Block until SIGINT/SIGTERM, then drain servers and close resources
+	if err := lifecycle.Wait(); err != nil {
+		log.Fatal("Server failed:", err)
+	}
+	slog.Info("auth service stopped")
 }
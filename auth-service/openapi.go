@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+
+	"skool-management/auth-service/internal/models"
+	"skool-management/shared"
+)
+
+// TokenClaims is the data returned by /validate
+type TokenClaims struct {
+	UserID    string   `json:"user_id"`
+	Email     string   `json:"email" jsonschema:"format=email"`
+	Role      string   `json:"role"`
+	SchoolIDs []string `json:"school_ids"`
+}
+
+// AccessToken is the data returned by /refresh
+type AccessToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+// openAPISpec describes the auth service's HTTP API
+func openAPISpec() *shared.OpenAPIDocument {
+	validate := shared.Endpoint{
+		Method: http.MethodGet, Path: "/validate", Tag: "auth", Authenticated: true,
+		Summary:     "Validate an access token",
+		Description: "The token is read from the Authorization header.",
+		Response:    TokenClaims{},
+		Errors:      []int{http.StatusUnauthorized},
+	}
+	validatePost := validate
+	validatePost.Method = http.MethodPost
+	validatePost.Summary = "Validate an access token (legacy method)"
+
+	return shared.NewOpenAPIDocument("Auth Service", "1.0.0", "Issues and validates JWT access and refresh tokens.").AddEndpoints(
+		shared.Endpoint{
+			Method: http.MethodPost, Path: "/signup", Tag: "auth",
+			Strict:     true,
+			Idempotent: true,
+			Summary:    "Register a user",
+			Request:    models.SignupRequest{},
+			Response:   models.User{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodPost, Path: "/login", Tag: "auth",
+			Strict:   true,
+			Summary:  "Log in with email and password",
+			Request:  models.LoginRequest{},
+			Response: models.LoginResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodPost, Path: "/refresh", Tag: "auth",
+			Strict:   true,
+			Summary:  "Exchange a refresh token for a new access token",
+			Request:  models.RefreshRequest{},
+			Response: AccessToken{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized},
+		},
+		validate,
+		validatePost,
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/users", Tag: "users", Authenticated: true,
+			Summary:     "List users a page at a time (admin)",
+			QueryParams: shared.PaginationQueryParams(),
+			Response:    models.UserPage{},
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/users/{id}", Tag: "users", Authenticated: true,
+			Summary:  "Get a user (admin)",
+			Response: models.User{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodPut, Path: "/users/{id}", Tag: "users", Authenticated: true,
+			Summary:     "Update a user's name, role and schools (admin)",
+			Description: "Tokens already issued keep the old role and schools until they are refreshed.",
+			Request:     models.UpdateUserRequest{},
+			Response:    models.User{},
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodDelete, Path: "/users/{id}", Tag: "users", Authenticated: true,
+			Summary:     "Delete a user (admin)",
+			Description: "The user's refresh token stops working at once; access tokens already issued stay valid until they expire.",
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+	).AddEndpoints(shared.OperationalEndpoints()...)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+
+	"skool-management/auth-service/internal/handlers"
+	"skool-management/auth-service/internal/middleware"
+	"skool-management/shared"
+)
+
+// requireAdmin authenticates the caller and requires the admin role. Signup
+// never grants that role, so only users an admin promoted get through.
+func requireAdmin(jwtManager *shared.JWTManager) func(http.HandlerFunc) http.HandlerFunc {
+	authMiddleware := middleware.AuthMiddleware(jwtManager)
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware(shared.RequireRole(shared.RoleAdmin, next))
+	}
+}
+
+// routes lists every HTTP route the auth service serves. Each one must be
+// described by openAPISpec, which routes_test.go enforces.
+// admin authenticates the caller and requires the admin role.
+func routes(h *handlers.AuthHandlers, admin func(http.HandlerFunc) http.HandlerFunc, readiness, breakerAdmin http.HandlerFunc) []shared.Route {
+	return append([]shared.Route{
+		{Method: http.MethodPost, Path: "/signup", Handler: h.Signup},
+		{Method: http.MethodPost, Path: "/login", Handler: h.Login},
+		{Method: http.MethodPost, Path: "/refresh", Handler: h.Refresh},
+		{Method: http.MethodGet, Path: "/validate", Handler: h.ValidateToken},
+		// Older clients validate with POST; the token is read from the header either way
+		{Method: http.MethodPost, Path: "/validate", Handler: h.ValidateToken},
+		{Method: http.MethodGet, Path: "/users", Handler: admin(h.ListUsers)},
+		{Method: http.MethodGet, Path: "/users/{id}", Handler: admin(h.GetUser)},
+		{Method: http.MethodPut, Path: "/users/{id}", Handler: admin(h.UpdateUser)},
+		{Method: http.MethodDelete, Path: "/users/{id}", Handler: admin(h.DeleteUser)},
+	}, shared.OperationalRoutes(readiness, breakerAdmin, openAPISpec())...)
+}
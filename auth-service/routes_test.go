@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"skool-management/auth-service/internal/handlers"
+	"skool-management/auth-service/internal/repository"
+	"skool-management/auth-service/internal/service"
+	"skool-management/shared"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	passthrough := func(next http.HandlerFunc) http.HandlerFunc { return next }
+	for _, problem := range shared.CheckRouteCoverage(routes(nil, passthrough, nil, nil), openAPISpec()) {
+		t.Error(problem)
+	}
+}
+
+func TestSelfRegisteredUserCannotUseAdminRoutes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("signup asking for admin", func(mt *mtest.T) {
+		jwtManager := shared.NewJWTManager("secret", "refresh-secret", time.Minute, time.Hour)
+		authService := service.NewAuthService(repository.NewUserRepository(mt.DB), jwtManager)
+		registry := shared.NewCircuitBreakerRegistry()
+		breaker := shared.NewCircuitBreaker(shared.CircuitBreakerConfig{Name: "test"})
+		registry.Register(breaker)
+
+		admin := requireAdmin(jwtManager)
+		mux := http.NewServeMux()
+		shared.RegisterRoutes(mux, routes(handlers.NewAuthHandlers(authService), admin, nil, admin(shared.CircuitBreakerAdminHandler(registry))))
+
+		call := func(method, path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			return rec
+		}
+
+		// Signup looks the email up, finds nothing and inserts the user
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "authdb.users", mtest.FirstBatch), mtest.CreateSuccessResponse())
+		rec := call(http.MethodPost, "/signup", "",
+			`{"email":"eve@example.com","password":"secret","first_name":"Eve","last_name":"Doe","role":"admin","school_ids":["1"]}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("signup status = %d: %s", rec.Code, rec.Body)
+		}
+		var signup struct {
+			Data struct {
+				Role      string   `json:"role"`
+				SchoolIDs []string `json:"school_ids"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &signup); err != nil {
+			t.Fatal(err)
+		}
+		if signup.Data.Role != shared.RoleUser || len(signup.Data.SchoolIDs) != 0 {
+			t.Fatalf("signup stored role %q and schools %v, want user without schools", signup.Data.Role, signup.Data.SchoolIDs)
+		}
+
+		// Login reads back the user as signup stored it
+		hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(1, "authdb.users", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "email", Value: "eve@example.com"},
+				{Key: "password", Value: string(hash)},
+				{Key: "role", Value: signup.Data.Role},
+			}),
+			mtest.CreateSuccessResponse(),
+		)
+		rec = call(http.MethodPost, "/login", "", `{"email":"eve@example.com","password":"secret"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("login status = %d: %s", rec.Code, rec.Body)
+		}
+		var login struct {
+			Data struct {
+				AccessToken string `json:"access_token"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &login); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, path := range []string{shared.CircuitBreakerAdminPath, shared.CircuitBreakerAdminPath + "/test/open"} {
+			method := http.MethodGet
+			if strings.HasSuffix(path, "/open") {
+				method = http.MethodPost
+			}
+			if rec := call(method, path, login.Data.AccessToken, ""); rec.Code != http.StatusForbidden {
+				t.Errorf("%s %s status = %d, want 403", method, path, rec.Code)
+			}
+		}
+		if rec := call(http.MethodGet, "/users", login.Data.AccessToken, ""); rec.Code != http.StatusForbidden {
+			t.Errorf("GET /users status = %d, want 403", rec.Code)
+		}
+		if breaker.Stats().Forced {
+			t.Error("a self-registered user forced the breaker")
+		}
+	})
+}
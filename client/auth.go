@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// Login authenticates with email and password and keeps the tokens for
+// later calls
+func (c *Client) Login(ctx context.Context, email, password string) (*LoginResponse, error) {
+	var resp LoginResponse
+	err := c.call(ctx, request{method: http.MethodPost, path: "/auth/login", body: map[string]string{"email": email, "password": password}, anonymous: true}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	c.SetTokens(Tokens{AccessToken: resp.AccessToken, RefreshToken: resp.RefreshToken})
+	return &resp, nil
+}
+
+// Refresh replaces the access token using the refresh token. Calls refresh
+// by themselves when the access token has expired; this is for callers
+// that want a fresh token up front.
+func (c *Client) Refresh(ctx context.Context) error {
+	return c.refresh(ctx, c.Tokens().AccessToken)
+}
+
+// Signup creates a user
+func (c *Client) Signup(ctx context.Context, req SignupRequest) (*User, error) {
+	var user User
+	if err := c.call(ctx, request{method: http.MethodPost, path: "/auth/signup", body: req}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Validate returns the claims of the access token
+func (c *Client) Validate(ctx context.Context) (*Claims, error) {
+	var claims Claims
+	if err := c.call(ctx, request{method: http.MethodGet, path: "/auth/validate"}, &claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// ListUsers returns a page of users; admin only
+func (c *Client) ListUsers(ctx context.Context, opts ListOptions) (*Page[User], error) {
+	return listPage[User](ctx, c, "/auth/users", opts)
+}
+
+// AllUsers iterates over every user from opts.Page on; admin only
+func (c *Client) AllUsers(ctx context.Context, opts ListOptions) iter.Seq2[User, error] {
+	return all(ctx, opts, c.ListUsers)
+}
+
+// GetUser returns a user; admin only
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	var user User
+	if err := c.call(ctx, request{method: http.MethodGet, path: userPath(id)}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser replaces a user's name, role and schools; admin only
+func (c *Client) UpdateUser(ctx context.Context, id string, req UpdateUserRequest) (*User, error) {
+	var user User
+	if err := c.call(ctx, request{method: http.MethodPut, path: userPath(id), body: req}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser deletes a user; admin only
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: userPath(id)}, nil)
+}
+
+func userPath(id string) string {
+	return "/auth/users/" + url.PathEscape(id)
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"skool-management/shared"
+)
+
+// Actions of ChangeBreaker
+const (
+	BreakerOpen  = "open"
+	BreakerClose = "close"
+	BreakerReset = "reset"
+)
+
+// ListBreakers returns the gateway's circuit breakers; admin only
+func (c *Client) ListBreakers(ctx context.Context) ([]shared.CircuitBreakerView, error) {
+	var breakers []shared.CircuitBreakerView
+	if err := c.call(ctx, request{method: http.MethodGet, path: shared.CircuitBreakerAdminPath}, &breakers); err != nil {
+		return nil, err
+	}
+	return breakers, nil
+}
+
+// GetBreaker returns one of the gateway's circuit breakers; admin only
+func (c *Client) GetBreaker(ctx context.Context, name string) (*shared.CircuitBreakerView, error) {
+	var breaker shared.CircuitBreakerView
+	if err := c.call(ctx, request{method: http.MethodGet, path: breakerPath(name)}, &breaker); err != nil {
+		return nil, err
+	}
+	return &breaker, nil
+}
+
+// ChangeBreaker forces a breaker open or closed, or resets it, with
+// BreakerOpen, BreakerClose or BreakerReset; admin only
+func (c *Client) ChangeBreaker(ctx context.Context, name, action string) (*shared.CircuitBreakerView, error) {
+	var breaker shared.CircuitBreakerView
+	if err := c.call(ctx, request{method: http.MethodPost, path: breakerPath(name) + "/" + url.PathEscape(action)}, &breaker); err != nil {
+		return nil, err
+	}
+	return &breaker, nil
+}
+
+func breakerPath(name string) string {
+	return shared.CircuitBreakerAdminPath + "/" + url.PathEscape(name)
+}
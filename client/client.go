@@ -0,0 +1,335 @@
+// Package client is a typed Go client for the public API served by the
+// gateway: authentication and users, schools, students, the change feed and
+// the circuit breaker admin API.
+//
+//	c := client.New(client.Config{BaseURL: "http://localhost:8080"})
+//	if _, err := c.Login(ctx, "admin@school.com", password); err != nil {
+//		return err
+//	}
+//	for school, err := range c.AllSchools(ctx, client.ListOptions{}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(school.Name)
+//	}
+//
+// The client refreshes an expired access token once per request with the
+// refresh token, retries calls that are safe to repeat when the gateway or
+// a service is briefly unavailable, and returns API failures as *Error,
+// which errors.Is matches against ErrNotFound, ErrConflict and the other
+// sentinel errors.
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"skool-management/shared"
+)
+
+// Defaults applied to zero valued Config fields
+const (
+	DefaultTimeout         = 30 * time.Second
+	DefaultMaxRetries      = 2
+	DefaultRetryBackoff    = 200 * time.Millisecond
+	DefaultMaxRetryBackoff = 5 * time.Second
+	DefaultUserAgent       = "skool-management-client"
+)
+
+// Config configures a Client
+type Config struct {
+	// BaseURL is the gateway, e.g. http://localhost:8080
+	BaseURL string
+	// HTTPClient sends the requests; http.DefaultClient when nil
+	HTTPClient *http.Client
+	// Timeout bounds each attempt of a call, reading the response included.
+	// Event streams are bounded by their context only.
+	Timeout time.Duration
+	// MaxRetries is how often a failed call that is safe to repeat is
+	// retried; negative disables retries
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry. It doubles with
+	// every retry up to MaxRetryBackoff; a Retry-After header takes
+	// precedence.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Tokens are the credentials to start with, e.g. saved from an earlier
+	// Login
+	Tokens Tokens
+	// OnTokenRefresh is called after the access token was refreshed, so the
+	// new tokens can be saved
+	OnTokenRefresh func(Tokens)
+	UserAgent      string
+}
+
+// Tokens are the credentials Login returns
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// Client calls the gateway. It is safe for concurrent use.
+type Client struct {
+	config  Config
+	baseURL string
+
+	mutex  sync.Mutex
+	tokens Tokens
+	// refreshing serializes refreshes, so concurrent calls that all find
+	// the access token expired refresh it once
+	refreshing sync.Mutex
+}
+
+// New creates a client, applying defaults to zero valued config fields
+func New(config Config) *Client {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = DefaultRetryBackoff
+	}
+	if config.MaxRetryBackoff <= 0 {
+		config.MaxRetryBackoff = DefaultMaxRetryBackoff
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+	return &Client{config: config, baseURL: strings.TrimSuffix(config.BaseURL, "/"), tokens: config.Tokens}
+}
+
+// BaseURL returns the gateway the client calls
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Tokens returns the current credentials
+func (c *Client) Tokens() Tokens {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.tokens
+}
+
+// SetTokens replaces the credentials
+func (c *Client) SetTokens(tokens Tokens) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.tokens = tokens
+}
+
+// request is one API call
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	// idempotencyKey makes a POST safe to retry
+	idempotencyKey string
+	// anonymous calls send no access token
+	anonymous bool
+}
+
+// call sends req and decodes the data of the success envelope into out,
+// which may be nil
+func (c *Client) call(ctx context.Context, req request, out any) error {
+	var payload []byte
+	if req.body != nil {
+		var err error
+		if payload, err = json.Marshal(req.body); err != nil {
+			return err
+		}
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		var token string
+		if !req.anonymous {
+			token = c.Tokens().AccessToken
+		}
+		status, header, data, err := c.send(ctx, req, payload, token)
+		if err == nil && status < 400 {
+			return decodeData(data, out)
+		}
+		if err == nil {
+			err = newError(status, header, data)
+		}
+
+		if status == http.StatusUnauthorized && !req.anonymous && !refreshed && c.canRefresh() {
+			refreshed = true
+			if refreshErr := c.refresh(ctx, token); refreshErr != nil {
+				return refreshErr
+			}
+			attempt--
+			continue
+		}
+		if attempt >= c.config.MaxRetries || !c.retryable(req, status, err) {
+			return err
+		}
+		if err := c.wait(ctx, attempt, err); err != nil {
+			return err
+		}
+	}
+}
+
+// send makes one attempt and reads the whole response
+func (c *Client) send(ctx context.Context, req request, payload []byte, token string) (int, http.Header, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	httpReq, err := c.newRequest(ctx, req, payload, token)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	resp, err := c.config.HTTPClient.Do(httpReq)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return resp.StatusCode, resp.Header, data, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, req request, payload []byte, token string) (*http.Request, error) {
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.config.UserAgent)
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	if req.idempotencyKey != "" {
+		httpReq.Header.Set(shared.HeaderIdempotencyKey, req.idempotencyKey)
+	}
+	return httpReq, nil
+}
+
+// canRefresh reports whether a 401 may be fixed by refreshing
+func (c *Client) canRefresh() bool {
+	return c.Tokens().RefreshToken != ""
+}
+
+// refresh exchanges the refresh token for a new access token, unless
+// another call already replaced the stale token
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+	tokens := c.Tokens()
+	if tokens.AccessToken != stale {
+		return nil
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	err := c.call(ctx, request{method: http.MethodPost, path: "/auth/refresh", body: map[string]string{"refresh_token": tokens.RefreshToken}, anonymous: true}, &resp)
+	if err != nil {
+		return fmt.Errorf("refreshing the access token: %w", err)
+	}
+
+	tokens.AccessToken = resp.AccessToken
+	c.SetTokens(tokens)
+	if c.config.OnTokenRefresh != nil {
+		c.config.OnTokenRefresh(tokens)
+	}
+	return nil
+}
+
+// retryable reports whether a failed attempt may be repeated. Only reads,
+// replacements, deletes and POSTs with an idempotency key are safe to send
+// twice, and only failures that may pass are worth retrying.
+func (c *Client) retryable(req request, status int, err error) bool {
+	if req.method == http.MethodPost && req.idempotencyKey == "" {
+		return false
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		// The request may not have reached the gateway at all
+		return !errors.Is(err, context.Canceled)
+	}
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		return apiErr.Code == "IDEMPOTENCY_KEY_IN_USE"
+	}
+	return false
+}
+
+// wait sleeps before retry attempt+1: the Retry-After the gateway asked for,
+// else an exponential backoff with jitter
+func (c *Client) wait(ctx context.Context, attempt int, err error) error {
+	delay := c.config.RetryBackoff << attempt
+	if delay <= 0 || delay > c.config.MaxRetryBackoff {
+		delay = c.config.MaxRetryBackoff
+	}
+	delay = delay/2 + rand.N(delay/2+1)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		delay = min(apiErr.RetryAfter, c.config.MaxRetryBackoff)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// decodeData unwraps the success envelope
+func decodeData(data []byte, out any) error {
+	if out == nil {
+		return nil
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+// NewIdempotencyKey returns a random key for a single create. Reuse the key
+// when repeating the same create, e.g. after a crash, to have the gateway
+// replay the first response instead of creating a duplicate.
+func NewIdempotencyKey() string {
+	return cryptorand.Text()
+}
@@ -0,0 +1,315 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"skool-management/shared"
+)
+
+// newTestClient serves handler and returns a client for it that retries
+// without noticeable delay
+func newTestClient(t *testing.T, handler http.HandlerFunc, tokens Tokens) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(Config{BaseURL: server.URL, Tokens: tokens, RetryBackoff: time.Millisecond, MaxRetryBackoff: 5 * time.Millisecond})
+}
+
+func TestLoginKeepsTokensAndRefreshesOnExpiry(t *testing.T) {
+	var refreshes atomic.Int32
+	var saved Tokens
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/login":
+			if r.Header.Get("Authorization") != "" {
+				t.Error("login sent an Authorization header")
+			}
+			shared.WriteSuccessResponse(w, http.StatusOK, "Login successful", LoginResponse{
+				User:         User{Email: "admin@school.com", Role: "admin"},
+				AccessToken:  "expired",
+				RefreshToken: "refresh",
+			})
+		case "/auth/refresh":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["refresh_token"] != "refresh" {
+				shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid refresh token")
+				return
+			}
+			refreshes.Add(1)
+			shared.WriteSuccessResponse(w, http.StatusOK, "Token refreshed successfully", map[string]string{"access_token": "fresh"})
+		case "/schools/7":
+			if r.Header.Get("Authorization") != "Bearer fresh" {
+				shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token")
+				return
+			}
+			shared.WriteSuccessResponse(w, http.StatusOK, "School retrieved successfully", School{ID: 7, Name: "Springfield Elementary"})
+		}
+	}
+	c := newTestClient(t, handler, Tokens{})
+	c.config.OnTokenRefresh = func(tokens Tokens) { saved = tokens }
+
+	resp, err := c.Login(context.Background(), "admin@school.com", "secret")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if resp.User.Role != "admin" {
+		t.Errorf("role = %q, want admin", resp.User.Role)
+	}
+
+	school, err := c.GetSchool(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetSchool: %v", err)
+	}
+	if school.Name != "Springfield Elementary" {
+		t.Errorf("name = %q", school.Name)
+	}
+	if refreshes.Load() != 1 {
+		t.Errorf("refreshed %d times, want 1", refreshes.Load())
+	}
+	if want := (Tokens{AccessToken: "fresh", RefreshToken: "refresh"}); c.Tokens() != want || saved != want {
+		t.Errorf("tokens = %+v, saved %+v, want %+v", c.Tokens(), saved, want)
+	}
+}
+
+func TestExpiredRefreshTokenIsUnauthorized(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token")
+	}
+	c := newTestClient(t, handler, Tokens{AccessToken: "expired", RefreshToken: "expired"})
+
+	_, err := c.Validate(context.Background())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestErrorsMatchSentinels(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+		want   error
+	}{
+		{http.StatusBadRequest, "VALIDATION_ERROR", ErrInvalidRequest},
+		{http.StatusForbidden, "FORBIDDEN", ErrForbidden},
+		{http.StatusNotFound, "SCHOOL_NOT_FOUND", ErrNotFound},
+		{http.StatusConflict, "REGISTRATION_EXISTS", ErrConflict},
+		{http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", ErrIdempotencyKeyReused},
+		{http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN", ErrUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(shared.HeaderRequestID, "req-1")
+				shared.WriteErrorResponse(w, tt.status, tt.code, "failed")
+			}
+			c := newTestClient(t, handler, Tokens{AccessToken: "token"})
+
+			_, err := c.UpdateSchool(context.Background(), 1, SchoolRequest{})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.code || apiErr.StatusCode != tt.status || apiErr.RequestID != "req-1" {
+				t.Errorf("err = %#v", err)
+			}
+			if errors.Is(err, ErrUnauthorized) {
+				t.Error("error matches ErrUnauthorized")
+			}
+		})
+	}
+}
+
+func TestValidationErrorCarriesFields(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		shared.WriteValidationErrorResponse(w, []shared.FieldError{{In: "body", Field: "email", Message: "must be an email"}})
+	}
+	c := newTestClient(t, handler, Tokens{AccessToken: "token"})
+
+	_, err := c.CreateStudent(context.Background(), StudentRequest{Email: "nope"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "email" {
+		t.Fatalf("err = %#v, want the email field", err)
+	}
+}
+
+func TestAllSchoolsWalksEveryPage(t *testing.T) {
+	const total, pageSize = 5, 2
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/schools" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		var items []School
+		for id := (page-1)*size + 1; id <= min(page*size, total); id++ {
+			items = append(items, School{ID: id})
+		}
+		shared.WriteSuccessResponse(w, http.StatusOK, "Schools retrieved successfully", Page[School]{
+			Items:      items,
+			Pagination: shared.NewPagination(shared.PageRequest{Page: page, PageSize: size}, total),
+		})
+	}
+	c := newTestClient(t, handler, Tokens{AccessToken: "token"})
+
+	schools, err := Collect(c.AllSchools(context.Background(), ListOptions{PageSize: pageSize}))
+	if err != nil {
+		t.Fatalf("AllSchools: %v", err)
+	}
+	if len(schools) != total {
+		t.Fatalf("got %d schools, want %d", len(schools), total)
+	}
+	for i, school := range schools {
+		if school.ID != i+1 {
+			t.Errorf("schools[%d].ID = %d", i, school.ID)
+		}
+	}
+}
+
+func TestAllStopsAtError(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			shared.WriteErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Access denied")
+			return
+		}
+		shared.WriteSuccessResponse(w, http.StatusOK, "Students retrieved successfully", Page[Student]{
+			Items:      []Student{{ID: 1}},
+			Pagination: shared.Pagination{Page: 1, PageSize: 1, Total: 3, TotalPages: 3},
+		})
+	}
+	c := newTestClient(t, handler, Tokens{AccessToken: "token"})
+
+	var ids []int
+	var lastErr error
+	for student, err := range c.AllStudentsBySchool(context.Background(), 4, ListOptions{PageSize: 1}) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ids = append(ids, student.ID)
+	}
+	if len(ids) != 1 || !errors.Is(lastErr, ErrForbidden) {
+		t.Fatalf("ids = %v, err = %v", ids, lastErr)
+	}
+}
+
+func TestRetriesUnavailableWithSameIdempotencyKey(t *testing.T) {
+	var calls atomic.Int32
+	keys := make(chan string, 3)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get(shared.HeaderIdempotencyKey)
+		if calls.Add(1) < 3 {
+			shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN", "School service is unavailable")
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req SchoolRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.Name != "Shelbyville High" {
+			t.Errorf("retried body = %s", body)
+		}
+		shared.WriteSuccessResponse(w, http.StatusCreated, "School created successfully", School{ID: 3, Name: req.Name})
+	}
+	c := newTestClient(t, handler, Tokens{AccessToken: "token"})
+
+	school, err := c.CreateSchool(context.Background(), SchoolRequest{Name: "Shelbyville High"})
+	if err != nil {
+		t.Fatalf("CreateSchool: %v", err)
+	}
+	if school.ID != 3 || calls.Load() != 3 {
+		t.Fatalf("school = %+v after %d calls", school, calls.Load())
+	}
+	first := <-keys
+	if first == "" || <-keys != first || <-keys != first {
+		t.Error("retries used a different idempotency key")
+	}
+}
+
+func TestDoesNotRetryUnsafeRequests(t *testing.T) {
+	var calls atomic.Int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Unavailable")
+	}
+	c := newTestClient(t, handler, Tokens{AccessToken: "token"})
+
+	_, err := c.Signup(context.Background(), SignupRequest{Email: "new@school.com"})
+	if !errors.Is(err, ErrUnavailable) || calls.Load() != 1 {
+		t.Fatalf("err = %v after %d calls, want one call", err, calls.Load())
+	}
+
+	calls.Store(0)
+	if _, err := c.ListSchools(context.Background(), ListOptions{}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v", err)
+	}
+	if calls.Load() != DefaultMaxRetries+1 {
+		t.Errorf("GET made %d calls, want %d", calls.Load(), DefaultMaxRetries+1)
+	}
+}
+
+func TestContextCancelStopsRetries(t *testing.T) {
+	var calls atomic.Int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "1")
+		shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "UPSTREAM_SATURATED", "Busy")
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+	c := New(Config{BaseURL: server.URL, Tokens: Tokens{AccessToken: "token"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetStudent(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second || calls.Load() != 1 {
+		t.Errorf("returned after %s and %d calls", elapsed, calls.Load())
+	}
+}
+
+func TestEventsStreamsAndResumes(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != shared.EventStreamPath || r.URL.Query().Get("types") != "student" || r.URL.Query().Get("school_ids") != "1,2" {
+			t.Errorf("request = %s", r.URL)
+		}
+		if r.URL.Query().Get("last_event_id") != "e-1" {
+			t.Errorf("last_event_id = %q", r.URL.Query().Get("last_event_id"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "retry: 1500\n\n: heartbeat\n\n")
+		data, _ := json.Marshal(shared.Event{ID: "e-2", Type: shared.EventStudentCreated, Entity: shared.EventEntityStudent, EntityID: 9, ActorEmail: "admin@school.com"})
+		io.WriteString(w, "id: e-2\nevent: student.created\ndata: "+string(data)+"\n\n")
+	}
+	c := newTestClient(t, handler, Tokens{AccessToken: "token"})
+
+	stream, err := c.Events(context.Background(), EventOptions{Types: []string{shared.EventEntityStudent}, SchoolIDs: []int{1, 2}, LastEventID: "e-1"})
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	defer stream.Close()
+
+	event, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if event.EntityID != 9 || event.ActorEmail != "admin@school.com" || stream.LastEventID() != "e-2" {
+		t.Errorf("event = %+v, last ID %q", event, stream.LastEventID())
+	}
+	if stream.Retry() != 1500*time.Millisecond {
+		t.Errorf("retry = %s", stream.Retry())
+	}
+	if _, err := stream.Next(); err != io.EOF {
+		t.Errorf("err = %v at the end of the stream, want io.EOF", err)
+	}
+}
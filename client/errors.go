@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"skool-management/shared"
+)
+
+// Sentinel errors an *Error matches with errors.Is, by the kind of failure
+var (
+	// ErrInvalidRequest: the request was malformed or failed validation;
+	// see Error.Fields
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrUnauthorized: the credentials are missing, wrong or expired and
+	// could not be refreshed
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden: the user may not do this, e.g. outside their schools
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound: the school, student, user or breaker does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict: a unique field such as a registration number, roll
+	// number or email is taken
+	ErrConflict = errors.New("conflict")
+	// ErrIdempotencyKeyReused: the idempotency key was used for a
+	// different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused")
+	// ErrUnavailable: the gateway or a service is overloaded, failing or
+	// shutting down; the call was retried as far as it was safe
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	// Code is the machine readable code, e.g. SCHOOL_NOT_FOUND
+	Code      string
+	Message   string
+	RequestID string
+	// Fields lists the invalid fields of a VALIDATION_ERROR
+	Fields []shared.FieldError
+	// RetryAfter is the delay the server asked for before retrying
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%s (%d %s)", e.Message, e.StatusCode, e.Code)
+	for _, field := range e.Fields {
+		fmt.Fprintf(&msg, "\n  %s %s: %s", field.In, field.Field, field.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&msg, "\n  request ID: %s", e.RequestID)
+	}
+	return msg.String()
+}
+
+// Is matches the sentinel error of the failure's kind
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalidRequest:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusRequestEntityTooLarge || e.StatusCode == http.StatusUnsupportedMediaType
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict && e.Code != "IDEMPOTENCY_KEY_IN_USE"
+	case ErrIdempotencyKeyReused:
+		return e.Code == "IDEMPOTENCY_KEY_REUSED"
+	case ErrUnavailable:
+		switch e.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return e.Code == "IDEMPOTENCY_KEY_IN_USE"
+	}
+	return false
+}
+
+// newError decodes an error response. Responses that are not the API's
+// error format, e.g. from a proxy in front of the gateway, get the code
+// HTTP_<status>.
+func newError(status int, header http.Header, data []byte) *Error {
+	var body shared.ErrorResponse
+	if json.Unmarshal(data, &body) != nil || body.Error == "" {
+		body = shared.ErrorResponse{Error: "HTTP_" + strconv.Itoa(status), Message: http.StatusText(status)}
+	}
+	err := &Error{
+		StatusCode: status,
+		Code:       body.Error,
+		Message:    body.Message,
+		RequestID:  body.RequestID,
+		Fields:     body.Fields,
+	}
+	if err.RequestID == "" {
+		err.RequestID = header.Get(shared.HeaderRequestID)
+	}
+	if seconds, parseErr := strconv.Atoi(header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		err.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"skool-management/shared"
+)
+
+// EventOptions selects the changes an event stream delivers
+type EventOptions struct {
+	// Types are the entities to follow, shared.EventEntitySchool and/or
+	// shared.EventEntityStudent; all when empty
+	Types []string
+	// SchoolIDs are the schools to follow; every school the user may
+	// access when empty
+	SchoolIDs []int
+	// LastEventID resumes after this event, replaying what was missed
+	LastEventID string
+}
+
+// EventStream is a connection to the change feed
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	lastID  string
+	retry   time.Duration
+}
+
+// Events connects to the change feed over Server-Sent Events. The stream
+// stays open until ctx ends, Close is called or the connection drops; to
+// carry on, connect again with LastEventID set to stream.LastEventID().
+func (c *Client) Events(ctx context.Context, opts EventOptions) (*EventStream, error) {
+	query := url.Values{}
+	if len(opts.Types) > 0 {
+		query.Set("types", strings.Join(opts.Types, ","))
+	}
+	if len(opts.SchoolIDs) > 0 {
+		ids := make([]string, len(opts.SchoolIDs))
+		for i, id := range opts.SchoolIDs {
+			ids[i] = strconv.Itoa(id)
+		}
+		query.Set("school_ids", strings.Join(ids, ","))
+	}
+	if opts.LastEventID != "" {
+		query.Set("last_event_id", opts.LastEventID)
+	}
+
+	refreshed := false
+	for {
+		token := c.Tokens().AccessToken
+		req, err := c.newRequest(ctx, request{method: http.MethodGet, path: shared.EventStreamPath, query: query}, nil, token)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		resp, err := c.config.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			scanner := bufio.NewScanner(resp.Body)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			return &EventStream{body: resp.Body, scanner: scanner, lastID: opts.LastEventID}, nil
+		}
+
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized && !refreshed && c.canRefresh() {
+			refreshed = true
+			if err := c.refresh(ctx, token); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, newError(resp.StatusCode, resp.Header, data)
+	}
+}
+
+// Next waits for the next event. A shared.EventTypeReset event means events
+// were missed and any state built from them should be reloaded. Next
+// returns io.EOF when the stream ends.
+func (s *EventStream) Next() (shared.Event, error) {
+	var id, data string
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		name, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch name {
+		case "":
+			if line != "" || data == "" {
+				// A comment such as a heartbeat, or the end of an event
+				// without data such as the retry field
+				continue
+			}
+			var event shared.Event
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return shared.Event{}, fmt.Errorf("invalid event: %w", err)
+			}
+			if id != "" {
+				s.lastID = id
+			}
+			return event, nil
+		case "id":
+			id = value
+		case "data":
+			data += value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return shared.Event{}, err
+	}
+	return shared.Event{}, io.EOF
+}
+
+// LastEventID is the ID of the last event received, to resume from
+func (s *EventStream) LastEventID() string {
+	return s.lastID
+}
+
+// Retry is the reconnection delay the gateway asked for, or zero
+func (s *EventStream) Retry() time.Duration {
+	return s.retry
+}
+
+// Close ends the stream
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
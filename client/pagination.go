@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// listPage fetches one page of a v2 list endpoint
+func listPage[T any](ctx context.Context, c *Client, path string, opts ListOptions) (*Page[T], error) {
+	query := url.Values{}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(opts.PageSize))
+	}
+	var page Page[T]
+	if err := c.call(ctx, request{method: http.MethodGet, path: path, query: query}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// all iterates over the items of every page from opts.Page on, fetching
+// each page as the previous one is used up. It stops after yielding the
+// first error.
+func all[T any](ctx context.Context, opts ListOptions, fetch func(context.Context, ListOptions) (*Page[T], error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		opts.Page = max(opts.Page, 1)
+		for {
+			page, err := fetch(ctx, opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(page.Items) == 0 || opts.Page >= page.Pagination.TotalPages {
+				return
+			}
+			opts.Page++
+		}
+	}
+}
+
+// Collect gathers the items of an iterator such as AllSchools into a slice,
+// stopping at the first error
+func Collect[T any](items iter.Seq2[T, error]) ([]T, error) {
+	var out []T
+	for item, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
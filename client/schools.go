@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"strconv"
+)
+
+// ListSchools returns a page of the schools the user may access
+func (c *Client) ListSchools(ctx context.Context, opts ListOptions) (*Page[School], error) {
+	return listPage[School](ctx, c, "/v2/schools", opts)
+}
+
+// AllSchools iterates over every school the user may access from
+// opts.Page on
+func (c *Client) AllSchools(ctx context.Context, opts ListOptions) iter.Seq2[School, error] {
+	return all(ctx, opts, c.ListSchools)
+}
+
+// GetSchool returns a school
+func (c *Client) GetSchool(ctx context.Context, id int) (*School, error) {
+	var school School
+	if err := c.call(ctx, request{method: http.MethodGet, path: schoolPath(id)}, &school); err != nil {
+		return nil, err
+	}
+	return &school, nil
+}
+
+// CreateSchool creates a school under a new idempotency key, so retries
+// never create it twice
+func (c *Client) CreateSchool(ctx context.Context, req SchoolRequest) (*School, error) {
+	return c.CreateSchoolWithKey(ctx, NewIdempotencyKey(), req)
+}
+
+// CreateSchoolWithKey creates a school under the given idempotency key.
+// Repeating a create with the same key and body returns the first
+// response.
+func (c *Client) CreateSchoolWithKey(ctx context.Context, idempotencyKey string, req SchoolRequest) (*School, error) {
+	var school School
+	if err := c.call(ctx, request{method: http.MethodPost, path: "/schools", body: req, idempotencyKey: idempotencyKey}, &school); err != nil {
+		return nil, err
+	}
+	return &school, nil
+}
+
+// UpdateSchool replaces a school
+func (c *Client) UpdateSchool(ctx context.Context, id int, req SchoolRequest) (*School, error) {
+	var school School
+	if err := c.call(ctx, request{method: http.MethodPut, path: schoolPath(id), body: req}, &school); err != nil {
+		return nil, err
+	}
+	return &school, nil
+}
+
+// DeleteSchool deletes a school
+func (c *Client) DeleteSchool(ctx context.Context, id int) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: schoolPath(id)}, nil)
+}
+
+func schoolPath(id int) string {
+	return "/schools/" + strconv.Itoa(id)
+}
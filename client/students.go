@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"strconv"
+)
+
+// ListStudents returns a page of the students the user may access
+func (c *Client) ListStudents(ctx context.Context, opts ListOptions) (*Page[Student], error) {
+	return listPage[Student](ctx, c, "/v2/students", opts)
+}
+
+// AllStudents iterates over every student the user may access from
+// opts.Page on
+func (c *Client) AllStudents(ctx context.Context, opts ListOptions) iter.Seq2[Student, error] {
+	return all(ctx, opts, c.ListStudents)
+}
+
+// ListStudentsBySchool returns a page of the students of a school
+func (c *Client) ListStudentsBySchool(ctx context.Context, schoolID int, opts ListOptions) (*Page[Student], error) {
+	return listPage[Student](ctx, c, "/v2/students/school/"+strconv.Itoa(schoolID), opts)
+}
+
+// AllStudentsBySchool iterates over every student of a school from
+// opts.Page on
+func (c *Client) AllStudentsBySchool(ctx context.Context, schoolID int, opts ListOptions) iter.Seq2[Student, error] {
+	return all(ctx, opts, func(ctx context.Context, opts ListOptions) (*Page[Student], error) {
+		return c.ListStudentsBySchool(ctx, schoolID, opts)
+	})
+}
+
+// GetStudent returns a student
+func (c *Client) GetStudent(ctx context.Context, id int) (*Student, error) {
+	var student Student
+	if err := c.call(ctx, request{method: http.MethodGet, path: studentPath(id)}, &student); err != nil {
+		return nil, err
+	}
+	return &student, nil
+}
+
+// CreateStudent creates a student under a new idempotency key, so retries
+// never create them twice
+func (c *Client) CreateStudent(ctx context.Context, req StudentRequest) (*Student, error) {
+	return c.CreateStudentWithKey(ctx, NewIdempotencyKey(), req)
+}
+
+// CreateStudentWithKey creates a student under the given idempotency key.
+// Repeating a create with the same key and body returns the first
+// response.
+func (c *Client) CreateStudentWithKey(ctx context.Context, idempotencyKey string, req StudentRequest) (*Student, error) {
+	var student Student
+	if err := c.call(ctx, request{method: http.MethodPost, path: "/students", body: req, idempotencyKey: idempotencyKey}, &student); err != nil {
+		return nil, err
+	}
+	return &student, nil
+}
+
+// UpdateStudent replaces a student
+func (c *Client) UpdateStudent(ctx context.Context, id int, req StudentRequest) (*Student, error) {
+	var student Student
+	if err := c.call(ctx, request{method: http.MethodPut, path: studentPath(id), body: req}, &student); err != nil {
+		return nil, err
+	}
+	return &student, nil
+}
+
+// DeleteStudent deletes a student
+func (c *Client) DeleteStudent(ctx context.Context, id int) error {
+	return c.call(ctx, request{method: http.MethodDelete, path: studentPath(id)}, nil)
+}
+
+func studentPath(id int) string {
+	return "/students/" + strconv.Itoa(id)
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"time"
+
+	"skool-management/shared"
+)
+
+// School is a school as the API returns it
+type School struct {
+	ID                 int       `json:"id"`
+	RegistrationNumber string    `json:"registration_number"`
+	Name               string    `json:"name"`
+	Address            string    `json:"address"`
+	Phone              string    `json:"phone"`
+	Email              string    `json:"email"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// SchoolRequest is the body that creates or replaces a school
+type SchoolRequest struct {
+	RegistrationNumber string `json:"registration_number"`
+	Name               string `json:"name"`
+	Address            string `json:"address"`
+	Phone              string `json:"phone"`
+	Email              string `json:"email"`
+}
+
+// Student is a student as the API returns it
+type Student struct {
+	ID          int    `json:"id"`
+	RollNumber  string `json:"roll_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	DateOfBirth string `json:"date_of_birth"`
+	Address     string `json:"address"`
+	SchoolID    int    `json:"school_id"`
+	// SchoolName is filled in by reads; it is not part of StudentRequest
+	SchoolName     string    `json:"school_name,omitempty"`
+	EnrollmentDate string    `json:"enrollment_date"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// StudentRequest is the body that creates or replaces a student. Dates are
+// YYYY-MM-DD; Status defaults to active.
+type StudentRequest struct {
+	RollNumber     string `json:"roll_number"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	DateOfBirth    string `json:"date_of_birth"`
+	Address        string `json:"address"`
+	SchoolID       int    `json:"school_id"`
+	EnrollmentDate string `json:"enrollment_date"`
+	Status         string `json:"status"`
+}
+
+// User is a user account
+type User struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+	SchoolIDs []string  `json:"school_ids,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SignupRequest creates a user with the user role and no schools. An admin
+// grants roles and schools with UpdateUser.
+type SignupRequest struct {
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// UpdateUserRequest replaces a user's name, role and schools
+type UpdateUserRequest struct {
+	FirstName string   `json:"first_name"`
+	LastName  string   `json:"last_name"`
+	Role      string   `json:"role"`
+	SchoolIDs []string `json:"school_ids,omitempty"`
+}
+
+// LoginResponse is the user logged in and their tokens
+type LoginResponse struct {
+	User         User   `json:"user"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Claims are what an access token says about its user
+type Claims struct {
+	UserID    string   `json:"user_id"`
+	Email     string   `json:"email"`
+	Role      string   `json:"role"`
+	SchoolIDs []string `json:"school_ids"`
+}
+
+// Page is one page of a list
+type Page[T any] struct {
+	Items      []T               `json:"items"`
+	Pagination shared.Pagination `json:"pagination"`
+}
+
+// ListOptions selects a page. Zero values select the first page of the
+// default size.
+type ListOptions struct {
+	Page     int
+	PageSize int
+}
@@ -0,0 +1,166 @@
+// Command devcerts generates a local certificate authority and a certificate
+// for every service, for trying TLS and mutual TLS on a development machine.
+// Each certificate is valid both as a server and as a client certificate.
+//
+//	go run ./cmd/devcerts -out certs
+//
+// Re-running it keeps the existing CA and reissues the service certificates,
+// which running services pick up on their next reload check.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var services = []string{"api-gateway", "auth-service", "school-service", "student-service"}
+
+func main() {
+	out := flag.String("out", "certs", "directory to write the PEM files to")
+	hosts := flag.String("hosts", "", "comma separated extra DNS names or IPs added to every certificate")
+	validity := flag.Duration("validity", 90*24*time.Hour, "lifetime of the service certificates")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	ca, caKey, err := loadOrCreateCA(*out)
+	if err != nil {
+		log.Fatal("CA: ", err)
+	}
+
+	var extra []string
+	for _, host := range strings.Split(*hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			extra = append(extra, host)
+		}
+	}
+
+	for _, service := range services {
+		// Containers reach each other by compose service name; local runs use localhost
+		names := append([]string{service, "localhost", "127.0.0.1", "::1"}, extra...)
+		if err := issue(*out, service, names, *validity, ca, caKey); err != nil {
+			log.Fatalf("%s: %v", service, err)
+		}
+		fmt.Printf("wrote %s\n", filepath.Join(*out, service+".pem"))
+	}
+	fmt.Printf("CA certificate: %s\n", filepath.Join(*out, "ca.pem"))
+}
+
+// loadOrCreateCA reuses ca.pem and ca-key.pem from dir when present, so
+// peers that already trust the CA keep working
+func loadOrCreateCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPath, keyPath := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+
+	if pair, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, nil, errors.New("existing CA key is not an ECDSA key")
+		}
+		return pair.Leaf, key, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: "skool-management development CA", Organization: []string{"skool-management"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(certPath, "CERTIFICATE", der, 0o644); err != nil {
+		return nil, nil, err
+	}
+	if err := writeKey(keyPath, key); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// issue writes <name>.pem and <name>-key.pem signed by the CA
+func issue(dir, name string, hosts []string, validity time.Duration, ca *x509.Certificate, caKey *ecdsa.PrivateKey) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"skool-management"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	// Write the key first: a reload that sees the new certificate must also
+	// find its key
+	if err := writeKey(filepath.Join(dir, name+"-key.pem"), key); err != nil {
+		return err
+	}
+	return writePEM(filepath.Join(dir, name+".pem"), "CERTIFICATE", der, 0o644)
+}
+
+func writeKey(path string, key *ecdsa.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return writePEM(path, "PRIVATE KEY", der, 0o600)
+}
+
+// writePEM replaces path atomically so services never read a partial file
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func serialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return serial
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"skool-management/client"
+	"skool-management/shared"
+)
+
+// defaultReconnectDelay is how long audit tail waits before reconnecting
+// when the gateway sent no retry field
+const defaultReconnectDelay = 3 * time.Second
+
+func auditCommand() *command {
+	return &command{
+		name:    "audit",
+		summary: "Follow who changes what",
+		subcommands: []*command{
+			{name: "tail", summary: "Print changes to schools and students as they happen", setup: tailAudit},
+		},
+	}
+}
+
+func tailAudit(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	types := fs.String("types", "", "entities to follow: school, student or both comma separated (default both)")
+	schoolIDs := fs.String("school-ids", "", "schools to follow, comma separated (default every school you can access)")
+	after := fs.String("after", "", "replay the changes after this event ID, as printed with -o json")
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		opts := client.EventOptions{LastEventID: *after}
+		if *types != "" {
+			opts.Types = strings.Split(*types, ",")
+		}
+		if *schoolIDs != "" {
+			for _, field := range strings.Split(*schoolIDs, ",") {
+				id, err := parseID(strings.TrimSpace(field), "school")
+				if err != nil {
+					return err
+				}
+				opts.SchoolIDs = append(opts.SchoolIDs, id)
+			}
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+
+		rows := a.newRowWriter([]string{"time", "actor", "type", "entity", "school", "request_id"}, []int{19, 28, 15, 12, 6})
+		tail := &auditTail{client: c, rows: rows, stderr: a.stderr, opts: opts, retry: defaultReconnectDelay}
+		return tail.run(ctx)
+	}
+}
+
+// auditTail follows the gateway's change feed, reconnecting after the last
+// event it printed whenever the stream breaks
+type auditTail struct {
+	client *client.Client
+	rows   *rowWriter
+	stderr io.Writer
+	opts   client.EventOptions
+	retry  time.Duration
+}
+
+func (t *auditTail) run(ctx context.Context) error {
+	for {
+		err := t.follow(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var apiErr *client.Error
+		if errors.As(err, &apiErr) && !errors.Is(err, client.ErrUnavailable) {
+			return err
+		}
+		fmt.Fprintf(t.stderr, "skoolctl: change feed interrupted (%v); reconnecting in %s\n", err, t.retry)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(t.retry):
+		}
+	}
+}
+
+// follow prints the events of one connection to the change feed until it
+// ends
+func (t *auditTail) follow(ctx context.Context) error {
+	stream, err := t.client.Events(ctx, t.opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	defer func() {
+		t.opts.LastEventID = stream.LastEventID()
+		if retry := stream.Retry(); retry > 0 {
+			t.retry = retry
+		}
+	}()
+
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			return err
+		}
+		if err := t.print(event); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *auditTail) print(event shared.Event) error {
+	if event.Type == shared.EventTypeReset {
+		fmt.Fprintln(t.stderr, "skoolctl: some changes were missed while disconnected; continuing from the latest")
+		return nil
+	}
+
+	actor := event.ActorEmail
+	if actor == "" {
+		actor = event.Actor
+	}
+	entity := event.Entity
+	if event.EntityID != 0 {
+		entity += " " + strconv.Itoa(event.EntityID)
+	}
+	school := ""
+	if event.SchoolID != 0 {
+		school = strconv.Itoa(event.SchoolID)
+	}
+	return t.rows.write(event, []string{event.Time.Local().Format("2006-01-02 15:04:05"), actor, event.Type, entity, school, event.RequestID})
+}
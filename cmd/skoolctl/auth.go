@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"skool-management/client"
+)
+
+func loginCommand() *command {
+	return &command{
+		name:    "login",
+		summary: "Log in to the gateway and save the credentials",
+		setup: func(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+			email := fs.String("email", "", "account email (prompted when empty)")
+			passwordStdin := fs.Bool("password-stdin", false, "read the password from standard input")
+			return func(ctx context.Context, args []string) error {
+				if err := exactArgs(args); err != nil {
+					return err
+				}
+				if *email == "" {
+					var err error
+					if *email, err = a.readLine("Email: "); err != nil {
+						return err
+					}
+				}
+				password, err := a.readPassword("Password: ", *passwordStdin)
+				if err != nil {
+					return err
+				}
+
+				c, err := a.api(false)
+				if err != nil {
+					return err
+				}
+				// Log in afresh, ignoring any saved or environment token
+				c.SetTokens(client.Tokens{})
+				resp, err := c.Login(ctx, *email, password)
+				if err != nil {
+					return err
+				}
+				creds := &credentials{Gateway: c.BaseURL(), Email: resp.User.Email, AccessToken: resp.AccessToken, RefreshToken: resp.RefreshToken}
+				if err := creds.save(a.configPath); err != nil {
+					return fmt.Errorf("saving credentials: %w", err)
+				}
+				return a.printMessage(resp.User, "Logged in to %s as %s (%s)", creds.Gateway, resp.User.Email, resp.User.Role)
+			}
+		},
+	}
+}
+
+func logoutCommand() *command {
+	return &command{
+		name:    "logout",
+		summary: "Forget the saved credentials",
+		setup: func(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+			return func(ctx context.Context, args []string) error {
+				if err := exactArgs(args); err != nil {
+					return err
+				}
+				if err := os.Remove(a.configPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return err
+				}
+				return a.printMessage(nil, "Logged out")
+			}
+		},
+	}
+}
+
+func whoamiCommand() *command {
+	return &command{
+		name:    "whoami",
+		summary: "Show the user the credentials belong to",
+		setup: func(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+			return func(ctx context.Context, args []string) error {
+				if err := exactArgs(args); err != nil {
+					return err
+				}
+				c, err := a.api(true)
+				if err != nil {
+					return err
+				}
+				claims, err := c.Validate(ctx)
+				if err != nil {
+					return err
+				}
+				return a.print(claims, table{
+					headers: []string{"user_id", "email", "role", "school_ids"},
+					rows:    [][]string{{claims.UserID, claims.Email, claims.Role, strings.Join(claims.SchoolIDs, ",")}},
+				})
+			}
+		},
+	}
+}
+
+// readLine prompts on the terminal and reads one line from standard input
+func (a *app) readLine(prompt string) (string, error) {
+	fmt.Fprint(a.stderr, prompt)
+	line, err := a.input().ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("reading %s: %w", strings.TrimSuffix(strings.ToLower(prompt), ": "), err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPassword reads a password without echo from the terminal, or the
+// whole of standard input with fromStdin
+func (a *app) readPassword(prompt string, fromStdin bool) (string, error) {
+	if fromStdin {
+		data, err := io.ReadAll(a.input())
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	file, ok := a.stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return "", errors.New("standard input is not a terminal; use -password-stdin")
+	}
+	fmt.Fprint(a.stderr, prompt)
+	password, err := term.ReadPassword(int(file.Fd()))
+	fmt.Fprintln(a.stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// confirm asks before a destructive action unless yes is set
+func (a *app) confirm(yes bool, format string, args ...any) error {
+	if yes {
+		return nil
+	}
+	file, ok := a.stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return errors.New("refusing without confirmation; pass -yes")
+	}
+	answer, err := a.readLine(fmt.Sprintf(format, args...) + " [y/N]: ")
+	if err != nil {
+		return err
+	}
+	if answer != "y" && answer != "yes" {
+		return errors.New("cancelled")
+	}
+	return nil
+}
+
+// input buffers standard input once, so prompts do not lose typed-ahead lines
+func (a *app) input() *bufio.Reader {
+	if a.lines == nil {
+		a.lines = bufio.NewReader(a.stdin)
+	}
+	return a.lines
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"skool-management/client"
+	"skool-management/shared"
+)
+
+func breakersCommand() *command {
+	return &command{
+		name:    "breakers",
+		summary: "Inspect and force the gateway's circuit breakers (admin only)",
+		subcommands: []*command{
+			{name: "list", summary: "List breakers with their window statistics", setup: listBreakers},
+			{name: "get", args: "NAME", summary: "Show a breaker", setup: getBreaker},
+			{name: "open", args: "NAME", summary: "Force a breaker open, failing calls fast", setup: changeBreaker(client.BreakerOpen)},
+			{name: "close", args: "NAME", summary: "Force a breaker closed, letting every call through", setup: changeBreaker(client.BreakerClose)},
+			{name: "reset", args: "NAME", summary: "Clear a forced state and the statistics", setup: changeBreaker(client.BreakerReset)},
+		},
+	}
+}
+
+func listBreakers(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		breakers, err := c.ListBreakers(ctx)
+		if err != nil {
+			return err
+		}
+		return a.print(breakers, breakerTable(breakers...))
+	}
+}
+
+func getBreaker(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "NAME"); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		breaker, err := c.GetBreaker(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return a.print(breaker, breakerTable(*breaker))
+	}
+}
+
+func changeBreaker(action string) func(*flag.FlagSet, *app) func(context.Context, []string) error {
+	return func(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+		return func(ctx context.Context, args []string) error {
+			if err := exactArgs(args, "NAME"); err != nil {
+				return err
+			}
+			c, err := a.api(true)
+			if err != nil {
+				return err
+			}
+			breaker, err := c.ChangeBreaker(ctx, args[0], action)
+			if err != nil {
+				return err
+			}
+			return a.print(breaker, breakerTable(*breaker))
+		}
+	}
+}
+
+func breakerTable(breakers ...shared.CircuitBreakerView) table {
+	t := table{headers: []string{"name", "state", "forced", "calls", "failures", "slow_calls", "failure_rate", "slow_call_rate"}}
+	for _, b := range breakers {
+		t.rows = append(t.rows, []string{
+			b.Name,
+			b.State,
+			strconv.FormatBool(b.Forced),
+			strconv.Itoa(b.Window.Calls),
+			strconv.Itoa(b.Window.Failures),
+			strconv.Itoa(b.Window.SlowCalls),
+			fmt.Sprintf("%.1f%%", b.Window.FailureRate),
+			fmt.Sprintf("%.1f%%", b.Window.SlowCallRate),
+		})
+	}
+	return t
+}
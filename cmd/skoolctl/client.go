@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"skool-management/client"
+)
+
+// api creates an API client with the saved credentials, which are saved
+// again whenever the client refreshes the access token. Commands that need
+// a login fail early when there are no credentials.
+func (a *app) api(requireLogin bool) (*client.Client, error) {
+	creds, err := loadCredentials(a.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := client.Config{
+		BaseURL:   a.gatewayURL(creds),
+		Timeout:   a.timeout,
+		UserAgent: "skoolctl",
+		Tokens:    client.Tokens{AccessToken: creds.AccessToken, RefreshToken: creds.RefreshToken},
+	}
+	if token := os.Getenv("SKOOLCTL_TOKEN"); token != "" {
+		// An access token from the environment is never refreshed or saved
+		config.Tokens = client.Tokens{AccessToken: token}
+	} else {
+		config.OnTokenRefresh = func(tokens client.Tokens) {
+			creds.AccessToken = tokens.AccessToken
+			if err := creds.save(a.configPath); err != nil {
+				fmt.Fprintf(a.stderr, "skoolctl: saving the refreshed credentials: %v\n", err)
+			}
+		}
+	}
+	if requireLogin && config.Tokens.AccessToken == "" {
+		return nil, errors.New("not logged in; run \"skoolctl login\" or set SKOOLCTL_TOKEN")
+	}
+	return client.New(config), nil
+}
+
+// gatewayURL is the -gateway flag, else the gateway logged in to
+func (a *app) gatewayURL(creds *credentials) string {
+	switch {
+	case a.gateway != "":
+		return a.gateway
+	case creds.Gateway != "":
+		return creds.Gateway
+	default:
+		return defaultGateway
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// completeCommandName is the hidden command the completion scripts run with
+// the words typed so far, the last being the one to complete
+const completeCommandName = "__complete"
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func completionCommand() *command {
+	return &command{
+		name:    "completion",
+		args:    "bash|zsh|fish",
+		summary: "Print a shell completion script",
+		setup: func(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+			return func(ctx context.Context, args []string) error {
+				if err := exactArgs(args, "SHELL"); err != nil {
+					return err
+				}
+				script, ok := completionScripts[args[0]]
+				if !ok {
+					return fmt.Errorf("unknown shell %q; use %s", args[0], strings.Join(completionShells, ", "))
+				}
+				_, err := io.WriteString(a.stdout, script)
+				return err
+			}
+		},
+	}
+}
+
+// complete prints the candidates for the last of words, one per line
+func (a *app) complete(root *command, words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, words := words[len(words)-1], words[:len(words)-1]
+
+	// Walk down the command tree, passing over flags and their values
+	cmd := root
+	var previous string
+	for _, word := range words {
+		previous = word
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		if next := findCommand(cmd.subcommands, word); next != nil {
+			cmd = next
+		}
+	}
+
+	var candidates []string
+	switch {
+	case previous == "-o" || previous == "-output" || previous == "--output":
+		candidates = outputFormats
+	case cmd.name == "completion" && !strings.HasPrefix(current, "-"):
+		candidates = completionShells
+	case strings.HasPrefix(current, "-"):
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		a.addGlobalFlags(fs)
+		if cmd.setup != nil {
+			cmd.setup(fs, a)
+		}
+		fs.VisitAll(func(f *flag.Flag) { candidates = append(candidates, "-"+f.Name) })
+	default:
+		for _, sub := range cmd.subcommands {
+			candidates = append(candidates, sub.name)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Fprintln(a.stdout, candidate)
+		}
+	}
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for skoolctl; load with: source <(skoolctl completion bash)
+_skoolctl() {
+	local IFS=$'\n'
+	COMPREPLY=($(skoolctl __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _skoolctl skoolctl
+`,
+	"zsh": `#compdef skoolctl
+# zsh completion for skoolctl; load with: source <(skoolctl completion zsh)
+_skoolctl() {
+	local -a candidates
+	candidates=("${(@f)$(skoolctl __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	compadd -a candidates
+}
+compdef _skoolctl skoolctl
+`,
+	"fish": `# fish completion for skoolctl; load with: skoolctl completion fish | source
+complete -c skoolctl -f -a '(skoolctl __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`,
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+
+	"skool-management/client"
+)
+
+// fieldKind is the type of a request field set from a flag
+type fieldKind int
+
+const (
+	stringField fieldKind = iota
+	intField
+	listField
+)
+
+// field is a request body field that create and update commands take as a
+// flag named after it, such as -roll-number for roll_number
+type field struct {
+	name  string
+	usage string
+	kind  fieldKind
+}
+
+func (f field) flagName() string {
+	return strings.ReplaceAll(f.name, "_", "-")
+}
+
+// registerFields adds a flag for every field
+func registerFields(fs *flag.FlagSet, fields []field) {
+	for _, f := range fields {
+		switch f.kind {
+		case intField:
+			fs.Int(f.flagName(), 0, f.usage)
+		case listField:
+			fs.Var(&listValue{}, f.flagName(), f.usage+" (comma separated)")
+		default:
+			fs.String(f.flagName(), "", f.usage)
+		}
+	}
+}
+
+// applyFields overwrites the fields of the request ptr points to with the
+// flags that were given, leaving the others as they are
+func applyFields(fs *flag.FlagSet, fields []field, ptr any) error {
+	values := make(map[string]any)
+	if err := convert(ptr, &values); err != nil {
+		return err
+	}
+	fs.Visit(func(set *flag.Flag) {
+		for _, f := range fields {
+			if f.flagName() == set.Name {
+				values[f.name] = set.Value.(flag.Getter).Get()
+			}
+		}
+	})
+	return convert(values, ptr)
+}
+
+// listValue is a comma separated flag value
+type listValue []string
+
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+func (l *listValue) Get() any {
+	return []string(*l)
+}
+
+// pageFlags are the flags of list commands
+type pageFlags struct {
+	page     *int
+	pageSize *int
+	all      *bool
+}
+
+func registerPageFlags(fs *flag.FlagSet) pageFlags {
+	return pageFlags{
+		page:     fs.Int("page", 1, "page to show"),
+		pageSize: fs.Int("page-size", 20, "items per page, at most 100"),
+		all:      fs.Bool("all", false, "fetch every page from -page on"),
+	}
+}
+
+// listWithFlags fetches the page the flags select and notes where it sits
+// in the full list, or with -all every page from there on
+func listWithFlags[T any](ctx context.Context, a *app, flags pageFlags, noun string, list func(context.Context, client.ListOptions) (*client.Page[T], error), all func(context.Context, client.ListOptions) iter.Seq2[T, error]) ([]T, error) {
+	opts := client.ListOptions{Page: *flags.page, PageSize: *flags.pageSize}
+	if *flags.all {
+		return client.Collect(all(ctx, opts))
+	}
+	page, err := list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if a.output == outputTable && page.Pagination.TotalPages > 1 {
+		fmt.Fprintf(a.stderr, "Page %d of %d (%d %s); use -page or -all for more\n", page.Pagination.Page, page.Pagination.TotalPages, page.Pagination.Total, noun)
+	}
+	return page.Items, nil
+}
+
+// parseID reads a numeric ID argument
+func parseID(arg, noun string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s ID %q", noun, arg)
+	}
+	return id, nil
+}
@@ -0,0 +1,273 @@
+// Command skoolctl operates the platform through the API gateway. It logs
+// in, manages users, schools and students, imports and exports data,
+// inspects and forces circuit breakers, and tails the change feed as an
+// audit log.
+//
+//	go run ./cmd/skoolctl login --email admin@school.com
+//	go run ./cmd/skoolctl schools list -o csv
+//	go run ./cmd/skoolctl import students students.csv
+//
+// Credentials are kept in the user config directory, see "skoolctl help".
+// Lists print as a table by default, or as JSON or CSV with -o.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"skool-management/client"
+)
+
+// Defaults of the global flags
+const (
+	defaultGateway = "http://localhost:8080"
+	defaultTimeout = 30 * time.Second
+)
+
+// Output formats selected with -o
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputCSV}
+
+// command is a node of the command tree. Groups have subcommands; leaves
+// have setup, which registers their flags and returns what runs them.
+type command struct {
+	name    string
+	args    string
+	summary string
+	setup   func(fs *flag.FlagSet, app *app) func(ctx context.Context, args []string) error
+
+	subcommands []*command
+}
+
+// app holds the global options and streams shared by every command
+type app struct {
+	gateway    string
+	output     string
+	configPath string
+	timeout    time.Duration
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	lines  *bufio.Reader
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a := &app{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	if err := a.run(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "skoolctl:", err)
+		if errors.Is(err, client.ErrUnauthorized) {
+			fmt.Fprintln(os.Stderr, "Run \"skoolctl login\" to log in again.")
+		}
+		os.Exit(1)
+	}
+}
+
+// commands is the command tree below skoolctl
+func commands() []*command {
+	return []*command{
+		loginCommand(),
+		logoutCommand(),
+		whoamiCommand(),
+		usersCommand(),
+		schoolsCommand(),
+		studentsCommand(),
+		importCommand(),
+		exportCommand(),
+		breakersCommand(),
+		auditCommand(),
+		completionCommand(),
+	}
+}
+
+func rootCommand() *command {
+	return &command{name: "skoolctl", summary: "Operate the school management platform", subcommands: commands()}
+}
+
+// run parses the global flags, finds the command named by args and runs it
+func (a *app) run(ctx context.Context, args []string) error {
+	root := rootCommand()
+	global := flag.NewFlagSet("skoolctl", flag.ContinueOnError)
+	global.SetOutput(a.stderr)
+	a.addGlobalFlags(global)
+	global.Usage = func() { a.printUsage([]*command{root}, global) }
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	args = global.Args()
+
+	// Hidden command used by the completion scripts
+	if len(args) > 0 && args[0] == completeCommandName {
+		a.complete(root, args[1:])
+		return nil
+	}
+
+	path := []*command{root}
+	cmd := root
+	for len(cmd.subcommands) > 0 {
+		if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			a.printUsage(path, global)
+			if len(args) == 0 && cmd == root {
+				return flag.ErrHelp
+			}
+			return nil
+		}
+		next := findCommand(cmd.subcommands, args[0])
+		if next == nil {
+			a.printUsage(path, global)
+			return fmt.Errorf("unknown command %q", strings.Join(append(commandNames(path[1:]), args[0]), " "))
+		}
+		path = append(path, next)
+		cmd, args = next, args[1:]
+	}
+
+	fs := flag.NewFlagSet(strings.Join(commandNames(path), " "), flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	a.addGlobalFlags(fs)
+	runner := cmd.setup(fs, a)
+	fs.Usage = func() { a.printUsage(path, fs) }
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := a.resolveDefaults(); err != nil {
+		return err
+	}
+	return runner(ctx, positional)
+}
+
+// addGlobalFlags registers the options every command accepts, so they may
+// be given before or after the command name
+func (a *app) addGlobalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&a.gateway, "gateway", a.gateway, "gateway URL (default $SKOOLCTL_GATEWAY, the URL logged in to, or "+defaultGateway+")")
+	fs.StringVar(&a.output, "output", a.output, "output format: table, json or csv")
+	fs.StringVar(&a.output, "o", a.output, "shorthand for -output")
+	fs.StringVar(&a.configPath, "config", a.configPath, "credentials file (default $SKOOLCTL_CONFIG or the user config directory)")
+	fs.DurationVar(&a.timeout, "timeout", a.timeout, "timeout of each API call (default 30s)")
+}
+
+// resolveDefaults fills the options left unset from the environment
+func (a *app) resolveDefaults() error {
+	if a.output == "" {
+		a.output = outputTable
+	}
+	if !slices.Contains(outputFormats, a.output) {
+		return fmt.Errorf("unknown output format %q; use %s", a.output, strings.Join(outputFormats, ", "))
+	}
+	if a.timeout <= 0 {
+		a.timeout = defaultTimeout
+	}
+	if a.configPath == "" {
+		a.configPath = os.Getenv("SKOOLCTL_CONFIG")
+	}
+	if a.configPath == "" {
+		path, err := defaultConfigPath()
+		if err != nil {
+			return err
+		}
+		a.configPath = path
+	}
+	if a.gateway == "" {
+		a.gateway = os.Getenv("SKOOLCTL_GATEWAY")
+	}
+	return nil
+}
+
+func (a *app) printUsage(path []*command, fs *flag.FlagSet) {
+	cmd := path[len(path)-1]
+	name := strings.Join(commandNames(path), " ")
+	w := a.stderr
+
+	if len(cmd.subcommands) > 0 {
+		fmt.Fprintf(w, "%s\n\nUsage:\n  %s <command> [flags]\n\nCommands:\n", cmd.summary, name)
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		for _, sub := range cmd.subcommands {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.name, sub.summary)
+		}
+		tw.Flush()
+		if cmd.name == "skoolctl" {
+			fmt.Fprintf(w, "\nGlobal flags:\n")
+			fs.PrintDefaults()
+			fmt.Fprintf(w, "\nCredentials are saved by login in %s.\nSet SKOOLCTL_TOKEN to use an access token instead.\n", displayConfigPath())
+		}
+		fmt.Fprintf(w, "\nRun \"%s <command> -h\" for help on a command.\n", name)
+		return
+	}
+
+	usage := name + " [flags]"
+	if cmd.args != "" {
+		usage = name + " " + cmd.args + " [flags]"
+	}
+	fmt.Fprintf(w, "%s\n\nUsage:\n  %s\n\nFlags:\n", cmd.summary, usage)
+	fs.PrintDefaults()
+}
+
+// parseInterspersed parses flags wherever they appear among the arguments
+// and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func findCommand(commands []*command, name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func commandNames(path []*command) []string {
+	names := make([]string, len(path))
+	for i, cmd := range path {
+		names[i] = cmd.name
+	}
+	return names
+}
+
+// exactArgs checks the number of positional arguments
+func exactArgs(args []string, names ...string) error {
+	if len(args) != len(names) && len(names) == 0 {
+		return fmt.Errorf("unexpected argument %q", args[0])
+	}
+	if len(args) != len(names) {
+		return fmt.Errorf("expected %d argument(s): %s", len(names), strings.Join(names, " "))
+	}
+	return nil
+}
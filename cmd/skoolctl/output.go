@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// table is how a result prints in the table and CSV formats; the JSON
+// format prints the value itself
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+// print writes value in the selected output format
+func (a *app) print(value any, t table) error {
+	switch a.output {
+	case outputJSON:
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	case outputCSV:
+		w := csv.NewWriter(a.stdout)
+		w.Write(t.headers)
+		w.WriteAll(t.rows)
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(t.headers, "\t")))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// printMessage reports the outcome of a command that has nothing else to
+// print. JSON output gets the value instead, so scripts can read it.
+func (a *app) printMessage(value any, format string, args ...any) error {
+	if a.output == outputJSON && value != nil {
+		return a.print(value, table{})
+	}
+	_, err := fmt.Fprintf(a.stdout, format+"\n", args...)
+	return err
+}
+
+// rowWriter prints rows as they arrive, for output that never ends
+type rowWriter struct {
+	format string
+	out    io.Writer
+	csv    *csv.Writer
+	widths []int
+}
+
+// newRowWriter prints headers right away. widths are the table column
+// widths; the last column is not padded.
+func (a *app) newRowWriter(headers []string, widths []int) *rowWriter {
+	w := &rowWriter{format: a.output, out: a.stdout, widths: widths}
+	switch a.output {
+	case outputCSV:
+		w.csv = csv.NewWriter(a.stdout)
+		w.csv.Write(headers)
+		w.csv.Flush()
+	case outputTable:
+		w.writeTableRow(upper(headers))
+	}
+	return w
+}
+
+// write prints one row, or value as a line of JSON
+func (w *rowWriter) write(value any, row []string) error {
+	switch w.format {
+	case outputJSON:
+		return json.NewEncoder(w.out).Encode(value)
+	case outputCSV:
+		w.csv.Write(row)
+		w.csv.Flush()
+		return w.csv.Error()
+	default:
+		return w.writeTableRow(row)
+	}
+}
+
+func (w *rowWriter) writeTableRow(row []string) error {
+	var line strings.Builder
+	for i, cell := range row {
+		if i < len(row)-1 && i < len(w.widths) {
+			fmt.Fprintf(&line, "%-*s  ", w.widths[i], cell)
+		} else {
+			line.WriteString(cell)
+		}
+	}
+	_, err := fmt.Fprintln(w.out, strings.TrimRight(line.String(), " "))
+	return err
+}
+
+func upper(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToUpper(v)
+	}
+	return out
+}
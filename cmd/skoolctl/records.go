@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// convert copies the fields src and dst share by JSON name, such as a
+// fetched school into the request that replaces it
+func convert(src, dst any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// CSV records are the fields of a struct named by their JSON names. Lists
+// are comma separated within their cell and times are RFC 3339.
+
+// csvHeader returns the column names of struct type T
+func csvHeader[T any]() []string {
+	t := reflect.TypeFor[T]()
+	var header []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			header = append(header, name)
+		}
+	}
+	return header
+}
+
+// csvRecord formats the fields of v in csvHeader order
+func csvRecord(v any) []string {
+	value := reflect.ValueOf(v)
+	var record []string
+	for i := 0; i < value.NumField(); i++ {
+		if jsonName(value.Type().Field(i)) == "" {
+			continue
+		}
+		switch field := value.Field(i).Interface().(type) {
+		case string:
+			record = append(record, field)
+		case int:
+			record = append(record, strconv.Itoa(field))
+		case []string:
+			record = append(record, strings.Join(field, ","))
+		case time.Time:
+			if field.IsZero() {
+				record = append(record, "")
+			} else {
+				record = append(record, field.Format(time.RFC3339))
+			}
+		default:
+			record = append(record, fmt.Sprint(field))
+		}
+	}
+	return record
+}
+
+// decodeCSVRecord sets the fields of the struct ptr points to from a record
+// with the given header. Columns the struct does not have are ignored, so
+// an export can be imported again.
+func decodeCSVRecord(header, record []string, ptr any) error {
+	value := reflect.ValueOf(ptr).Elem()
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < value.NumField(); i++ {
+		if name := jsonName(value.Type().Field(i)); name != "" {
+			fields[name] = value.Field(i)
+		}
+	}
+
+	for i, name := range header {
+		field, ok := fields[strings.TrimSpace(name)]
+		if !ok || i >= len(record) {
+			continue
+		}
+		cell := strings.TrimSpace(record[i])
+		switch field.Interface().(type) {
+		case string:
+			field.SetString(cell)
+		case int:
+			if cell == "" {
+				continue
+			}
+			n, err := strconv.Atoi(cell)
+			if err != nil {
+				return fmt.Errorf("column %s: %q is not a number", name, cell)
+			}
+			field.SetInt(int64(n))
+		case []string:
+			var items []string
+			for _, item := range strings.Split(cell, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			field.Set(reflect.ValueOf(items))
+		}
+	}
+	return nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" || !field.IsExported() {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// formatTime prints times in tables
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+
+	"skool-management/client"
+)
+
+var schoolFields = []field{
+	{name: "registration_number", usage: "registration number"},
+	{name: "name", usage: "school name"},
+	{name: "address", usage: "postal address"},
+	{name: "phone", usage: "phone number"},
+	{name: "email", usage: "contact email"},
+}
+
+func schoolsCommand() *command {
+	return &command{
+		name:    "schools",
+		summary: "Manage schools",
+		subcommands: []*command{
+			{name: "list", summary: "List schools", setup: listSchools},
+			{name: "get", args: "ID", summary: "Show a school", setup: getSchool},
+			{name: "create", summary: "Create a school", setup: createSchool},
+			{name: "update", args: "ID", summary: "Change the flags given of a school", setup: updateSchool},
+			{name: "delete", args: "ID", summary: "Delete a school", setup: deleteSchool},
+		},
+	}
+}
+
+func listSchools(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	pages := registerPageFlags(fs)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		schools, err := listWithFlags(ctx, a, pages, "schools", c.ListSchools, c.AllSchools)
+		if err != nil {
+			return err
+		}
+		return a.print(schools, schoolTable(schools...))
+	}
+}
+
+func getSchool(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		id, err := parseID(args[0], "school")
+		if err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		s, err := c.GetSchool(ctx, id)
+		if err != nil {
+			return err
+		}
+		return a.print(s, schoolTable(*s))
+	}
+}
+
+func createSchool(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	registerFields(fs, schoolFields)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		var req client.SchoolRequest
+		if err := applyFields(fs, schoolFields, &req); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		s, err := c.CreateSchool(ctx, req)
+		if err != nil {
+			return err
+		}
+		return a.printMessage(s, "Created school %d (%s)", s.ID, s.Name)
+	}
+}
+
+func updateSchool(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	registerFields(fs, schoolFields)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		id, err := parseID(args[0], "school")
+		if err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		// The API replaces the school, so start from the stored one
+		current, err := c.GetSchool(ctx, id)
+		if err != nil {
+			return err
+		}
+		var req client.SchoolRequest
+		if err := convert(current, &req); err != nil {
+			return err
+		}
+		if err := applyFields(fs, schoolFields, &req); err != nil {
+			return err
+		}
+
+		s, err := c.UpdateSchool(ctx, id, req)
+		if err != nil {
+			return err
+		}
+		return a.printMessage(s, "Updated school %d (%s)", s.ID, s.Name)
+	}
+}
+
+func deleteSchool(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		id, err := parseID(args[0], "school")
+		if err != nil {
+			return err
+		}
+		if err := a.confirm(*yes, "Delete school %d?", id); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		if err := c.DeleteSchool(ctx, id); err != nil {
+			return err
+		}
+		return a.printMessage(nil, "Deleted school %d", id)
+	}
+}
+
+func schoolTable(schools ...client.School) table {
+	t := table{headers: []string{"id", "registration_number", "name", "email", "phone", "created_at"}}
+	for _, s := range schools {
+		t.rows = append(t.rows, []string{strconv.Itoa(s.ID), s.RegistrationNumber, s.Name, s.Email, s.Phone, formatTime(s.CreatedAt)})
+	}
+	return t
+}
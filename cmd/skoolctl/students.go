@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"iter"
+	"strconv"
+
+	"skool-management/client"
+)
+
+var studentFields = []field{
+	{name: "roll_number", usage: "roll number, unique within the school"},
+	{name: "first_name", usage: "first name"},
+	{name: "last_name", usage: "last name"},
+	{name: "email", usage: "email"},
+	{name: "phone", usage: "phone number"},
+	{name: "date_of_birth", usage: "date of birth, YYYY-MM-DD"},
+	{name: "address", usage: "postal address"},
+	{name: "school_id", usage: "school the student attends", kind: intField},
+	{name: "enrollment_date", usage: "enrollment date, YYYY-MM-DD"},
+	{name: "status", usage: "status: active, inactive or graduated"},
+}
+
+func studentsCommand() *command {
+	return &command{
+		name:    "students",
+		summary: "Manage students",
+		subcommands: []*command{
+			{name: "list", summary: "List students", setup: listStudents},
+			{name: "get", args: "ID", summary: "Show a student", setup: getStudent},
+			{name: "create", summary: "Create a student", setup: createStudent},
+			{name: "update", args: "ID", summary: "Change the flags given of a student", setup: updateStudent},
+			{name: "delete", args: "ID", summary: "Delete a student", setup: deleteStudent},
+		},
+	}
+}
+
+func listStudents(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	schoolID := fs.Int("school", 0, "only students of this school")
+	pages := registerPageFlags(fs)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		list, all := studentLister(c, *schoolID)
+		students, err := listWithFlags(ctx, a, pages, "students", list, all)
+		if err != nil {
+			return err
+		}
+		return a.print(students, studentTable(students...))
+	}
+}
+
+func getStudent(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		id, err := parseID(args[0], "student")
+		if err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		s, err := c.GetStudent(ctx, id)
+		if err != nil {
+			return err
+		}
+		return a.print(s, studentTable(*s))
+	}
+}
+
+func createStudent(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	registerFields(fs, studentFields)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		var req client.StudentRequest
+		if err := applyFields(fs, studentFields, &req); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		s, err := c.CreateStudent(ctx, req)
+		if err != nil {
+			return err
+		}
+		return a.printMessage(s, "Created student %d (%s %s)", s.ID, s.FirstName, s.LastName)
+	}
+}
+
+func updateStudent(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	registerFields(fs, studentFields)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		id, err := parseID(args[0], "student")
+		if err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		// The API replaces the student, so start from the stored one
+		current, err := c.GetStudent(ctx, id)
+		if err != nil {
+			return err
+		}
+		var req client.StudentRequest
+		if err := convert(current, &req); err != nil {
+			return err
+		}
+		if err := applyFields(fs, studentFields, &req); err != nil {
+			return err
+		}
+
+		s, err := c.UpdateStudent(ctx, id, req)
+		if err != nil {
+			return err
+		}
+		return a.printMessage(s, "Updated student %d (%s %s)", s.ID, s.FirstName, s.LastName)
+	}
+}
+
+func deleteStudent(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		id, err := parseID(args[0], "student")
+		if err != nil {
+			return err
+		}
+		if err := a.confirm(*yes, "Delete student %d?", id); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		if err := c.DeleteStudent(ctx, id); err != nil {
+			return err
+		}
+		return a.printMessage(nil, "Deleted student %d", id)
+	}
+}
+
+// studentLister lists every student, or those of one school
+func studentLister(c *client.Client, schoolID int) (func(context.Context, client.ListOptions) (*client.Page[client.Student], error), func(context.Context, client.ListOptions) iter.Seq2[client.Student, error]) {
+	if schoolID == 0 {
+		return c.ListStudents, c.AllStudents
+	}
+	list := func(ctx context.Context, opts client.ListOptions) (*client.Page[client.Student], error) {
+		return c.ListStudentsBySchool(ctx, schoolID, opts)
+	}
+	all := func(ctx context.Context, opts client.ListOptions) iter.Seq2[client.Student, error] {
+		return c.AllStudentsBySchool(ctx, schoolID, opts)
+	}
+	return list, all
+}
+
+func studentTable(students ...client.Student) table {
+	t := table{headers: []string{"id", "roll_number", "name", "school_id", "status", "email", "enrollment_date"}}
+	for _, s := range students {
+		t.rows = append(t.rows, []string{strconv.Itoa(s.ID), s.RollNumber, s.FirstName + " " + s.LastName, strconv.Itoa(s.SchoolID), s.Status, s.Email, s.EnrollmentDate})
+	}
+	return t
+}
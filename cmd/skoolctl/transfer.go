@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skool-management/client"
+)
+
+// exportPageSize is the page size export and import use, the API maximum
+const exportPageSize = 100
+
+func exportCommand() *command {
+	return &command{
+		name:    "export",
+		summary: "Write every school or student as JSON or CSV",
+		subcommands: []*command{
+			{name: "schools", summary: "Export schools", setup: exportSchools},
+			{name: "students", summary: "Export students", setup: exportStudents},
+		},
+	}
+}
+
+func importCommand() *command {
+	return &command{
+		name:    "import",
+		summary: "Create schools or students from a JSON or CSV file",
+		subcommands: []*command{
+			{name: "schools", args: "FILE", summary: "Import schools", setup: importSchools},
+			{name: "students", args: "FILE", summary: "Import students", setup: importStudents},
+		},
+	}
+}
+
+func exportSchools(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	file := fs.String("file", "", "file to write instead of standard output; .csv selects CSV")
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		schools, err := client.Collect(c.AllSchools(ctx, client.ListOptions{PageSize: exportPageSize}))
+		if err != nil {
+			return err
+		}
+		return exportRecords(a, *file, schools)
+	}
+}
+
+func exportStudents(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	file := fs.String("file", "", "file to write instead of standard output; .csv selects CSV")
+	schoolID := fs.Int("school", 0, "only students of this school")
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		_, all := studentLister(c, *schoolID)
+		students, err := client.Collect(all(ctx, client.ListOptions{PageSize: exportPageSize}))
+		if err != nil {
+			return err
+		}
+		return exportRecords(a, *file, students)
+	}
+}
+
+// exportRecords writes records as a JSON array or CSV with a header row.
+// Tables would lose fields, so the table format falls back to the file
+// extension.
+func exportRecords[T any](a *app, file string, records []T) error {
+	format := a.output
+	if format == outputTable {
+		format = formatOf(file)
+	}
+
+	out := a.stdout
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var err error
+	if format == outputCSV {
+		err = writeCSV(out, records)
+	} else {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(records)
+	}
+	if err != nil {
+		return err
+	}
+	if f, ok := out.(*os.File); ok && file != "" {
+		return f.Close()
+	}
+	return nil
+}
+
+func writeCSV[T any](out io.Writer, records []T) error {
+	w := csv.NewWriter(out)
+	w.Write(csvHeader[T]())
+	for _, r := range records {
+		w.Write(csvRecord(r))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// formatOf picks CSV for .csv files and JSON otherwise
+func formatOf(file string) string {
+	if strings.EqualFold(filepath.Ext(file), ".csv") {
+		return outputCSV
+	}
+	return outputJSON
+}
+
+func importSchools(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	dryRun := fs.Bool("dry-run", false, "read and check the file without creating anything")
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "FILE"); err != nil {
+			return err
+		}
+		records, err := readRecords[client.SchoolRequest](args[0])
+		if err != nil {
+			return err
+		}
+		return importRecords(ctx, a, "schools", records, *dryRun, func(ctx context.Context, c *client.Client, key string, record client.SchoolRequest) error {
+			_, err := c.CreateSchoolWithKey(ctx, key, record)
+			return err
+		})
+	}
+}
+
+func importStudents(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	dryRun := fs.Bool("dry-run", false, "read and check the file without creating anything")
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "FILE"); err != nil {
+			return err
+		}
+		records, err := readRecords[client.StudentRequest](args[0])
+		if err != nil {
+			return err
+		}
+		return importRecords(ctx, a, "students", records, *dryRun, func(ctx context.Context, c *client.Client, key string, record client.StudentRequest) error {
+			_, err := c.CreateStudentWithKey(ctx, key, record)
+			return err
+		})
+	}
+}
+
+// readRecords reads a JSON array, or CSV with a header row when the file
+// ends in .csv. Exports read back as they are; IDs and timestamps are
+// ignored.
+func readRecords[T any](file string) ([]T, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []T
+	if formatOf(file) == outputJSON {
+		if err := json.NewDecoder(f).Decode(&records); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return records, nil
+	}
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: reading header: %w", file, err)
+	}
+	for line := 2; ; line++ {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		var record T
+		if err := decodeCSVRecord(header, row, &record); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", file, line, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// importResult is the outcome of an import
+type importResult struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// importRecords creates each record in turn. The idempotency key is derived
+// from the record, so rerunning an interrupted import replays what was
+// already created instead of duplicating it; records that already exist
+// are skipped.
+func importRecords[T any](ctx context.Context, a *app, noun string, items []T, dryRun bool, create func(context.Context, *client.Client, string, T) error) error {
+	if dryRun {
+		return a.printMessage(map[string]int{"records": len(items)}, "%d %s to import", len(items), noun)
+	}
+	c, err := a.api(true)
+	if err != nil {
+		return err
+	}
+
+	var result importResult
+	for i, record := range items {
+		key, err := importKey(noun, record)
+		if err != nil {
+			return err
+		}
+		err = create(ctx, c, key, record)
+		switch {
+		case err == nil:
+			result.Created++
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, client.ErrConflict):
+			result.Skipped++
+			fmt.Fprintf(a.stderr, "record %d: skipped: %v\n", i+1, firstLine(err))
+		default:
+			result.Failed++
+			fmt.Fprintf(a.stderr, "record %d: %v\n", i+1, err)
+		}
+	}
+
+	if err := a.printMessage(result, "Imported %s: %d created, %d skipped, %d failed", noun, result.Created, result.Skipped, result.Failed); err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d of %d %s failed", result.Failed, len(items), noun)
+	}
+	return nil
+}
+
+func importKey(noun string, record any) (string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(noun+"\n"), data...))
+	return "skoolctl-import-" + hex.EncodeToString(sum[:16]), nil
+}
+
+func firstLine(err error) string {
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return line
+}
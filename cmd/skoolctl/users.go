@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"skool-management/client"
+)
+
+var userFields = []field{
+	{name: "first_name", usage: "first name"},
+	{name: "last_name", usage: "last name"},
+	{name: "role", usage: "role: admin, or user for access limited to -school-ids"},
+	{name: "school_ids", usage: "school IDs a user may access", kind: listField},
+}
+
+func usersCommand() *command {
+	return &command{
+		name:    "users",
+		summary: "Manage user accounts (admin only)",
+		subcommands: []*command{
+			{name: "list", summary: "List users", setup: listUsers},
+			{name: "get", args: "ID", summary: "Show a user", setup: getUser},
+			{name: "create", summary: "Create a user", setup: createUser},
+			{name: "update", args: "ID", summary: "Change the flags given of a user", setup: updateUser},
+			{name: "delete", args: "ID", summary: "Delete a user", setup: deleteUser},
+		},
+	}
+}
+
+func listUsers(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	pages := registerPageFlags(fs)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		users, err := listWithFlags(ctx, a, pages, "users", c.ListUsers, c.AllUsers)
+		if err != nil {
+			return err
+		}
+		return a.print(users, userTable(users...))
+	}
+}
+
+func getUser(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		u, err := c.GetUser(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return a.print(u, userTable(*u))
+	}
+}
+
+func createUser(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	email := fs.String("email", "", "account email")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from standard input")
+	registerFields(fs, userFields)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args); err != nil {
+			return err
+		}
+		req := client.SignupRequest{Email: *email}
+		if err := applyFields(fs, userFields, &req); err != nil {
+			return err
+		}
+		password, err := a.readPassword("Password for "+req.Email+": ", *passwordStdin)
+		if err != nil {
+			return err
+		}
+		req.Password = password
+
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		u, err := c.Signup(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		// Signup always creates a plain user; grant the role and schools given
+		if grantsAccess(fs) {
+			var update client.UpdateUserRequest
+			if err := convert(u, &update); err != nil {
+				return err
+			}
+			if err := applyFields(fs, userFields, &update); err != nil {
+				return err
+			}
+			if u, err = c.UpdateUser(ctx, u.ID, update); err != nil {
+				return err
+			}
+		}
+		return a.printMessage(u, "Created user %s (%s)", u.ID, u.Email)
+	}
+}
+
+func updateUser(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	registerFields(fs, userFields)
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		// The API replaces the user, so start from the stored one
+		current, err := c.GetUser(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		var req client.UpdateUserRequest
+		if err := convert(current, &req); err != nil {
+			return err
+		}
+		if err := applyFields(fs, userFields, &req); err != nil {
+			return err
+		}
+
+		u, err := c.UpdateUser(ctx, args[0], req)
+		if err != nil {
+			return err
+		}
+		return a.printMessage(u, "Updated user %s (%s)", u.ID, u.Email)
+	}
+}
+
+func deleteUser(fs *flag.FlagSet, a *app) func(context.Context, []string) error {
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	return func(ctx context.Context, args []string) error {
+		if err := exactArgs(args, "ID"); err != nil {
+			return err
+		}
+		if err := a.confirm(*yes, "Delete user %s?", args[0]); err != nil {
+			return err
+		}
+		c, err := a.api(true)
+		if err != nil {
+			return err
+		}
+		if err := c.DeleteUser(ctx, args[0]); err != nil {
+			return err
+		}
+		return a.printMessage(nil, "Deleted user %s", args[0])
+	}
+}
+
+// grantsAccess reports whether the -role or -school-ids flag was given
+func grantsAccess(fs *flag.FlagSet) bool {
+	given := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "role" || f.Name == "school-ids" {
+			given = true
+		}
+	})
+	return given
+}
+
+func userTable(users ...client.User) table {
+	t := table{headers: []string{"id", "email", "name", "role", "school_ids", "created_at"}}
+	for _, u := range users {
+		t.rows = append(t.rows, []string{u.ID, u.Email, fmt.Sprintf("%s %s", u.FirstName, u.LastName), u.Role, strings.Join(u.SchoolIDs, ","), formatTime(u.CreatedAt)})
+	}
+	return t
+}
@@ -0,0 +1,7 @@
+// Package schoolpb holds the Go code protoc generates from proto/school.proto
+// for the school service's gRPC API. Run "go generate ./proto/..." or
+// "make proto" after changing the .proto file; this needs protoc,
+// protoc-gen-go and protoc-gen-go-grpc on PATH.
+package schoolpb
+
+//go:generate protoc -I .. --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative ../school.proto
@@ -2,29 +2,64 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	HTTPPort   string
-	GRPCPort   string
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	JWTSecret  string
+	LogLevel       string
+	HTTPPort       string
+	GRPCPort       string
+	DBHost         string
+	DBPort         string
+	DBUser         string
+	DBPassword     string
+	DBName         string
+	JWTSecret      string
+	IdentitySecret string
+	// TLS for the service's listeners; a client CA turns on mutual TLS
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string
+	TLSReloadInterval time.Duration
+	// HTTP server timeouts and shutdown sequence
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	DrainDelay      time.Duration
+	// Change events kept for the gateway to resume from, and the heartbeat
+	// interval of the event stream
+	EventLogSize   int
+	EventHeartbeat time.Duration
 }
 
 func Load() *Config {
 	return &Config{
-		HTTPPort:   getEnv("HTTP_PORT", "8082"),
-		GRPCPort:   getEnv("GRPC_PORT", "50051"),
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "schooluser"),
-		DBPassword: getEnv("DB_PASSWORD", "schoolpass"),
-		DBName:     getEnv("DB_NAME", "schooldb"),
-		JWTSecret:  getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+		LogLevel:       getEnv("LOG_LEVEL", "info"),
+		HTTPPort:       getEnv("HTTP_PORT", "8082"),
+		GRPCPort:       getEnv("GRPC_PORT", "50051"),
+		DBHost:         getEnv("DB_HOST", "localhost"),
+		DBPort:         getEnv("DB_PORT", "5432"),
+		DBUser:         getEnv("DB_USER", "schooluser"),
+		DBPassword:     getEnv("DB_PASSWORD", "schoolpass"),
+		DBName:         getEnv("DB_NAME", "schooldb"),
+		JWTSecret:      getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+		IdentitySecret: getEnv("IDENTITY_SECRET", "your-super-secret-identity-key-change-in-production"),
+
+		TLSCertFile:       getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:        getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile:   getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSReloadInterval: getDurationEnv("TLS_RELOAD_INTERVAL", 30*time.Second),
+
+		ReadTimeout:     getDurationEnv("HTTP_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:    getDurationEnv("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:     getDurationEnv("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 15*time.Second),
+		DrainDelay:      getDurationEnv("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+
+		EventLogSize:   getIntEnv("EVENT_LOG_SIZE", 1000),
+		EventHeartbeat: getDurationEnv("EVENT_HEARTBEAT_INTERVAL", 15*time.Second),
 	}
 }
 
@@ -39,3 +74,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
@@ -6,65 +6,42 @@ import (
 	"strconv"
 	"time"
 
-	"skool-management/school-service/internal/service"
+	"skool-management/proto/schoolpb"
+	"skool-management/school-service/internal/models"
 )
 
-// Protobuf message types
-type ProtoSchool struct {
-	Id                 string
-	RegistrationNumber string
-	Name               string
-	Address            string
-	Phone              string
-	Email              string
-	CreatedAt          string
-	UpdatedAt          string
-}
-
-type GetSchoolRequest struct {
-	Id string
-}
-
-type GetSchoolResponse struct {
-	School *ProtoSchool
-	Found  bool
-}
-
-type ValidateSchoolRequest struct {
-	Id string
-}
-
-type ValidateSchoolResponse struct {
-	Exists bool
-	Name   string
+// SchoolLookup finds schools by ID; *service.SchoolService implements it
+type SchoolLookup interface {
+	GetSchoolByID(ctx context.Context, id int) (*models.School, error)
 }
 
 // GRPCSchoolServer implements the gRPC server
 type GRPCSchoolServer struct {
-	schoolService *service.SchoolService
+	schoolpb.UnimplementedSchoolServiceServer
+	schools SchoolLookup
 }
 
-func NewGRPCSchoolServer(schoolService *service.SchoolService) *GRPCSchoolServer {
+func NewGRPCSchoolServer(schools SchoolLookup) *GRPCSchoolServer {
 	return &GRPCSchoolServer{
-		schoolService: schoolService,
+		schools: schools,
 	}
 }
 
-func (g *GRPCSchoolServer) GetSchool(ctx context.Context, req *GetSchoolRequest) (*GetSchoolResponse, error) {
-	id, err := strconv.Atoi(req.Id)
+func (g *GRPCSchoolServer) GetSchool(ctx context.Context, req *schoolpb.GetSchoolRequest) (*schoolpb.GetSchoolResponse, error) {
+	id, err := strconv.Atoi(req.GetId())
 	if err != nil {
-		return &GetSchoolResponse{Found: false}, nil
+		return &schoolpb.GetSchoolResponse{Found: false}, nil
 	}
 
-	school, err := g.schoolService.GetSchoolByID(id)
+	school, err := g.schools.GetSchoolByID(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return &GetSchoolResponse{Found: false}, nil
+			return &schoolpb.GetSchoolResponse{Found: false}, nil
 		}
 		return nil, err
 	}
 
-	protoSchool := &ProtoSchool{
+	protoSchool := &schoolpb.School{
 		Id:                 strconv.Itoa(school.ID),
 		RegistrationNumber: school.RegistrationNumber,
 		Name:               school.Name,
@@ -75,27 +52,27 @@ func (g *GRPCSchoolServer) GetSchool(ctx context.Context, req *GetSchoolRequest)
 		UpdatedAt:          school.UpdatedAt.Format(time.RFC3339),
 	}
 
-	return &GetSchoolResponse{
+	return &schoolpb.GetSchoolResponse{
 		School: protoSchool,
 		Found:  true,
 	}, nil
 }
 
-func (g *GRPCSchoolServer) ValidateSchool(ctx context.Context, req *ValidateSchoolRequest) (*ValidateSchoolResponse, error) {
-	id, err := strconv.Atoi(req.Id)
+func (g *GRPCSchoolServer) ValidateSchool(ctx context.Context, req *schoolpb.ValidateSchoolRequest) (*schoolpb.ValidateSchoolResponse, error) {
+	id, err := strconv.Atoi(req.GetId())
 	if err != nil {
-		return &ValidateSchoolResponse{Exists: false}, nil
+		return &schoolpb.ValidateSchoolResponse{Exists: false}, nil
 	}
 
-	school, err := g.schoolService.GetSchoolByID(id)
+	school, err := g.schools.GetSchoolByID(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return &ValidateSchoolResponse{Exists: false}, nil
+			return &schoolpb.ValidateSchoolResponse{Exists: false}, nil
 		}
-		return &ValidateSchoolResponse{Exists: false}, err
+		return nil, err
 	}
 
-	return &ValidateSchoolResponse{
+	return &schoolpb.ValidateSchoolResponse{
 		Exists: true,
 		Name:   school.Name,
 	}, nil
@@ -0,0 +1,123 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"skool-management/proto/schoolpb"
+	"skool-management/school-service/internal/models"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeSchools serves schools from a map, failing for ID 500
+type fakeSchools map[int]*models.School
+
+func (f fakeSchools) GetSchoolByID(ctx context.Context, id int) (*models.School, error) {
+	if id == 500 {
+		return nil, errors.New("database is down")
+	}
+	school, ok := f[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return school, nil
+}
+
+// dialSchoolService serves GRPCSchoolServer over an in-memory listener and
+// returns a client of the generated stubs connected to it
+func dialSchoolService(t *testing.T, schools SchoolLookup) schoolpb.SchoolServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	schoolpb.RegisterSchoolServiceServer(server, NewGRPCSchoolServer(schools))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return schoolpb.NewSchoolServiceClient(conn)
+}
+
+func TestValidateSchoolRoundTrip(t *testing.T) {
+	client := dialSchoolService(t, fakeSchools{7: {ID: 7, Name: "Springfield Elementary"}})
+
+	tests := []struct {
+		id     string
+		exists bool
+		name   string
+	}{
+		{id: "7", exists: true, name: "Springfield Elementary"},
+		{id: "8", exists: false},
+		{id: "not-a-number", exists: false},
+	}
+	for _, tt := range tests {
+		resp, err := client.ValidateSchool(context.Background(), &schoolpb.ValidateSchoolRequest{Id: tt.id})
+		if err != nil {
+			t.Fatalf("ValidateSchool(%q): %v", tt.id, err)
+		}
+		if resp.GetExists() != tt.exists || resp.GetName() != tt.name {
+			t.Errorf("ValidateSchool(%q) = {exists: %t, name: %q}, want {exists: %t, name: %q}", tt.id, resp.GetExists(), resp.GetName(), tt.exists, tt.name)
+		}
+	}
+
+	_, err := client.ValidateSchool(context.Background(), &schoolpb.ValidateSchoolRequest{Id: "500"})
+	if status.Code(err) != codes.Unknown {
+		t.Errorf("ValidateSchool with a failing lookup: got %v, want code Unknown", err)
+	}
+}
+
+func TestGetSchoolRoundTrip(t *testing.T) {
+	created := time.Date(2024, 9, 1, 8, 30, 0, 0, time.UTC)
+	client := dialSchoolService(t, fakeSchools{7: {
+		ID:                 7,
+		RegistrationNumber: "REG-007",
+		Name:               "Springfield Elementary",
+		Address:            "19 Plympton Street",
+		Phone:              "555-0107",
+		Email:              "office@springfield.example",
+		CreatedAt:          created,
+		UpdatedAt:          created.Add(time.Hour),
+	}})
+
+	resp, err := client.GetSchool(context.Background(), &schoolpb.GetSchoolRequest{Id: "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &schoolpb.School{
+		Id:                 "7",
+		RegistrationNumber: "REG-007",
+		Name:               "Springfield Elementary",
+		Address:            "19 Plympton Street",
+		Phone:              "555-0107",
+		Email:              "office@springfield.example",
+		CreatedAt:          "2024-09-01T08:30:00Z",
+		UpdatedAt:          "2024-09-01T09:30:00Z",
+	}
+	if !resp.GetFound() || !proto.Equal(resp.GetSchool(), want) {
+		t.Errorf("GetSchool(7) = %v, want found with %v", resp, want)
+	}
+
+	resp, err = client.GetSchool(context.Background(), &schoolpb.GetSchoolRequest{Id: "8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetFound() || resp.GetSchool() != nil {
+		t.Errorf("GetSchool(8) = %v, want not found", resp)
+	}
+}
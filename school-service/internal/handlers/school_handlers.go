@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -28,7 +29,7 @@ func (h *SchoolHandlers) CreateSchool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	school, err := h.schoolService.CreateSchool(&req)
+	school, err := h.schoolService.CreateSchool(r.Context(), &req)
 	if err != nil {
 		switch err.Error() {
 		case "school name is required":
@@ -38,7 +39,7 @@ func (h *SchoolHandlers) CreateSchool(w http.ResponseWriter, r *http.Request) {
 		case "school with this registration number already exists":
 			shared.WriteErrorResponse(w, http.StatusConflict, "REGISTRATION_EXISTS", err.Error())
 		default:
-			shared.LogError("SCHOOL_SERVICE", "create school", err)
+			slog.ErrorContext(r.Context(), "create school failed", "error", err)
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create school")
 		}
 		return
@@ -48,16 +49,57 @@ func (h *SchoolHandlers) CreateSchool(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *SchoolHandlers) GetSchools(w http.ResponseWriter, r *http.Request) {
-	schools, err := h.schoolService.GetAllSchools()
+	// ?ids=1,2,3 fetches several schools in one call
+	ids, fields := shared.ParseIDList(r.URL.Query(), "ids")
+	if len(fields) > 0 {
+		shared.WriteValidationErrorResponse(w, fields)
+		return
+	}
+
+	// Callers limited to some schools only ever see those
+	ids = shared.ScopeSchoolIDs(r.Context(), ids)
+
+	var schools []models.School
+	var err error
+	if ids != nil {
+		schools, err = h.schoolService.GetSchoolsByIDs(r.Context(), ids)
+	} else {
+		schools, err = h.schoolService.GetAllSchools(r.Context())
+	}
 	if err != nil {
-		shared.LogError("SCHOOL_SERVICE", "get schools", err)
+		slog.ErrorContext(r.Context(), "get schools failed", "error", err)
 		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get schools")
 		return
 	}
 
+	if shared.NotModified(w, r, schoolsETag(schools...)) {
+		return
+	}
 	shared.WriteSuccessResponse(w, http.StatusOK, "Schools retrieved successfully", schools)
 }
 
+// ListSchools serves GET /schools in API v2 with a paginated envelope
+func (h *SchoolHandlers) ListSchools(w http.ResponseWriter, r *http.Request) {
+	page, fields := shared.ParsePageRequest(r.URL.Query())
+	if len(fields) > 0 {
+		shared.WriteValidationErrorResponse(w, fields)
+		return
+	}
+
+	schools, total, err := h.schoolService.ListSchools(r.Context(), shared.ScopeSchoolIDs(r.Context(), nil), page)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "list schools failed", "error", err)
+		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get schools")
+		return
+	}
+
+	pagination := shared.NewPagination(page, total)
+	if shared.NotModified(w, r, schoolPageETag(schools, pagination)) {
+		return
+	}
+	shared.WriteSuccessResponse(w, http.StatusOK, "Schools retrieved successfully", models.SchoolPage{Items: schools, Pagination: pagination})
+}
+
 func (h *SchoolHandlers) GetSchool(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/schools/")
 	id, err := strconv.Atoi(idStr)
@@ -65,18 +107,24 @@ func (h *SchoolHandlers) GetSchool(w http.ResponseWriter, r *http.Request) {
 		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid school ID")
 		return
 	}
+	if !shared.CheckSchoolAccess(w, r, id) {
+		return
+	}
 
-	school, err := h.schoolService.GetSchoolByID(id)
+	school, err := h.schoolService.GetSchoolByID(r.Context(), id)
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			shared.WriteErrorResponse(w, http.StatusNotFound, "SCHOOL_NOT_FOUND", "School not found")
 			return
 		}
-		shared.LogError("SCHOOL_SERVICE", "get school", err)
+		slog.ErrorContext(r.Context(), "get school failed", "error", err)
 		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get school")
 		return
 	}
 
+	if shared.NotModified(w, r, schoolsETag(*school)) {
+		return
+	}
 	shared.WriteSuccessResponse(w, http.StatusOK, "School retrieved successfully", school)
 }
 
@@ -87,6 +135,9 @@ func (h *SchoolHandlers) UpdateSchool(w http.ResponseWriter, r *http.Request) {
 		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid school ID")
 		return
 	}
+	if !shared.CheckSchoolAccess(w, r, id) {
+		return
+	}
 
 	var req models.UpdateSchoolRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -94,7 +145,7 @@ func (h *SchoolHandlers) UpdateSchool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	school, err := h.schoolService.UpdateSchool(id, &req)
+	school, err := h.schoolService.UpdateSchool(r.Context(), id, &req)
 	if err != nil {
 		switch err.Error() {
 		case "registration number and name are required":
@@ -106,7 +157,7 @@ func (h *SchoolHandlers) UpdateSchool(w http.ResponseWriter, r *http.Request) {
 		case "school with this email already exists":
 			shared.WriteErrorResponse(w, http.StatusConflict, "EMAIL_EXISTS", err.Error())
 		default:
-			shared.LogError("SCHOOL_SERVICE", "update school", err)
+			slog.ErrorContext(r.Context(), "update school failed", "error", err)
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update school")
 		}
 		return
@@ -122,14 +173,17 @@ func (h *SchoolHandlers) DeleteSchool(w http.ResponseWriter, r *http.Request) {
 		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid school ID")
 		return
 	}
+	if !shared.CheckSchoolAccess(w, r, id) {
+		return
+	}
 
-	err = h.schoolService.DeleteSchool(id)
+	err = h.schoolService.DeleteSchool(r.Context(), id)
 	if err != nil {
 		if err.Error() == "school not found" {
 			shared.WriteErrorResponse(w, http.StatusNotFound, "SCHOOL_NOT_FOUND", err.Error())
 			return
 		}
-		shared.LogError("SCHOOL_SERVICE", "delete school", err)
+		slog.ErrorContext(r.Context(), "delete school failed", "error", err)
 		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete school")
 		return
 	}
@@ -137,6 +191,20 @@ func (h *SchoolHandlers) DeleteSchool(w http.ResponseWriter, r *http.Request) {
 	shared.WriteSuccessResponse(w, http.StatusOK, "School deleted successfully", nil)
 }
 
-func (h *SchoolHandlers) Health(w http.ResponseWriter, r *http.Request) {
-	shared.WriteSuccessResponse(w, http.StatusOK, "School service is healthy", nil)
+// schoolsETag versions a response by the schools' IDs and update times
+func schoolsETag(schools ...models.School) string {
+	return addSchools(shared.NewETagBuilder(), schools).ETag()
+}
+
+// schoolPageETag also covers the page's position, since a school added
+// elsewhere in the list changes the totals
+func schoolPageETag(schools []models.School, pagination shared.Pagination) string {
+	return addSchools(shared.NewETagBuilder(), schools).AddPagination(pagination).ETag()
+}
+
+func addSchools(etag *shared.ETagBuilder, schools []models.School) *shared.ETagBuilder {
+	for _, school := range schools {
+		etag.Add(school.ID, school.UpdatedAt)
+	}
+	return etag
 }
@@ -7,29 +7,39 @@ import (
 	"skool-management/shared"
 )
 
-func AuthMiddleware(jwtSecret string) func(http.HandlerFunc) http.HandlerFunc {
+// AuthMiddleware trusts identity headers only when they carry a valid gateway
+// signature, and otherwise falls back to verifying the bearer token itself.
+func AuthMiddleware(jwtSecret string, identitySigner *shared.IdentitySigner) func(http.HandlerFunc) http.HandlerFunc {
+	// Create JWT manager with same secret as auth service
+	jwtManager := shared.NewJWTManager(jwtSecret, "", time.Hour, time.Hour)
+
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(shared.HeaderIdentitySignature) != "" {
+				identity, err := identitySigner.Verify(r.Header)
+				if err != nil {
+					shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_IDENTITY", "Identity headers could not be verified")
+					return
+				}
+				next(w, r.WithContext(shared.WithIdentity(r.Context(), identity)))
+				return
+			}
+
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				shared.WriteErrorResponse(w, http.StatusUnauthorized, "MISSING_TOKEN", "Authorization header is required")
 				return
 			}
 
-			tokenString := authHeader
-			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-				tokenString = authHeader[7:]
-			}
-
-			// Create JWT manager with same secret as auth service
-			jwtManager := shared.NewJWTManager(jwtSecret, "", time.Hour, time.Hour)
-			_, err := jwtManager.VerifyToken(tokenString)
+			claims, err := jwtManager.VerifyToken(shared.ExtractBearerToken(authHeader))
 			if err != nil {
 				shared.WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token")
 				return
 			}
 
-			next(w, r)
+			identity := shared.IdentityFromClaims(claims)
+			identity.RequestID = shared.RequestIDFromContext(r.Context())
+			next(w, r.WithContext(shared.WithIdentity(r.Context(), identity)))
 		}
 	}
 }
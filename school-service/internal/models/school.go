@@ -1,30 +1,40 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"skool-management/shared"
+)
 
 type School struct {
-	ID                 int       `json:"id" db:"id"`
+	ID                 int       `json:"id" db:"id" jsonschema:"readonly"`
 	RegistrationNumber string    `json:"registration_number" db:"registration_number"`
 	Name               string    `json:"name" db:"name"`
 	Address            string    `json:"address" db:"address"`
 	Phone              string    `json:"phone" db:"phone"`
-	Email              string    `json:"email" db:"email"`
-	CreatedAt          time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+	Email              string    `json:"email" db:"email" jsonschema:"format=email"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at" jsonschema:"readonly"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at" jsonschema:"readonly"`
+}
+
+// SchoolPage is the v2 list response
+type SchoolPage struct {
+	Items      []School          `json:"items"`
+	Pagination shared.Pagination `json:"pagination"`
 }
 
 type CreateSchoolRequest struct {
-	RegistrationNumber string `json:"registration_number"`
-	Name               string `json:"name"`
+	RegistrationNumber string `json:"registration_number" jsonschema:"required,minLength=1"`
+	Name               string `json:"name" jsonschema:"required,minLength=1"`
 	Address            string `json:"address"`
 	Phone              string `json:"phone"`
-	Email              string `json:"email"`
+	Email              string `json:"email" jsonschema:"format=email"`
 }
 
 type UpdateSchoolRequest struct {
-	RegistrationNumber string `json:"registration_number"`
-	Name               string `json:"name"`
+	RegistrationNumber string `json:"registration_number" jsonschema:"required,minLength=1"`
+	Name               string `json:"name" jsonschema:"required,minLength=1"`
 	Address            string `json:"address"`
 	Phone              string `json:"phone"`
-	Email              string `json:"email"`
+	Email              string `json:"email" jsonschema:"format=email"`
 }
@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
 	"skool-management/school-service/internal/models"
+	"skool-management/shared"
+
+	"github.com/lib/pq"
 )
 
 type SchoolRepository struct {
@@ -15,7 +19,7 @@ func NewSchoolRepository(db *sql.DB) *SchoolRepository {
 	return &SchoolRepository{db: db}
 }
 
-func (r *SchoolRepository) Create(school *models.CreateSchoolRequest) (*models.School, error) {
+func (r *SchoolRepository) Create(ctx context.Context, school *models.CreateSchoolRequest) (*models.School, error) {
 	query := `
 		INSERT INTO schools (registration_number, name, address, phone, email, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -24,11 +28,13 @@ func (r *SchoolRepository) Create(school *models.CreateSchoolRequest) (*models.S
 
 	now := time.Now()
 	var result models.School
-	err := r.db.QueryRow(query, school.RegistrationNumber, school.Name, school.Address,
-		school.Phone, school.Email, now, now).Scan(
-		&result.ID, &result.RegistrationNumber, &result.Name, &result.Address,
-		&result.Phone, &result.Email, &result.CreatedAt, &result.UpdatedAt,
-	)
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.Create", query, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, query, school.RegistrationNumber, school.Name, school.Address,
+			school.Phone, school.Email, now, now).Scan(
+			&result.ID, &result.RegistrationNumber, &result.Name, &result.Address,
+			&result.Phone, &result.Email, &result.CreatedAt, &result.UpdatedAt,
+		)
+	})
 
 	if err != nil {
 		return nil, err
@@ -37,36 +43,123 @@ func (r *SchoolRepository) Create(school *models.CreateSchoolRequest) (*models.S
 	return &result, nil
 }
 
-func (r *SchoolRepository) GetAll() ([]models.School, error) {
+func (r *SchoolRepository) GetAll(ctx context.Context) ([]models.School, error) {
 	query := `
 		SELECT id, registration_number, name, address, phone, email, created_at, updated_at
 		FROM schools
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+	var schools []models.School
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.GetAll", query, func(ctx context.Context) error {
+		var err error
+		schools, err = r.querySchools(ctx, query)
+		return err
+	})
+
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+
+	return schools, nil
+}
+
+// GetPage returns one page of schools, newest first, and the total number of schools
+func (r *SchoolRepository) GetPage(ctx context.Context, page shared.PageRequest) ([]models.School, int, error) {
+	countQuery := `SELECT COUNT(*) FROM schools`
+	query := `
+		SELECT id, registration_number, name, address, phone, email, created_at, updated_at
+		FROM schools
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	var total int
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.Count", countQuery, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, countQuery).Scan(&total)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
 
 	var schools []models.School
-	for rows.Next() {
-		var school models.School
-		err := rows.Scan(
-			&school.ID, &school.RegistrationNumber, &school.Name, &school.Address,
-			&school.Phone, &school.Email, &school.CreatedAt, &school.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		schools = append(schools, school)
+	err = shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.GetPage", query, func(ctx context.Context) error {
+		var err error
+		schools, err = r.querySchools(ctx, query, page.PageSize, page.Offset())
+		return err
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if schools == nil {
+		schools = []models.School{}
+	}
+	return schools, total, nil
+}
+
+// GetPageByIDs returns one page of the schools with the given IDs, newest
+// first, and how many of those schools exist
+func (r *SchoolRepository) GetPageByIDs(ctx context.Context, ids []int, page shared.PageRequest) ([]models.School, int, error) {
+	countQuery := `SELECT COUNT(*) FROM schools WHERE id = ANY($1)`
+	query := `
+		SELECT id, registration_number, name, address, phone, email, created_at, updated_at
+		FROM schools
+		WHERE id = ANY($3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	var total int
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.CountByIDs", countQuery, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, countQuery, pq.Array(ids)).Scan(&total)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var schools []models.School
+	err = shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.GetPageByIDs", query, func(ctx context.Context) error {
+		var err error
+		schools, err = r.querySchools(ctx, query, page.PageSize, page.Offset(), pq.Array(ids))
+		return err
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if schools == nil {
+		schools = []models.School{}
+	}
+	return schools, total, nil
+}
+
+// GetByIDs returns the schools with the given IDs; unknown IDs are skipped
+func (r *SchoolRepository) GetByIDs(ctx context.Context, ids []int) ([]models.School, error) {
+	query := `
+		SELECT id, registration_number, name, address, phone, email, created_at, updated_at
+		FROM schools
+		WHERE id = ANY($1)
+		ORDER BY created_at DESC
+	`
+
+	var schools []models.School
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.GetByIDs", query, func(ctx context.Context) error {
+		var err error
+		schools, err = r.querySchools(ctx, query, pq.Array(ids))
+		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return schools, nil
 }
 
-func (r *SchoolRepository) GetByID(id int) (*models.School, error) {
+func (r *SchoolRepository) GetByID(ctx context.Context, id int) (*models.School, error) {
 	query := `
 		SELECT id, registration_number, name, address, phone, email, created_at, updated_at
 		FROM schools
@@ -74,10 +167,12 @@ func (r *SchoolRepository) GetByID(id int) (*models.School, error) {
 	`
 
 	var school models.School
-	err := r.db.QueryRow(query, id).Scan(
-		&school.ID, &school.RegistrationNumber, &school.Name, &school.Address,
-		&school.Phone, &school.Email, &school.CreatedAt, &school.UpdatedAt,
-	)
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.GetByID", query, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, query, id).Scan(
+			&school.ID, &school.RegistrationNumber, &school.Name, &school.Address,
+			&school.Phone, &school.Email, &school.CreatedAt, &school.UpdatedAt,
+		)
+	})
 
 	if err != nil {
 		return nil, err
@@ -86,7 +181,7 @@ func (r *SchoolRepository) GetByID(id int) (*models.School, error) {
 	return &school, nil
 }
 
-func (r *SchoolRepository) Update(id int, school *models.UpdateSchoolRequest) (*models.School, error) {
+func (r *SchoolRepository) Update(ctx context.Context, id int, school *models.UpdateSchoolRequest) (*models.School, error) {
 	query := `
 		UPDATE schools
 		SET registration_number = $1, name = $2, address = $3, phone = $4, email = $5, updated_at = $6
@@ -95,11 +190,13 @@ func (r *SchoolRepository) Update(id int, school *models.UpdateSchoolRequest) (*
 	`
 
 	var result models.School
-	err := r.db.QueryRow(query, school.RegistrationNumber, school.Name, school.Address,
-		school.Phone, school.Email, time.Now(), id).Scan(
-		&result.ID, &result.RegistrationNumber, &result.Name, &result.Address,
-		&result.Phone, &result.Email, &result.CreatedAt, &result.UpdatedAt,
-	)
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.Update", query, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, query, school.RegistrationNumber, school.Name, school.Address,
+			school.Phone, school.Email, time.Now(), id).Scan(
+			&result.ID, &result.RegistrationNumber, &result.Name, &result.Address,
+			&result.Phone, &result.Email, &result.CreatedAt, &result.UpdatedAt,
+		)
+	})
 
 	if err != nil {
 		return nil, err
@@ -108,17 +205,48 @@ func (r *SchoolRepository) Update(id int, school *models.UpdateSchoolRequest) (*
 	return &result, nil
 }
 
-func (r *SchoolRepository) Delete(id int) error {
+func (r *SchoolRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM schools WHERE id = $1`
-	result, err := r.db.Exec(query, id)
+	var rowsAffected int64
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "SchoolRepository.Delete", query, func(ctx context.Context) error {
+		result, err := r.db.ExecContext(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		rowsAffected, _ = result.RowsAffected()
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
 		return sql.ErrNoRows
 	}
 
 	return nil
 }
+
+// querySchools runs a SELECT returning full school rows
+func (r *SchoolRepository) querySchools(ctx context.Context, query string, args ...interface{}) ([]models.School, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schools []models.School
+	for rows.Next() {
+		var school models.School
+		err := rows.Scan(
+			&school.ID, &school.RegistrationNumber, &school.Name, &school.Address,
+			&school.Phone, &school.Email, &school.CreatedAt, &school.UpdatedAt,
+		)
+		if err != nil {
+			continue
+		}
+		schools = append(schools, school)
+	}
+
+	return schools, nil
+}
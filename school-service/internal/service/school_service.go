@@ -1,25 +1,30 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"strings"
 
 	"skool-management/school-service/internal/models"
 	"skool-management/school-service/internal/repository"
+	"skool-management/shared"
 )
 
 type SchoolService struct {
 	schoolRepo *repository.SchoolRepository
+	events     *shared.EventLog
 }
 
-func NewSchoolService(schoolRepo *repository.SchoolRepository) *SchoolService {
+// NewSchoolService creates the service; every change it makes is published to events
+func NewSchoolService(schoolRepo *repository.SchoolRepository, events *shared.EventLog) *SchoolService {
 	return &SchoolService{
 		schoolRepo: schoolRepo,
+		events:     events,
 	}
 }
 
-func (s *SchoolService) CreateSchool(req *models.CreateSchoolRequest) (*models.School, error) {
+func (s *SchoolService) CreateSchool(ctx context.Context, req *models.CreateSchoolRequest) (*models.School, error) {
 	if req.Name == "" {
 		return nil, errors.New("school name is required")
 	}
@@ -28,7 +33,7 @@ func (s *SchoolService) CreateSchool(req *models.CreateSchoolRequest) (*models.S
 		return nil, errors.New("school registration number is required")
 	}
 
-	school, err := s.schoolRepo.Create(req)
+	school, err := s.schoolRepo.Create(ctx, req)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key value") {
 			return nil, errors.New("school with this registration number already exists")
@@ -36,23 +41,38 @@ func (s *SchoolService) CreateSchool(req *models.CreateSchoolRequest) (*models.S
 		return nil, errors.New("failed to create school")
 	}
 
+	s.events.Publish(ctx, schoolEvent(shared.EventSchoolCreated, school.ID), school)
 	return school, nil
 }
 
-func (s *SchoolService) GetAllSchools() ([]models.School, error) {
-	return s.schoolRepo.GetAll()
+func (s *SchoolService) GetAllSchools(ctx context.Context) ([]models.School, error) {
+	return s.schoolRepo.GetAll(ctx)
 }
 
-func (s *SchoolService) GetSchoolByID(id int) (*models.School, error) {
-	return s.schoolRepo.GetByID(id)
+// GetSchoolsByIDs returns the schools with the given IDs, skipping unknown ones
+func (s *SchoolService) GetSchoolsByIDs(ctx context.Context, ids []int) ([]models.School, error) {
+	return s.schoolRepo.GetByIDs(ctx, ids)
 }
 
-func (s *SchoolService) UpdateSchool(id int, req *models.UpdateSchoolRequest) (*models.School, error) {
+// ListSchools returns one page of the schools with the given IDs, or of
+// every school when ids is nil, and their total number
+func (s *SchoolService) ListSchools(ctx context.Context, ids []int, page shared.PageRequest) ([]models.School, int, error) {
+	if ids != nil {
+		return s.schoolRepo.GetPageByIDs(ctx, ids, page)
+	}
+	return s.schoolRepo.GetPage(ctx, page)
+}
+
+func (s *SchoolService) GetSchoolByID(ctx context.Context, id int) (*models.School, error) {
+	return s.schoolRepo.GetByID(ctx, id)
+}
+
+func (s *SchoolService) UpdateSchool(ctx context.Context, id int, req *models.UpdateSchoolRequest) (*models.School, error) {
 	if req.RegistrationNumber == "" || req.Name == "" {
 		return nil, errors.New("registration number and name are required")
 	}
 
-	school, err := s.schoolRepo.Update(id, req)
+	school, err := s.schoolRepo.Update(ctx, id, req)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("school not found")
@@ -67,16 +87,23 @@ func (s *SchoolService) UpdateSchool(id int, req *models.UpdateSchoolRequest) (*
 		return nil, errors.New("failed to update school")
 	}
 
+	s.events.Publish(ctx, schoolEvent(shared.EventSchoolUpdated, school.ID), school)
 	return school, nil
 }
 
-func (s *SchoolService) DeleteSchool(id int) error {
-	err := s.schoolRepo.Delete(id)
+func (s *SchoolService) DeleteSchool(ctx context.Context, id int) error {
+	err := s.schoolRepo.Delete(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return errors.New("school not found")
 		}
 		return errors.New("failed to delete school")
 	}
+
+	s.events.Publish(ctx, schoolEvent(shared.EventSchoolDeleted, id), nil)
 	return nil
 }
+
+func schoolEvent(eventType string, id int) shared.Event {
+	return shared.Event{Type: eventType, Entity: shared.EventEntitySchool, EntityID: id, SchoolID: id}
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 
+	"skool-management/proto/schoolpb"
 	"skool-management/school-service/internal/config"
 	schoolGrpc "skool-management/school-service/internal/grpc"
 	"skool-management/school-service/internal/handlers"
@@ -16,6 +18,7 @@ import (
 	"skool-management/shared"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 
 	_ "github.com/lib/pq"
@@ -24,71 +27,95 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	shared.InitLogger("school-service", cfg.LogLevel)
+	lifecycle := shared.NewLifecycle(shared.ServerTimeouts{
+		Read:       cfg.ReadTimeout,
+		Write:      cfg.WriteTimeout,
+		Idle:       cfg.IdleTimeout,
+		Shutdown:   cfg.ShutdownTimeout,
+		DrainDelay: cfg.DrainDelay,
+	})
+
+	// Initialize tracing
+	shutdownTracing, err := shared.InitTracing(context.Background(), "school-service")
+	if err != nil {
+		log.Fatal("Failed to initialize tracing:", err)
+	}
+	lifecycle.OnShutdown("tracing", shutdownTracing)
 
 	// Connect to PostgreSQL
 	db, err := sql.Open("postgres", cfg.GetDSN())
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
+	lifecycle.OnShutdown("postgres", func(context.Context) error { return db.Close() })
 
 	// Test connection
 	if err := db.Ping(); err != nil {
 		log.Fatal("Failed to ping database:", err)
 	}
+	shared.RegisterDBStats(db, "schooldb")
+
+	// TLS for the service's listeners; they stay plain text without a certificate
+	serverTLS, err := shared.NewServerTLSConfig("school-service", shared.TLSFiles{
+		CertFile: cfg.TLSCertFile,
+		KeyFile:  cfg.TLSKeyFile,
+		CAFile:   cfg.TLSClientCAFile,
+	}, cfg.TLSReloadInterval)
+	if err != nil {
+		log.Fatal("Failed to load TLS certificates:", err)
+	}
 
 	// Initialize layers
 	schoolRepo := repository.NewSchoolRepository(db)
-	schoolService := service.NewSchoolService(schoolRepo)
+	events := shared.NewEventLog(shared.EventLogConfig{Name: "school-service", Size: cfg.EventLogSize})
+	schoolService := service.NewSchoolService(schoolRepo, events)
 	schoolHandlers := handlers.NewSchoolHandlers(schoolService)
-	authMiddleware := middleware.AuthMiddleware(cfg.JWTSecret)
+	authMiddleware := middleware.AuthMiddleware(cfg.JWTSecret, shared.NewIdentitySigner(cfg.IdentitySecret, shared.DefaultIdentityMaxSkew))
 
 	// Start gRPC server
-	go func() {
-		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
-		if err != nil {
-			log.Fatal("Failed to listen on gRPC port:", err)
-		}
-
-		grpcServer := grpc.NewServer()
-		grpcSchoolServer := schoolGrpc.NewGRPCSchoolServer(schoolService)
-		RegisterSchoolServiceServer(grpcServer, grpcSchoolServer)
-		reflection.Register(grpcServer)
-
-		shared.LogInfo("SCHOOL_SERVICE", fmt.Sprintf("Starting gRPC server on port %s", cfg.GRPCPort))
-		if err := grpcServer.Serve(lis); err != nil {
-			log.Fatal("Failed to serve gRPC:", err)
-		}
-	}()
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		log.Fatal("Failed to listen on gRPC port:", err)
+	}
 
-	// Setup HTTP routes
-	http.HandleFunc("/schools", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			authMiddleware(schoolHandlers.GetSchools)(w, r)
-		case "POST":
-			authMiddleware(schoolHandlers.CreateSchool)(w, r)
-		default:
-			shared.WriteErrorResponse(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
-		}
-	})
+	grpcOptions := []grpc.ServerOption{
+		shared.GRPCServerTracing(),
+		grpc.ChainUnaryInterceptor(
+			shared.UnaryServerRequestIDInterceptor(),
+			shared.UnaryServerMetricsInterceptor(),
+		),
+	}
+	if serverTLS != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(serverTLS)))
+	}
+	grpcServer := grpc.NewServer(grpcOptions...)
+	grpcSchoolServer := schoolGrpc.NewGRPCSchoolServer(schoolService)
+	schoolpb.RegisterSchoolServiceServer(grpcServer, grpcSchoolServer)
+	reflection.Register(grpcServer)
 
-	http.HandleFunc("/schools/", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			authMiddleware(schoolHandlers.GetSchool)(w, r)
-		case "PUT":
-			authMiddleware(schoolHandlers.UpdateSchool)(w, r)
-		case "DELETE":
-			authMiddleware(schoolHandlers.DeleteSchool)(w, r)
-		default:
-			shared.WriteErrorResponse(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
-		}
-	})
+	slog.Info("starting gRPC server", "port", cfg.GRPCPort, "tls", serverTLS != nil)
+	lifecycle.ServeGRPC(grpcServer, lis)
 
-	// Health check
-	http.HandleFunc("/health", schoolHandlers.Health)
+	// Health checks: /livez only reports the process is up, /readyz and
+	// /health also verify dependencies
+	readiness := shared.ReadinessHandler(lifecycle.ReadinessCheck(), shared.SQLPingCheck("postgres", db))
+	breakerAdmin := authMiddleware(shared.RequireRole(shared.RoleAdmin, shared.CircuitBreakerAdminHandler(shared.DefaultCircuitBreakerRegistry)))
+	eventStream := authMiddleware(shared.RequireRole(shared.RoleAdmin, shared.EventStreamHandler(events, shared.EventStreamConfig{Heartbeat: cfg.EventHeartbeat})))
 
-	shared.LogInfo("SCHOOL_SERVICE", fmt.Sprintf("Starting HTTP server on port %s", cfg.HTTPPort))
-	log.Fatal(http.ListenAndServe(":"+cfg.HTTPPort, nil))
+	// Setup HTTP routes
+	shared.RegisterRoutes(http.DefaultServeMux, routes(schoolHandlers, authMiddleware, readiness, breakerAdmin, eventStream))
+
+	slog.Info("starting HTTP server", "port", cfg.HTTPPort, "tls", serverTLS != nil)
+	httpServer := lifecycle.NewHTTPServer(":"+cfg.HTTPPort, shared.TraceHandler(shared.InstrumentHandler(shared.CompressHandler(shared.RequestIDHandler(shared.AccessLogHandler(shared.NewAPIVersioning(shared.APIVersionConfig{}).Handler(shared.RouteHandler(http.DefaultServeMux)))))), "school-service"))
+	httpServer.TLSConfig = serverTLS
+	// End the gateway's event streams as soon as draining starts
+	httpServer.RegisterOnShutdown(events.Close)
+	lifecycle.ServeHTTP(httpServer)
+
+	// Block until SIGINT/SIGTERM, then drain servers and close resources
+	if err := lifecycle.Wait(); err != nil {
+		log.Fatal("Server failed:", err)
+	}
+	slog.Info("school service stopped")
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+
+	"skool-management/school-service/internal/models"
+	"skool-management/shared"
+)
+
+// openAPISpec describes the school service's HTTP API
+func openAPISpec() *shared.OpenAPIDocument {
+	idParam := map[string]string{"id": "integer"}
+
+	return shared.NewOpenAPIDocument("School Service", "1.0.0", "Manages schools.").AddEndpoints(
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/schools", Tag: "schools", Authenticated: true,
+			Summary:     "List schools",
+			Description: "Pass ids=1,2,3 to fetch only those schools; unknown IDs are skipped.",
+			QueryParams: map[string]string{"ids": "string"},
+			Response:    []models.School{},
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/v2/schools", Tag: "schools", Authenticated: true,
+			Summary:     "List schools a page at a time",
+			QueryParams: shared.PaginationQueryParams(),
+			Response:    models.SchoolPage{},
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodPost, Path: "/schools", Tag: "schools", Authenticated: true,
+			Strict:     true,
+			Idempotent: true,
+			Summary:    "Create a school",
+			Request:    models.CreateSchoolRequest{},
+			Response:   models.School{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/schools/{id}", Tag: "schools", Authenticated: true,
+			Summary: "Get a school", PathParams: idParam,
+			Response: models.School{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodPut, Path: "/schools/{id}", Tag: "schools", Authenticated: true,
+			Summary: "Update a school", PathParams: idParam,
+			Request:  models.UpdateSchoolRequest{},
+			Response: models.School{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodDelete, Path: "/schools/{id}", Tag: "schools", Authenticated: true,
+			Summary: "Delete a school", PathParams: idParam,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		shared.EventStreamEndpoint(),
+	).AddEndpoints(shared.OperationalEndpoints()...)
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+
+	"skool-management/school-service/internal/handlers"
+	"skool-management/shared"
+)
+
+// routes lists every HTTP route the school service serves. Each one must be
+// described by openAPISpec, which routes_test.go enforces.
+func routes(h *handlers.SchoolHandlers, auth func(http.HandlerFunc) http.HandlerFunc, readiness, breakerAdmin, events http.HandlerFunc) []shared.Route {
+	return append([]shared.Route{
+		{Method: http.MethodGet, Path: "/schools", Handler: auth(h.GetSchools)},
+		{Method: http.MethodGet, Path: "/schools", Handler: auth(h.ListSchools), Version: shared.APIVersion2},
+		{Method: http.MethodPost, Path: "/schools", Handler: auth(h.CreateSchool)},
+		{Method: http.MethodGet, Path: "/schools/{id}", Handler: auth(h.GetSchool)},
+		{Method: http.MethodPut, Path: "/schools/{id}", Handler: auth(h.UpdateSchool)},
+		{Method: http.MethodDelete, Path: "/schools/{id}", Handler: auth(h.DeleteSchool)},
+		{Method: http.MethodGet, Path: shared.EventStreamPath, Handler: events},
+	}, shared.OperationalRoutes(readiness, breakerAdmin, openAPISpec())...)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"skool-management/school-service/internal/handlers"
+	"skool-management/shared"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	passthrough := func(next http.HandlerFunc) http.HandlerFunc { return next }
+	for _, problem := range shared.CheckRouteCoverage(routes(nil, passthrough, nil, nil, nil), openAPISpec()) {
+		t.Error(problem)
+	}
+}
+
+func TestSchoolsOutsideScopeAreForbidden(t *testing.T) {
+	// The handlers have no service, so any request that gets past the scope
+	// check would panic
+	scoped := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			identity := &shared.Identity{UserID: "u1", Roles: []string{shared.RoleUser}, SchoolIDs: []string{"1"}}
+			next(w, r.WithContext(shared.WithIdentity(r.Context(), identity)))
+		}
+	}
+	mux := http.NewServeMux()
+	shared.RegisterRoutes(mux, routes(handlers.NewSchoolHandlers(nil), scoped, nil, nil, nil))
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, "/schools/2", strings.NewReader(`{}`)))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s /schools/2 status = %d, want 403", method, rec.Code)
+		}
+	}
+}
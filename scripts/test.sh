@@ -18,8 +18,7 @@ SIGNUP_RESPONSE=$(curl -s -X POST "$API_BASE/auth/signup" \
     "email": "test@example.com",
     "password": "password123",
     "first_name": "Test",
-    "last_name": "User",
-    "role": "admin"
+    "last_name": "User"
   }')
 echo "$SIGNUP_RESPONSE" | jq '.' || echo "Signup failed"
 echo ""
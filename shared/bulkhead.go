@@ -0,0 +1,132 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBulkheadFull is returned when a bulkhead has no free slot and its wait
+// queue is full or the queue timeout expired
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+// Defaults applied to zero valued BulkheadConfig fields
+const (
+	DefaultBulkheadMaxConcurrent = 50
+	DefaultBulkheadQueueTimeout  = time.Second
+)
+
+// BulkheadConfig holds configuration for a bulkhead
+type BulkheadConfig struct {
+	Name string
+	// MaxConcurrent is the number of calls allowed to run at once
+	MaxConcurrent int
+	// MaxQueue is the number of calls allowed to wait for a slot; further
+	// calls are rejected immediately (0 disables waiting)
+	MaxQueue int
+	// QueueTimeout bounds how long a queued call waits for a slot
+	QueueTimeout time.Duration
+}
+
+// Bulkhead limits the number of concurrent calls to a dependency so that a
+// slow dependency cannot exhaust the goroutines and connections shared with
+// others
+type Bulkhead struct {
+	name    string
+	timeout time.Duration
+	slots   chan struct{}
+	queue   chan struct{}
+}
+
+// NewBulkhead creates a new bulkhead
+func NewBulkhead(config BulkheadConfig) *Bulkhead {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = DefaultBulkheadMaxConcurrent
+	}
+	if config.MaxQueue < 0 {
+		config.MaxQueue = 0
+	}
+	if config.QueueTimeout <= 0 {
+		config.QueueTimeout = DefaultBulkheadQueueTimeout
+	}
+
+	bulkheadInFlight.WithLabelValues(config.Name).Set(0)
+	bulkheadQueued.WithLabelValues(config.Name).Set(0)
+	return &Bulkhead{
+		name:    config.Name,
+		timeout: config.QueueTimeout,
+		slots:   make(chan struct{}, config.MaxConcurrent),
+		queue:   make(chan struct{}, config.MaxQueue),
+	}
+}
+
+// Acquire reserves a slot, waiting in the queue if necessary. The returned
+// release function must be called exactly once when the call finishes.
+func (b *Bulkhead) Acquire(ctx context.Context) (func(), error) {
+	// Fast path: a slot is free
+	select {
+	case b.slots <- struct{}{}:
+		return b.admitted(), nil
+	default:
+	}
+
+	// Join the wait queue unless it is full
+	select {
+	case b.queue <- struct{}{}:
+	default:
+		bulkheadRejections.WithLabelValues(b.name, "queue_full").Inc()
+		return nil, fmt.Errorf("%w: %s queue is full", ErrBulkheadFull, b.name)
+	}
+	bulkheadQueued.WithLabelValues(b.name).Inc()
+	defer func() {
+		<-b.queue
+		bulkheadQueued.WithLabelValues(b.name).Dec()
+	}()
+
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		return b.admitted(), nil
+	case <-timer.C:
+		bulkheadRejections.WithLabelValues(b.name, "queue_timeout").Inc()
+		return nil, fmt.Errorf("%w: %s queue timeout after %s", ErrBulkheadFull, b.name, b.timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Bulkhead) admitted() func() {
+	bulkheadInFlight.WithLabelValues(b.name).Inc()
+	return func() {
+		bulkheadInFlight.WithLabelValues(b.name).Dec()
+		<-b.slots
+	}
+}
+
+// Execute runs fn once a slot is available
+func (b *Bulkhead) Execute(ctx context.Context, fn func(context.Context) error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
+
+// InFlight returns the number of calls currently holding a slot
+func (b *Bulkhead) InFlight() int {
+	return len(b.slots)
+}
+
+// Queued returns the number of calls waiting for a slot
+func (b *Bulkhead) Queued() int {
+	return len(b.queue)
+}
+
+// Name returns the bulkhead name
+func (b *Bulkhead) Name() string {
+	return b.name
+}
@@ -0,0 +1,127 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBulkheadRejectsWhenFull(t *testing.T) {
+	tests := []struct {
+		name   string
+		config BulkheadConfig
+		// fillQueue parks a waiter in the queue first
+		fillQueue bool
+	}{
+		{"no queue", BulkheadConfig{MaxConcurrent: 1}, false},
+		{"queue full", BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Minute}, true},
+		{"queue timeout", BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Name = "test-" + tt.name
+			b := NewBulkhead(tt.config)
+			release, err := b.Acquire(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer release()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.fillQueue {
+				go b.Acquire(ctx)
+				waitFor(t, func() bool { return b.Queued() == 1 })
+			}
+
+			if _, err := b.Acquire(context.Background()); !errors.Is(err, ErrBulkheadFull) {
+				t.Errorf("Acquire err = %v, want ErrBulkheadFull", err)
+			}
+			if !tt.fillQueue && b.Queued() != 0 {
+				t.Errorf("%d calls still queued", b.Queued())
+			}
+		})
+	}
+}
+
+func TestBulkheadQueuedCallGetsReleasedSlot(t *testing.T) {
+	b := NewBulkhead(BulkheadConfig{Name: "test-release", MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Minute})
+	release, err := b.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		release, err := b.Acquire(context.Background())
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+	waitFor(t, func() bool { return b.Queued() == 1 })
+
+	release()
+	if err := <-acquired; err != nil {
+		t.Errorf("queued Acquire err = %v", err)
+	}
+	if b.InFlight() != 0 || b.Queued() != 0 {
+		t.Errorf("in flight %d, queued %d after release; want 0, 0", b.InFlight(), b.Queued())
+	}
+}
+
+func TestBulkheadCanceledWhileQueued(t *testing.T) {
+	b := NewBulkhead(BulkheadConfig{Name: "test-cancel", MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Minute})
+	release, err := b.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	acquired := make(chan error)
+	go func() {
+		_, err := b.Acquire(ctx)
+		acquired <- err
+	}()
+	waitFor(t, func() bool { return b.Queued() == 1 })
+
+	cancel()
+	// A canceled caller gave up; the bulkhead did not reject it
+	if err := <-acquired; !errors.Is(err, context.Canceled) || errors.Is(err, ErrBulkheadFull) {
+		t.Errorf("Acquire err = %v, want context.Canceled", err)
+	}
+	if b.Queued() != 0 || b.InFlight() != 1 {
+		t.Errorf("in flight %d, queued %d; want 1, 0", b.InFlight(), b.Queued())
+	}
+}
+
+func TestBulkheadExecuteReleasesSlot(t *testing.T) {
+	b := NewBulkhead(BulkheadConfig{Name: "test-execute", MaxConcurrent: 1})
+	if err := b.Execute(context.Background(), func(context.Context) error { return errBoom }); !errors.Is(err, errBoom) {
+		t.Errorf("Execute err = %v, want the function's error", err)
+	}
+	func() {
+		defer func() { recover() }()
+		b.Execute(context.Background(), func(context.Context) error { panic(errBoom) })
+	}()
+	if b.InFlight() != 0 {
+		t.Errorf("%d slots held after Execute returned", b.InFlight())
+	}
+	if err := b.Execute(context.Background(), func(context.Context) error { return nil }); err != nil {
+		t.Errorf("Execute after release: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
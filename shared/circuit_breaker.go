@@ -1,7 +1,10 @@
 package shared
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -15,84 +18,408 @@ const (
 	StateOpen
 )
 
-// CircuitBreaker implements the circuit breaker pattern
-type CircuitBreaker struct {
-	name            string
-	maxFailures     int
-	resetTimeout    time.Duration
-	failureCount    int
-	lastFailureTime time.Time
-	state           CircuitBreakerState
-	mutex           sync.Mutex
-}
+// ErrCircuitOpen is returned when the breaker rejects a call without running it
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// WindowType selects how the sliding window of recent calls is measured
+type WindowType int
+
+const (
+	// CountBasedWindow keeps the outcome of the last WindowSize calls
+	CountBasedWindow WindowType = iota
+	// TimeBasedWindow keeps the outcome of calls made in the last WindowDuration
+	TimeBasedWindow
+)
+
+// Defaults applied to zero valued CircuitBreakerConfig fields
+const (
+	DefaultWindowSize            = 20
+	DefaultWindowDuration        = 60 * time.Second
+	DefaultMinimumCalls          = 10
+	DefaultFailureRateThreshold  = 50
+	DefaultSlowCallRateThreshold = 100
+	DefaultHalfOpenMaxCalls      = 3
+	DefaultResetTimeout          = 30 * time.Second
+)
 
 // CircuitBreakerConfig holds configuration for circuit breaker
 type CircuitBreakerConfig struct {
-	Name         string
-	MaxFailures  int
+	Name string
+	// ResetTimeout is how long the breaker stays open before probing
 	ResetTimeout time.Duration
+
+	// WindowType, WindowSize and WindowDuration describe the sliding window
+	// used to compute failure and slow call rates
+	WindowType     WindowType
+	WindowSize     int
+	WindowDuration time.Duration
+	// MinimumCalls is the number of calls in the window before rates are evaluated
+	MinimumCalls int
+	// FailureRateThreshold is the failure percentage (1-100) that opens the breaker
+	FailureRateThreshold float64
+
+	// SlowCallDuration marks successful calls slower than this as slow (0 disables)
+	SlowCallDuration time.Duration
+	// SlowCallRateThreshold is the slow call percentage (1-100) that opens the breaker
+	SlowCallRateThreshold float64
+
+	// HalfOpenMaxCalls bounds concurrent probes while half-open; that many
+	// successful probes close the breaker again
+	HalfOpenMaxCalls int
+
+	// IsFailure decides which errors count against the breaker; errors it
+	// rejects are returned to the caller but recorded as successes. Calls
+	// canceled by the caller are never recorded, whatever it says.
+	// Defaults to DefaultIsFailure.
+	IsFailure ErrorClassifier
+	// OnStateChange is registered as the first state change listener
+	OnStateChange StateChangeFunc
+}
+
+// ErrorClassifier reports whether an error returned by a protected call
+// should be recorded as a failure
+type ErrorClassifier func(err error) bool
+
+// StateChangeFunc is called after the breaker moves between states. It runs
+// outside the breaker's lock and must not block.
+type StateChangeFunc func(name string, from, to CircuitBreakerState)
+
+// DefaultIsFailure counts every error except caller cancellation as a failure
+func DefaultIsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// IgnoreErrors returns a classifier that, on top of DefaultIsFailure, does not
+// count the given errors (matched with errors.Is) as failures. Use it for
+// expected outcomes such as "not found" that say nothing about dependency health.
+func IgnoreErrors(ignored ...error) ErrorClassifier {
+	return func(err error) bool {
+		for _, target := range ignored {
+			if errors.Is(err, target) {
+				return false
+			}
+		}
+		return DefaultIsFailure(err)
+	}
+}
+
+// CircuitBreakerStats is a snapshot of the breaker and its sliding window
+type CircuitBreakerStats struct {
+	State        CircuitBreakerState `json:"-"`
+	Forced       bool                `json:"-"`
+	Calls        int                 `json:"calls"`
+	Failures     int                 `json:"failures"`
+	SlowCalls    int                 `json:"slow_calls"`
+	FailureRate  float64             `json:"failure_rate"`
+	SlowCallRate float64             `json:"slow_call_rate"`
+}
+
+// CircuitBreaker implements the circuit breaker pattern. The protected
+// function always runs outside the breaker's lock so concurrent calls are
+// never serialized.
+type CircuitBreaker struct {
+	name   string
+	config CircuitBreakerConfig
+
+	mutex    sync.Mutex
+	state    CircuitBreakerState
+	window   slidingWindow
+	openedAt time.Time
+	// generation changes on every state transition so results of calls
+	// started in an earlier state are ignored
+	generation        uint64
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+	// forced pins the breaker in its current state until Reset
+	forced bool
+
+	// listeners are notified of transitions queued in pendingChanges once
+	// the lock is released
+	listeners      []StateChangeFunc
+	pendingChanges []stateChange
 }
 
+type stateChange struct {
+	from, to CircuitBreakerState
+}
+
+// callResult is how a finished call is recorded
+type callResult int
+
+const (
+	callSucceeded callResult = iota
+	callFailed
+	// callIgnored is recorded for calls that say nothing about the
+	// dependency, such as those canceled by the caller; it only frees the
+	// call's half-open probe slot
+	callIgnored
+)
+
 // NewCircuitBreaker creates a new circuit breaker
 func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
-	return &CircuitBreaker{
-		name:         config.Name,
-		maxFailures:  config.MaxFailures,
-		resetTimeout: config.ResetTimeout,
-		state:        StateClosed,
+	config = withCircuitBreakerDefaults(config)
+
+	var window slidingWindow
+	if config.WindowType == TimeBasedWindow {
+		window = newTimeWindow(config.WindowDuration)
+	} else {
+		window = newCountWindow(config.WindowSize)
+	}
+
+	recordCircuitBreakerState(config.Name, StateClosed)
+	cb := &CircuitBreaker{
+		name:      config.Name,
+		config:    config,
+		state:     StateClosed,
+		window:    window,
+		listeners: []StateChangeFunc{recordCircuitBreakerTransition},
 	}
+	if config.OnStateChange != nil {
+		cb.listeners = append(cb.listeners, config.OnStateChange)
+	}
+	DefaultCircuitBreakerRegistry.Register(cb)
+	return cb
+}
+
+func withCircuitBreakerDefaults(config CircuitBreakerConfig) CircuitBreakerConfig {
+	if config.ResetTimeout <= 0 {
+		config.ResetTimeout = DefaultResetTimeout
+	}
+	if config.WindowSize <= 0 {
+		config.WindowSize = DefaultWindowSize
+	}
+	if config.WindowDuration <= 0 {
+		config.WindowDuration = DefaultWindowDuration
+	}
+	if config.MinimumCalls <= 0 {
+		config.MinimumCalls = DefaultMinimumCalls
+	}
+	if config.FailureRateThreshold <= 0 || config.FailureRateThreshold > 100 {
+		config.FailureRateThreshold = DefaultFailureRateThreshold
+	}
+	if config.SlowCallRateThreshold <= 0 || config.SlowCallRateThreshold > 100 {
+		config.SlowCallRateThreshold = DefaultSlowCallRateThreshold
+	}
+	if config.HalfOpenMaxCalls <= 0 {
+		config.HalfOpenMaxCalls = DefaultHalfOpenMaxCalls
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = DefaultIsFailure
+	}
+	return config
 }
 
 // Execute runs the given function with circuit breaker protection
 func (cb *CircuitBreaker) Execute(fn func() error) error {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	_, err := ExecuteCtx(context.Background(), cb, func(context.Context) (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
 
-	// Check if circuit breaker should be reset
-	if cb.state == StateOpen && time.Since(cb.lastFailureTime) > cb.resetTimeout {
-		cb.state = StateHalfOpen
-		cb.failureCount = 0
-		LogInfo("CIRCUIT_BREAKER", cb.name+" circuit breaker moved to HALF_OPEN state")
+// ExecuteCtx runs fn with circuit breaker protection and returns its result.
+// A context that is already done is returned without calling fn or touching
+// the breaker; fn receives ctx and is expected to honour its cancellation.
+// Calls canceled by the caller count as neither success nor failure, and a
+// panic in fn is recorded as a failure before it propagates.
+func ExecuteCtx[T any](ctx context.Context, cb *CircuitBreaker, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
 	}
 
-	// If circuit is open, return error immediately
-	if cb.state == StateOpen {
-		return errors.New("circuit breaker is OPEN")
+	generation, err := cb.beforeCall()
+	if err != nil {
+		return zero, err
 	}
 
-	// Execute the function
-	err := fn()
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			// Free the half-open probe slot, or the breaker would reject
+			// every call until restart
+			cb.afterCall(generation, callFailed, time.Since(start))
+			panic(r)
+		}
+	}()
+	result, err := fn(ctx)
+	cb.afterCall(generation, cb.classify(ctx, err), time.Since(start))
+	return result, err
+}
 
+// ExecuteWithFallback behaves like ExecuteCtx but hands any error, including
+// ErrCircuitOpen, to fallback and returns its result instead
+func ExecuteWithFallback[T any](ctx context.Context, cb *CircuitBreaker, fn func(context.Context) (T, error), fallback func(context.Context, error) (T, error)) (T, error) {
+	result, err := ExecuteCtx(ctx, cb, fn)
 	if err != nil {
-		cb.onFailure()
-		return err
+		return fallback(ctx, err)
 	}
+	return result, nil
+}
+
+// OnStateChange registers a listener for state transitions, e.g. to raise alerts
+func (cb *CircuitBreaker) OnStateChange(fn StateChangeFunc) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.listeners = append(cb.listeners, fn)
+}
+
+// unlock releases the lock and then notifies listeners of queued transitions
+func (cb *CircuitBreaker) unlock() {
+	changes := cb.pendingChanges
+	cb.pendingChanges = nil
+	listeners := cb.listeners
+	cb.mutex.Unlock()
+
+	for _, change := range changes {
+		for _, listener := range listeners {
+			listener(cb.name, change.from, change.to)
+		}
+	}
+}
+
+// beforeCall decides whether a call may proceed and reserves a probe slot
+// when half-open
+func (cb *CircuitBreaker) beforeCall() (uint64, error) {
+	cb.mutex.Lock()
+	defer cb.unlock()
+
+	// Check if circuit breaker should start probing
+	if cb.state == StateOpen && !cb.forced && time.Since(cb.openedAt) >= cb.config.ResetTimeout {
+		cb.setState(StateHalfOpen)
+	}
+
+	switch cb.state {
+	case StateOpen:
+		return 0, ErrCircuitOpen
+	case StateHalfOpen:
+		if cb.halfOpenInFlight >= cb.config.HalfOpenMaxCalls {
+			return 0, fmt.Errorf("%w: half-open probe limit reached", ErrCircuitOpen)
+		}
+		cb.halfOpenInFlight++
+	}
+
+	return cb.generation, nil
+}
 
-	cb.onSuccess()
-	return nil
+// classify decides how a call that returned err is recorded. A call canceled
+// by its caller is ignored even if fn reported the cancellation with its own
+// error type, as gRPC does.
+func (cb *CircuitBreaker) classify(ctx context.Context, err error) callResult {
+	switch {
+	case err == nil:
+		return callSucceeded
+	case errors.Is(err, context.Canceled), errors.Is(ctx.Err(), context.Canceled):
+		return callIgnored
+	case cb.config.IsFailure(err):
+		return callFailed
+	default:
+		return callSucceeded
+	}
 }
 
-// onFailure handles failure cases
-func (cb *CircuitBreaker) onFailure() {
-	cb.failureCount++
-	cb.lastFailureTime = time.Now()
+// afterCall records the outcome of a call started in the given generation
+func (cb *CircuitBreaker) afterCall(generation uint64, result callResult, duration time.Duration) {
+	cb.mutex.Lock()
+	defer cb.unlock()
+
+	if generation != cb.generation {
+		return
+	}
+
+	failed := result == callFailed
+	slow := result == callSucceeded && cb.config.SlowCallDuration > 0 && duration >= cb.config.SlowCallDuration
+	if failed {
+		recordCircuitBreakerFailure(cb.name)
+	}
+
+	switch cb.state {
+	case StateHalfOpen:
+		cb.halfOpenInFlight--
+		if result == callIgnored {
+			return
+		}
+		if failed || slow {
+			slog.Warn("circuit breaker probe failed, re-opening", "breaker", cb.name, "slow", slow)
+			cb.setState(StateOpen)
+			return
+		}
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.config.HalfOpenMaxCalls {
+			cb.setState(StateClosed)
+		}
 
-	if cb.failureCount >= cb.maxFailures {
-		cb.state = StateOpen
-		LogError("CIRCUIT_BREAKER", cb.name+" circuit breaker OPENED",
-			errors.New("max failures reached"))
+	case StateClosed:
+		if result == callIgnored {
+			return
+		}
+		cb.window.record(outcome{failed: failed, slow: slow}, time.Now())
+		stats := cb.window.stats(time.Now())
+		if cb.forced || stats.Calls < cb.config.MinimumCalls {
+			return
+		}
+		if stats.FailureRate >= cb.config.FailureRateThreshold {
+			slog.Warn("circuit breaker OPENED", "breaker", cb.name, "reason", "failure rate threshold exceeded",
+				"failure_rate", stats.FailureRate, "calls", stats.Calls)
+			cb.setState(StateOpen)
+		} else if cb.config.SlowCallDuration > 0 && stats.SlowCallRate >= cb.config.SlowCallRateThreshold {
+			slog.Warn("circuit breaker OPENED", "breaker", cb.name, "reason", "slow call rate threshold exceeded",
+				"slow_call_rate", stats.SlowCallRate, "calls", stats.Calls)
+			cb.setState(StateOpen)
+		}
 	}
 }
 
-// onSuccess handles success cases
-func (cb *CircuitBreaker) onSuccess() {
-	if cb.state == StateHalfOpen {
-		cb.state = StateClosed
-		cb.failureCount = 0
-		LogInfo("CIRCUIT_BREAKER", cb.name+" circuit breaker moved to CLOSED state")
-	} else if cb.state == StateClosed {
-		cb.failureCount = 0
+// setState moves the breaker to a new state and queues the transition for
+// listeners; callers must hold the lock and release it with unlock
+func (cb *CircuitBreaker) setState(state CircuitBreakerState) {
+	if cb.state == state {
+		return
 	}
+
+	from := cb.state
+	cb.state = state
+	cb.generation++
+	cb.halfOpenInFlight = 0
+	cb.halfOpenSuccesses = 0
+
+	switch state {
+	case StateOpen:
+		cb.openedAt = time.Now()
+	case StateClosed:
+		cb.window.reset()
+	}
+
+	cb.pendingChanges = append(cb.pendingChanges, stateChange{from: from, to: state})
+	slog.Info("circuit breaker state changed", "breaker", cb.name, "from", from.String(), "to", state.String())
+}
+
+// ForceOpen opens the breaker and keeps it open, rejecting every call, until
+// ForceClose or Reset is called
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	cb.setState(StateOpen)
+	cb.forced = true
+}
+
+// ForceClose closes the breaker and keeps it closed regardless of the failure
+// rate until ForceOpen or Reset is called
+func (cb *CircuitBreaker) ForceClose() {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	cb.setState(StateClosed)
+	cb.forced = true
+}
+
+// Reset clears any forced state and returns the breaker to closed with an
+// empty window
+func (cb *CircuitBreaker) Reset() {
+	cb.mutex.Lock()
+	defer cb.unlock()
+	cb.forced = false
+	cb.setState(StateClosed)
+	cb.window.reset()
 }
 
 // GetState returns the current state of the circuit breaker
@@ -102,11 +429,25 @@ func (cb *CircuitBreaker) GetState() CircuitBreakerState {
 	return cb.state
 }
 
-// GetFailureCount returns the current failure count
+// GetFailureCount returns the number of failures in the current window
 func (cb *CircuitBreaker) GetFailureCount() int {
+	return cb.Stats().Failures
+}
+
+// Stats returns a snapshot of the breaker state and its sliding window
+func (cb *CircuitBreaker) Stats() CircuitBreakerStats {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
-	return cb.failureCount
+
+	stats := cb.window.stats(time.Now())
+	stats.State = cb.state
+	stats.Forced = cb.forced
+	return stats
+}
+
+// Name returns the breaker name
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
 }
 
 // StateString returns string representation of the state
@@ -122,3 +463,123 @@ func (state CircuitBreakerState) String() string {
 		return "UNKNOWN"
 	}
 }
+
+// outcome is the result of a single call recorded in a sliding window
+type outcome struct {
+	failed bool
+	slow   bool
+}
+
+type slidingWindow interface {
+	record(o outcome, now time.Time)
+	stats(now time.Time) CircuitBreakerStats
+	reset()
+}
+
+func newStats(calls, failures, slow int) CircuitBreakerStats {
+	stats := CircuitBreakerStats{Calls: calls, Failures: failures, SlowCalls: slow}
+	if calls > 0 {
+		stats.FailureRate = float64(failures) * 100 / float64(calls)
+		stats.SlowCallRate = float64(slow) * 100 / float64(calls)
+	}
+	return stats
+}
+
+// countWindow is a ring buffer holding the last N outcomes
+type countWindow struct {
+	outcomes []outcome
+	next     int
+	filled   int
+	failures int
+	slow     int
+}
+
+func newCountWindow(size int) *countWindow {
+	return &countWindow{outcomes: make([]outcome, size)}
+}
+
+func (w *countWindow) record(o outcome, _ time.Time) {
+	if w.filled == len(w.outcomes) {
+		evicted := w.outcomes[w.next]
+		if evicted.failed {
+			w.failures--
+		}
+		if evicted.slow {
+			w.slow--
+		}
+	} else {
+		w.filled++
+	}
+
+	w.outcomes[w.next] = o
+	w.next = (w.next + 1) % len(w.outcomes)
+	if o.failed {
+		w.failures++
+	}
+	if o.slow {
+		w.slow++
+	}
+}
+
+func (w *countWindow) stats(_ time.Time) CircuitBreakerStats {
+	return newStats(w.filled, w.failures, w.slow)
+}
+
+func (w *countWindow) reset() {
+	w.next, w.filled, w.failures, w.slow = 0, 0, 0, 0
+}
+
+// timeWindow aggregates outcomes into one bucket per second
+type timeWindow struct {
+	buckets []timeBucket
+}
+
+type timeBucket struct {
+	second   int64
+	calls    int
+	failures int
+	slow     int
+}
+
+func newTimeWindow(duration time.Duration) *timeWindow {
+	seconds := int(duration / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &timeWindow{buckets: make([]timeBucket, seconds)}
+}
+
+func (w *timeWindow) record(o outcome, now time.Time) {
+	second := now.Unix()
+	bucket := &w.buckets[second%int64(len(w.buckets))]
+	if bucket.second != second {
+		*bucket = timeBucket{second: second}
+	}
+
+	bucket.calls++
+	if o.failed {
+		bucket.failures++
+	}
+	if o.slow {
+		bucket.slow++
+	}
+}
+
+func (w *timeWindow) stats(now time.Time) CircuitBreakerStats {
+	oldest := now.Unix() - int64(len(w.buckets)) + 1
+	calls, failures, slow := 0, 0, 0
+	for _, bucket := range w.buckets {
+		if bucket.second >= oldest {
+			calls += bucket.calls
+			failures += bucket.failures
+			slow += bucket.slow
+		}
+	}
+	return newStats(calls, failures, slow)
+}
+
+func (w *timeWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = timeBucket{}
+	}
+}
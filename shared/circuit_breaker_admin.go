@@ -0,0 +1,95 @@
+package shared
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// CircuitBreakerAdminPath is where services mount CircuitBreakerAdminHandler
+const CircuitBreakerAdminPath = "/admin/breakers"
+
+// CircuitBreakerView is the admin API representation of a breaker
+type CircuitBreakerView struct {
+	Name   string              `json:"name"`
+	State  string              `json:"state"`
+	Forced bool                `json:"forced"`
+	Window CircuitBreakerStats `json:"window"`
+}
+
+// NewCircuitBreakerView snapshots a breaker for the admin API
+func NewCircuitBreakerView(cb *CircuitBreaker) CircuitBreakerView {
+	stats := cb.Stats()
+	return CircuitBreakerView{
+		Name:   cb.Name(),
+		State:  stats.State.String(),
+		Forced: stats.Forced,
+		Window: stats,
+	}
+}
+
+// CircuitBreakerAdminHandler serves the breaker admin API below
+// CircuitBreakerAdminPath:
+//
+//	GET  /admin/breakers               list breakers with window statistics
+//	GET  /admin/breakers/{name}        show one breaker
+//	POST /admin/breakers/{name}/open   force the breaker open
+//	POST /admin/breakers/{name}/close  force the breaker closed
+//	POST /admin/breakers/{name}/reset  clear forced state and statistics
+//
+// It performs no authentication; wrap it with the service's auth middleware
+// and RequireRole(RoleAdmin, ...).
+func CircuitBreakerAdminHandler(registry *CircuitBreakerRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, CircuitBreakerAdminPath), "/")
+		if path == "" {
+			if r.Method != http.MethodGet {
+				WriteErrorResponse(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+				return
+			}
+			breakers := registry.List()
+			views := make([]CircuitBreakerView, 0, len(breakers))
+			for _, cb := range breakers {
+				views = append(views, NewCircuitBreakerView(cb))
+			}
+			WriteSuccessResponse(w, http.StatusOK, "Circuit breakers retrieved successfully", views)
+			return
+		}
+
+		name, action, _ := strings.Cut(path, "/")
+		cb, ok := registry.Get(name)
+		if !ok {
+			WriteErrorResponse(w, http.StatusNotFound, "BREAKER_NOT_FOUND", "Circuit breaker not found")
+			return
+		}
+
+		if action == "" {
+			if r.Method != http.MethodGet {
+				WriteErrorResponse(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+				return
+			}
+			WriteSuccessResponse(w, http.StatusOK, "Circuit breaker retrieved successfully", NewCircuitBreakerView(cb))
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			WriteErrorResponse(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+			return
+		}
+
+		switch action {
+		case "open":
+			cb.ForceOpen()
+		case "close":
+			cb.ForceClose()
+		case "reset":
+			cb.Reset()
+		default:
+			WriteErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Unknown circuit breaker action")
+			return
+		}
+
+		slog.WarnContext(r.Context(), "circuit breaker changed by admin", "breaker", name, "action", action)
+		WriteSuccessResponse(w, http.StatusOK, "Circuit breaker updated successfully", NewCircuitBreakerView(cb))
+	}
+}
@@ -0,0 +1,50 @@
+package shared
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultCircuitBreakerRegistry holds every breaker created with NewCircuitBreaker
+var DefaultCircuitBreakerRegistry = NewCircuitBreakerRegistry()
+
+// CircuitBreakerRegistry tracks circuit breakers by name
+type CircuitBreakerRegistry struct {
+	mutex    sync.RWMutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewCircuitBreakerRegistry creates an empty registry
+func NewCircuitBreakerRegistry() *CircuitBreakerRegistry {
+	return &CircuitBreakerRegistry{breakers: make(map[string]*CircuitBreaker)}
+}
+
+// Register adds a breaker, replacing any earlier breaker with the same name
+func (r *CircuitBreakerRegistry) Register(cb *CircuitBreaker) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.breakers[cb.Name()] = cb
+}
+
+// Get returns the breaker registered under name
+func (r *CircuitBreakerRegistry) Get(name string) (*CircuitBreaker, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	cb, ok := r.breakers[name]
+	return cb, ok
+}
+
+// List returns all registered breakers sorted by name
+func (r *CircuitBreakerRegistry) List() []*CircuitBreaker {
+	r.mutex.RLock()
+	breakers := make([]*CircuitBreaker, 0, len(r.breakers))
+	for _, cb := range r.breakers {
+		breakers = append(breakers, cb)
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(breakers, func(i, j int) bool {
+		return breakers[i].Name() < breakers[j].Name()
+	})
+	return breakers
+}
@@ -0,0 +1,284 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	errBoom    = errors.New("boom")
+	errIgnored = errors.New("ignored")
+)
+
+func TestCountWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		outcomes []outcome
+		want     CircuitBreakerStats
+	}{
+		{"empty", 3, nil, CircuitBreakerStats{}},
+		{
+			"partly filled", 4,
+			[]outcome{{failed: true}, {}},
+			CircuitBreakerStats{Calls: 2, Failures: 1, FailureRate: 50},
+		},
+		{
+			"evicts oldest", 3,
+			[]outcome{{failed: true}, {slow: true}, {}, {}},
+			CircuitBreakerStats{Calls: 3, SlowCalls: 1, SlowCallRate: 100.0 / 3},
+		},
+		{
+			"wraps twice", 2,
+			[]outcome{{failed: true}, {failed: true}, {slow: true}, {}, {failed: true}},
+			CircuitBreakerStats{Calls: 2, Failures: 1, FailureRate: 50},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newCountWindow(tt.size)
+			now := time.Now()
+			for _, o := range tt.outcomes {
+				w.record(o, now)
+			}
+			if got := w.stats(now); got != tt.want {
+				t.Errorf("stats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	start := time.Unix(1_000_000, 0)
+	tests := []struct {
+		name    string
+		records []time.Duration // offsets from start
+		at      time.Duration
+		want    int
+	}{
+		{"same second", []time.Duration{0, 0, 0}, 0, 3},
+		{"spread inside window", []time.Duration{0, 2 * time.Second, 4 * time.Second}, 4 * time.Second, 3},
+		{"oldest second expired", []time.Duration{0, 2 * time.Second, 4 * time.Second}, 5 * time.Second, 2},
+		{"all expired", []time.Duration{0, time.Second}, time.Minute, 0},
+		{"bucket reused after wrap", []time.Duration{0, 5 * time.Second}, 5 * time.Second, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTimeWindow(5 * time.Second)
+			for _, offset := range tt.records {
+				w.record(outcome{failed: true}, start.Add(offset))
+			}
+			stats := w.stats(start.Add(tt.at))
+			if stats.Calls != tt.want || stats.Failures != tt.want {
+				t.Errorf("stats = %+v, want %d failed calls", stats, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerOpensAfterMinimumCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   CircuitBreakerConfig
+		calls    []callResult
+		duration time.Duration
+		want     CircuitBreakerState
+	}{
+		{
+			"below minimum calls",
+			CircuitBreakerConfig{MinimumCalls: 5},
+			[]callResult{callFailed, callFailed, callFailed, callFailed},
+			0, StateClosed,
+		},
+		{
+			"failure rate reached at minimum calls",
+			CircuitBreakerConfig{MinimumCalls: 4, FailureRateThreshold: 50},
+			[]callResult{callSucceeded, callFailed, callSucceeded, callFailed},
+			0, StateOpen,
+		},
+		{
+			"failure rate below threshold",
+			CircuitBreakerConfig{MinimumCalls: 4, FailureRateThreshold: 50},
+			[]callResult{callSucceeded, callFailed, callSucceeded, callSucceeded},
+			0, StateClosed,
+		},
+		{
+			"ignored calls do not count",
+			CircuitBreakerConfig{MinimumCalls: 3},
+			[]callResult{callFailed, callFailed, callIgnored, callIgnored},
+			0, StateClosed,
+		},
+		{
+			"slow call rate reached",
+			CircuitBreakerConfig{MinimumCalls: 2, SlowCallDuration: time.Second, SlowCallRateThreshold: 100},
+			[]callResult{callSucceeded, callSucceeded},
+			2 * time.Second, StateOpen,
+		},
+		{
+			"slow calls ignored without a duration",
+			CircuitBreakerConfig{MinimumCalls: 2, SlowCallRateThreshold: 100},
+			[]callResult{callSucceeded, callSucceeded},
+			time.Hour, StateClosed,
+		},
+		{
+			"failed calls are not slow",
+			CircuitBreakerConfig{MinimumCalls: 2, FailureRateThreshold: 100, SlowCallDuration: time.Second, SlowCallRateThreshold: 50},
+			[]callResult{callFailed, callSucceeded},
+			time.Millisecond, StateClosed,
+		},
+		{
+			"time based window",
+			CircuitBreakerConfig{WindowType: TimeBasedWindow, WindowDuration: time.Minute, MinimumCalls: 2},
+			[]callResult{callFailed, callFailed},
+			0, StateOpen,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Name = "test-" + tt.name
+			cb := NewCircuitBreaker(tt.config)
+			for _, result := range tt.calls {
+				generation, err := cb.beforeCall()
+				if err != nil {
+					t.Fatalf("beforeCall: %v", err)
+				}
+				cb.afterCall(generation, result, tt.duration)
+			}
+			if got := cb.GetState(); got != tt.want {
+				t.Errorf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe func(context.Context) (struct{}, error)
+		// cancel cancels the probe's context while it runs
+		cancel bool
+		want   CircuitBreakerState
+	}{
+		{"successful probe", func(context.Context) (struct{}, error) { return struct{}{}, nil }, false, StateHalfOpen},
+		{"failed probe", func(context.Context) (struct{}, error) { return struct{}{}, errBoom }, false, StateOpen},
+		{"ignored error counts as success", func(context.Context) (struct{}, error) { return struct{}{}, errIgnored }, false, StateHalfOpen},
+		{"slow probe", func(context.Context) (struct{}, error) { time.Sleep(20 * time.Millisecond); return struct{}{}, nil }, false, StateOpen},
+		{"canceled probe", func(ctx context.Context) (struct{}, error) { <-ctx.Done(); return struct{}{}, ctx.Err() }, true, StateHalfOpen},
+		{"probe hiding the cancellation", func(ctx context.Context) (struct{}, error) { <-ctx.Done(); return struct{}{}, errBoom }, true, StateHalfOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := newHalfOpenBreaker(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(time.Millisecond, cancel)
+			}
+
+			ExecuteCtx(ctx, cb, tt.probe)
+			if got := cb.GetState(); got != tt.want {
+				t.Fatalf("state = %v, want %v", got, tt.want)
+			}
+			// Whatever the outcome, the probe's slot is free again
+			if got := cb.GetState(); got == StateHalfOpen && cb.halfOpenInFlight != 0 {
+				t.Errorf("%d probes still in flight", cb.halfOpenInFlight)
+			}
+			if tt.cancel && cb.halfOpenSuccesses != 0 {
+				t.Errorf("canceled probe counted as %d successes", cb.halfOpenSuccesses)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerClosesAfterHalfOpenSuccesses(t *testing.T) {
+	cb := newHalfOpenBreaker(t)
+	for i := 0; i < 2; i++ {
+		if err := cb.Execute(func() error { return nil }); err != nil {
+			t.Fatalf("probe %d: %v", i, err)
+		}
+	}
+	if got := cb.GetState(); got != StateClosed {
+		t.Errorf("state = %v, want CLOSED", got)
+	}
+}
+
+func TestCircuitBreakerLimitsHalfOpenProbes(t *testing.T) {
+	cb := newHalfOpenBreaker(t)
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			cb.Execute(func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+			done <- struct{}{}
+		}()
+	}
+	<-started
+	<-started
+
+	if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("third probe err = %v, want ErrCircuitOpen", err)
+	}
+	close(release)
+	<-done
+	<-done
+	if got := cb.GetState(); got != StateClosed {
+		t.Errorf("state = %v, want CLOSED", got)
+	}
+}
+
+func TestCircuitBreakerReleasesProbeSlotOnPanic(t *testing.T) {
+	cb := newHalfOpenBreaker(t)
+	func() {
+		defer func() {
+			if r := recover(); r != errBoom {
+				t.Errorf("recovered %v, want the original panic", r)
+			}
+		}()
+		cb.Execute(func() error { panic(errBoom) })
+	}()
+
+	stats := cb.Stats()
+	if stats.State != StateOpen {
+		t.Errorf("state = %v, want OPEN after a panicking probe", stats.State)
+	}
+	cb.openedAt = time.Now().Add(-time.Hour)
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Errorf("probe after panic: %v", err)
+	}
+}
+
+func TestCanceledCallsAreNotRecorded(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{Name: "test-canceled", MinimumCalls: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	ExecuteCtx(ctx, cb, func(context.Context) (struct{}, error) {
+		cancel()
+		return struct{}{}, errBoom
+	})
+	if stats := cb.Stats(); stats.Calls != 0 || stats.State != StateClosed {
+		t.Errorf("stats = %+v, want no recorded calls", stats)
+	}
+}
+
+// newHalfOpenBreaker returns a breaker allowing two probes whose reset
+// timeout has passed, so the next call moves it to half-open
+func newHalfOpenBreaker(t *testing.T) *CircuitBreaker {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		Name:             "test-" + t.Name(),
+		HalfOpenMaxCalls: 2,
+		SlowCallDuration: 10 * time.Millisecond,
+		IsFailure:        IgnoreErrors(errIgnored),
+	})
+	cb.ForceOpen()
+	cb.mutex.Lock()
+	cb.forced = false
+	cb.openedAt = time.Now().Add(-time.Hour)
+	cb.mutex.Unlock()
+	return cb
+}
@@ -0,0 +1,235 @@
+package shared
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// compressMinBytes is the smallest body worth compressing; below it the
+// encoding overhead outweighs the savings
+const compressMinBytes = 1024
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}
+	brotliWriters = sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(io.Discard, 4)
+	}}
+)
+
+// CompressHandler compresses responses with brotli or gzip according to the
+// request's Accept-Encoding. Only textual bodies of at least 1 KiB are
+// compressed; event streams and responses that already carry a
+// Content-Encoding pass through untouched. A compressed response's ETag is
+// made weak since its bytes differ from the identity representation, which
+// If-None-Match's weak comparison still matches.
+func CompressHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		// Upgraded connections need the original writer to hijack
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks the preferred supported coding from an
+// Accept-Encoding header, favouring brotli on equal weights
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		weights[coding] = q
+	}
+
+	weight := func(coding string) float64 {
+		if q, ok := weights[coding]; ok {
+			return q
+		}
+		if q, ok := weights["*"]; ok {
+			return q
+		}
+		return 0
+	}
+	br, gz := weight(encodingBrotli), weight(encodingGzip)
+	switch {
+	case br > 0 && br >= gz:
+		return encodingBrotli
+	case gz > 0:
+		return encodingGzip
+	}
+	return ""
+}
+
+// compressible reports whether a content type benefits from compression
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/javascript",
+		mediaType == "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compressWriter buffers the start of a body until it knows whether the
+// response is worth compressing
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	status   int
+
+	wroteHeader bool
+	decided     bool
+	buffer      bytes.Buffer
+	encoder     io.WriteCloser
+	flusher     interface{ Flush() error }
+	release     func()
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.status = code
+	// Bodiless and informational responses go straight out
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		c.decide(false)
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.decided {
+		if c.encoder != nil {
+			return c.encoder.Write(p)
+		}
+		return c.ResponseWriter.Write(p)
+	}
+
+	c.buffer.Write(p)
+	if c.buffer.Len() >= compressMinBytes {
+		if err := c.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends what has been written so far, compressing it if the response qualifies
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		c.decide(c.buffer.Len() > 0)
+	}
+	if c.flusher != nil {
+		c.flusher.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close finishes the response once the handler returns
+func (c *compressWriter) Close() error {
+	if !c.decided {
+		if err := c.decide(false); err != nil {
+			return err
+		}
+	}
+	if c.encoder == nil {
+		return nil
+	}
+	err := c.encoder.Close()
+	c.release()
+	c.encoder = nil
+	return err
+}
+
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// decide writes the header, compressing when want is set and the response qualifies,
+// and then the buffered body
+func (c *compressWriter) decide(want bool) error {
+	c.decided = true
+	h := c.ResponseWriter.Header()
+
+	eligible := h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type"))
+	if eligible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if want && eligible && c.status >= http.StatusOK && c.status != http.StatusNoContent && c.status != http.StatusNotModified {
+		h.Set("Content-Encoding", c.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		switch c.encoding {
+		case encodingBrotli:
+			bw := brotliWriters.Get().(*brotli.Writer)
+			bw.Reset(c.ResponseWriter)
+			c.encoder, c.flusher = bw, bw
+			c.release = func() { bw.Reset(io.Discard); brotliWriters.Put(bw) }
+		default:
+			gw := gzipWriters.Get().(*gzip.Writer)
+			gw.Reset(c.ResponseWriter)
+			c.encoder, c.flusher = gw, gw
+			c.release = func() { gw.Reset(io.Discard); gzipWriters.Put(gw) }
+		}
+	}
+
+	c.ResponseWriter.WriteHeader(c.status)
+	if c.buffer.Len() == 0 {
+		return nil
+	}
+	var err error
+	if c.encoder != nil {
+		_, err = c.encoder.Write(c.buffer.Bytes())
+	} else {
+		_, err = c.ResponseWriter.Write(c.buffer.Bytes())
+	}
+	c.buffer.Reset()
+	return err
+}
@@ -0,0 +1,165 @@
+package shared
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", encodingGzip},
+		{"gzip, deflate, br", encodingBrotli},
+		{"GZIP, BR", encodingBrotli},
+		{"br;q=0.5, gzip", encodingGzip},
+		{"br;q=0, gzip;q=0.1", encodingGzip},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", encodingBrotli},
+		{"*;q=0.2, br;q=0", encodingGzip},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+// decode undoes a response's Content-Encoding
+func decode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = rec.Body
+	switch rec.Header().Get("Content-Encoding") {
+	case encodingGzip:
+		gr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	case encodingBrotli:
+		r = brotli.NewReader(rec.Body)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCompressHandler(t *testing.T) {
+	large := strings.Repeat(`{"name":"Springfield Elementary"}`, 64)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		encoding       string
+		body           string
+		wantEncoding   string
+	}{
+		{"brotli", "gzip, br", "application/json", "", large, encodingBrotli},
+		{"gzip", "gzip", "application/json; charset=utf-8", "", large, encodingGzip},
+		{"not accepted", "", "application/json", "", large, ""},
+		{"small body", "gzip, br", "application/json", "", `{"ok":true}`, ""},
+		{"already encoded", "gzip, br", "application/json", "gzip", large, "gzip"},
+		{"binary", "gzip, br", "image/png", "", large, ""},
+		{"event stream", "gzip, br", "text/event-stream", "", large, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CompressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.Header().Set("ETag", `"v1"`)
+				// Written in pieces so the size is only known along the way
+				for chunk := range strings.SplitSeq(tt.body, "}") {
+					io.WriteString(w, chunk)
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/schools", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			want := strings.ReplaceAll(tt.body, "}", "")
+			if tt.encoding == "" {
+				if got := decode(t, rec); got != want {
+					t.Errorf("body = %.40q..., want %.40q...", got, want)
+				}
+			}
+			// Only a body the gateway compressed gets a weak tag
+			wantETag := `"v1"`
+			if tt.wantEncoding != "" && tt.encoding == "" {
+				wantETag = `W/"v1"`
+			}
+			if got := rec.Header().Get("ETag"); got != wantETag {
+				t.Errorf("ETag = %q, want %q", got, wantETag)
+			}
+		})
+	}
+}
+
+func TestCompressHandlerVaries(t *testing.T) {
+	handler := CompressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	// Even uncompressed, a compressible response depends on Accept-Encoding
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+}
+
+func TestCompressedResponseRevalidates(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 2*compressMinBytes)
+	handler := CompressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if NotModified(w, r, `"v1"`) {
+			return
+		}
+		w.Write(body)
+	}))
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag != `W/"v1"` {
+		t.Fatalf("first response = %d with ETag %q", first.Code, etag)
+	}
+	// The weak tag the client got back still matches the resource
+	rec := get(etag)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 || rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("revalidation = %d with %d bytes, encoding %q; want an empty 304",
+			rec.Code, rec.Body.Len(), rec.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,97 @@
+package shared
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		etag        string
+		want        bool
+	}{
+		{`"a"`, `"a"`, true},
+		{`"a"`, `"b"`, false},
+		// If-None-Match compares weakly, in either direction
+		{`W/"a"`, `"a"`, true},
+		{`"a"`, `W/"a"`, true},
+		{`W/"a"`, `W/"a"`, true},
+		{`"b", W/"a"`, `"a"`, true},
+		{` "b" ,"c"`, `"c"`, true},
+		{`"b", "c"`, `"a"`, false},
+		{`*`, `"a"`, true},
+		{`*`, "", false},
+		{`"a"`, "", false},
+		// The quotes are part of the tag
+		{`a`, `"a"`, false},
+	}
+	for _, tt := range tests {
+		if got := ETagMatches(tt.ifNoneMatch, tt.etag); got != tt.want {
+			t.Errorf("ETagMatches(%q, %q) = %v, want %v", tt.ifNoneMatch, tt.etag, got, tt.want)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no validator", "", false},
+		{"current", `"v1"`, true},
+		{"current weak", `W/"v1"`, true},
+		{"stale", `"v0"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			if got := NotModified(rec, req, `"v1"`); got != tt.want {
+				t.Errorf("NotModified = %v, want %v", got, tt.want)
+			}
+			if rec.Header().Get("ETag") != `"v1"` {
+				t.Errorf("ETag = %q, want it set either way", rec.Header().Get("ETag"))
+			}
+			wantCode := http.StatusOK
+			if tt.want {
+				wantCode = http.StatusNotModified
+			}
+			if rec.Code != wantCode || rec.Body.Len() != 0 {
+				t.Errorf("status = %d with %d bytes, want %d", rec.Code, rec.Body.Len(), wantCode)
+			}
+		})
+	}
+}
+
+func TestETagBuilder(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := Pagination{Page: 1, PageSize: 20, Total: 41, TotalPages: 3}
+	base := NewETagBuilder().Add(1, at).Add(2, at, "Springfield").AddPagination(page).ETag()
+	if base[0] != '"' || base[len(base)-1] != '"' {
+		t.Fatalf("ETag %s is not a quoted strong tag", base)
+	}
+
+	tests := []struct {
+		name    string
+		etag    string
+		changed bool
+	}{
+		{"same resources", NewETagBuilder().Add(1, at).Add(2, at, "Springfield").AddPagination(page).ETag(), false},
+		{"updated resource", NewETagBuilder().Add(1, at).Add(2, at.Add(time.Nanosecond), "Springfield").AddPagination(page).ETag(), true},
+		{"renamed join", NewETagBuilder().Add(1, at).Add(2, at, "Shelbyville").AddPagination(page).ETag(), true},
+		{"other order", NewETagBuilder().Add(2, at, "Springfield").Add(1, at).AddPagination(page).ETag(), true},
+		{"list grew", NewETagBuilder().Add(1, at).Add(2, at, "Springfield").AddPagination(Pagination{Page: 1, PageSize: 20, Total: 42, TotalPages: 3}).ETag(), true},
+	}
+	for _, tt := range tests {
+		if got := tt.etag != base; got != tt.changed {
+			t.Errorf("%s: changed = %v, want %v", tt.name, got, tt.changed)
+		}
+	}
+}
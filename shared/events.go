@@ -0,0 +1,239 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entities whose changes are published as events
+const (
+	EventEntitySchool  = "school"
+	EventEntityStudent = "student"
+)
+
+// Event types. EventTypeReset tells a subscriber that events may have been
+// missed, so it should refetch whatever state it keeps.
+const (
+	EventSchoolCreated  = "school.created"
+	EventSchoolUpdated  = "school.updated"
+	EventSchoolDeleted  = "school.deleted"
+	EventStudentCreated = "student.created"
+	EventStudentUpdated = "student.updated"
+	EventStudentDeleted = "student.deleted"
+	EventTypeReset      = "reset"
+)
+
+// Defaults applied to zero valued EventLogConfig fields
+const (
+	DefaultEventLogSize          = 1000
+	DefaultEventSubscriberBuffer = 64
+)
+
+// Event is a change to a school or student
+type Event struct {
+	// ID orders the events of one log; send it back as Last-Event-ID to resume
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Entity   string `json:"entity,omitempty"`
+	EntityID int    `json:"entity_id,omitempty"`
+	// SchoolID is the school the entity belongs to, or the school itself
+	SchoolID int `json:"school_id,omitempty"`
+	// PreviousSchoolID is set when a student moved to another school
+	PreviousSchoolID int `json:"previous_school_id,omitempty"`
+	// Data is the entity after the change; deletes carry none
+	Data json.RawMessage `json:"data,omitempty"`
+	Time time.Time       `json:"time"`
+	// Actor and ActorEmail identify the user who made the change, and
+	// RequestID the request, so the feed doubles as an audit trail
+	Actor      string `json:"actor,omitempty"`
+	ActorEmail string `json:"actor_email,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+// InSchool reports whether the event concerns schoolID
+func (e *Event) InSchool(schoolID int) bool {
+	return e.SchoolID == schoolID || (e.PreviousSchoolID != 0 && e.PreviousSchoolID == schoolID)
+}
+
+// ErrEventLogClosed is returned when subscribing to a closed log
+var ErrEventLogClosed = errors.New("event log is closed")
+
+// EventLogConfig bounds an EventLog
+type EventLogConfig struct {
+	// Name labels the log's metrics
+	Name string
+	// Size is how many recent events are kept for subscribers resuming
+	Size int
+	// SubscriberBuffer is how many events a subscriber may fall behind
+	// before it is dropped; it can resume from its last event ID
+	SubscriberBuffer int
+}
+
+// EventLog keeps the most recent events in memory and fans new ones out to
+// subscribers. Event IDs carry the log's start time, so a subscriber
+// resuming from an ID issued before a restart is told to reset rather than
+// silently missing events.
+type EventLog struct {
+	config EventLogConfig
+	epoch  string
+
+	mutex       sync.Mutex
+	next        uint64
+	events      []Event
+	subscribers map[*EventSubscription]struct{}
+	closed      bool
+}
+
+// EventSubscription receives the events published after it was created.
+// Events is closed when the subscriber falls behind or the log closes.
+type EventSubscription struct {
+	Events <-chan Event
+	events chan Event
+	log    *EventLog
+}
+
+// NewEventLog creates an empty event log
+func NewEventLog(config EventLogConfig) *EventLog {
+	if config.Size <= 0 {
+		config.Size = DefaultEventLogSize
+	}
+	if config.SubscriberBuffer <= 0 {
+		config.SubscriberBuffer = DefaultEventSubscriberBuffer
+	}
+	return &EventLog{
+		config:      config,
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		next:        1,
+		subscribers: make(map[*EventSubscription]struct{}),
+	}
+}
+
+// Publish records a change described by event, with data marshalled as
+// its data; pass nil data for deletes. The caller identity and request ID
+// in ctx are recorded as the actor.
+func (l *EventLog) Publish(ctx context.Context, event Event, data any) {
+	if identity, ok := IdentityFromContext(ctx); ok {
+		event.Actor, event.ActorEmail = identity.UserID, identity.Email
+	}
+	event.RequestID = RequestIDFromContext(ctx)
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to encode event data", "type", event.Type, "error", err)
+			return
+		}
+		event.Data = raw
+	}
+	event.Time = time.Now().UTC()
+	l.Append(event)
+}
+
+// Append records event under a new ID of this log and returns it
+func (l *EventLog) Append(event Event) Event {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return event
+	}
+
+	event.ID = l.id(l.next)
+	l.next++
+	if len(l.events) == l.config.Size {
+		copy(l.events, l.events[1:])
+		l.events = l.events[:len(l.events)-1]
+	}
+	l.events = append(l.events, event)
+	eventsPublished.WithLabelValues(l.config.Name, event.Type).Inc()
+
+	for subscription := range l.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			// Never block publishers on a slow subscriber
+			l.drop(subscription)
+			eventSubscribersDropped.WithLabelValues(l.config.Name).Inc()
+		}
+	}
+	return event
+}
+
+// Subscribe returns the events after lastEventID and a subscription for the
+// ones to come. An empty lastEventID replays nothing. When lastEventID is
+// unknown, from another run of the log or too old to replay, the replay is
+// a single reset event carrying the current position.
+func (l *EventLog) Subscribe(lastEventID string) ([]Event, *EventSubscription, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.closed {
+		return nil, nil, ErrEventLogClosed
+	}
+
+	var replay []Event
+	if lastEventID != "" {
+		var ok bool
+		if replay, ok = l.since(lastEventID); !ok {
+			replay = []Event{{ID: l.id(l.next - 1), Type: EventTypeReset, Time: time.Now().UTC()}}
+		}
+	}
+
+	events := make(chan Event, l.config.SubscriberBuffer)
+	subscription := &EventSubscription{Events: events, events: events, log: l}
+	l.subscribers[subscription] = struct{}{}
+	eventSubscribers.WithLabelValues(l.config.Name).Inc()
+	return replay, subscription, nil
+}
+
+func (l *EventLog) id(seq uint64) string {
+	return l.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// since returns a copy of the events after id, and false if they are not
+// all still held
+func (l *EventLog) since(id string) ([]Event, bool) {
+	epoch, seqText, ok := strings.Cut(id, "-")
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if !ok || err != nil || epoch != l.epoch || seq >= l.next {
+		return nil, false
+	}
+
+	// The log holds the contiguous IDs next-len(events) .. next-1
+	oldest := l.next - uint64(len(l.events))
+	if seq+1 < oldest {
+		return nil, false
+	}
+	return append([]Event(nil), l.events[seq+1-oldest:]...), true
+}
+
+// Close stops the subscription
+func (s *EventSubscription) Close() {
+	s.log.mutex.Lock()
+	defer s.log.mutex.Unlock()
+	s.log.drop(s)
+}
+
+func (l *EventLog) drop(subscription *EventSubscription) {
+	if _, ok := l.subscribers[subscription]; !ok {
+		return
+	}
+	delete(l.subscribers, subscription)
+	close(subscription.events)
+	eventSubscribers.WithLabelValues(l.config.Name).Dec()
+}
+
+// Close ends every subscription and refuses new ones. Register it with
+// http.Server.RegisterOnShutdown so open streams end when the server
+// drains instead of holding up shutdown.
+func (l *EventLog) Close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.closed = true
+	for subscription := range l.subscribers {
+		l.drop(subscription)
+	}
+}
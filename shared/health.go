@@ -0,0 +1,179 @@
+package shared
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// Health statuses reported by checks and reports
+const (
+	StatusHealthy   = "healthy"
+	StatusUnhealthy = "unhealthy"
+)
+
+// DefaultHealthCheckTimeout bounds a single check when HealthCheck.Timeout is zero
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// HealthCheck is a named dependency check
+type HealthCheck struct {
+	Name    string
+	Timeout time.Duration
+	Check   func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a single health check
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// HealthReport aggregates the results of several health checks
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Healthy reports whether every check passed
+func (r HealthReport) Healthy() bool {
+	return r.Status == StatusHealthy
+}
+
+// RunHealthChecks runs every check in parallel, each bounded by its own
+// timeout, and returns once all checks have finished or timed out
+func RunHealthChecks(ctx context.Context, checks []HealthCheck) HealthReport {
+	report := HealthReport{Status: StatusHealthy, Checks: make(map[string]CheckResult, len(checks))}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			result := runHealthCheck(ctx, check)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusHealthy {
+				report.Status = StatusUnhealthy
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// runHealthCheck runs one check and stops waiting for it once its timeout
+// expires, even if the check ignores its context
+func runHealthCheck(ctx context.Context, check HealthCheck) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", timeout)
+	}
+
+	result := CheckResult{Status: StatusHealthy, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusUnhealthy
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// WriteHealthReport writes the report with 200 when healthy and 503 otherwise
+func WriteHealthReport(w http.ResponseWriter, report HealthReport) {
+	statusCode := http.StatusOK
+	if !report.Healthy() {
+		statusCode = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSONResponse(w, statusCode, report)
+}
+
+// LivenessHandler reports that the process is running. It never checks
+// dependencies so a failing database does not get the container restarted.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		WriteHealthReport(w, HealthReport{Status: StatusHealthy})
+	}
+}
+
+// ReadinessHandler runs the given checks on every request and reports
+// whether the service can serve traffic
+func ReadinessHandler(checks ...HealthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		WriteHealthReport(w, RunHealthChecks(r.Context(), checks))
+	}
+}
+
+// SQLPingCheck checks that the database accepts connections
+func SQLPingCheck(name string, db *sql.DB) HealthCheck {
+	return HealthCheck{
+		Name:  name,
+		Check: db.PingContext,
+	}
+}
+
+// MongoPingCheck checks that the MongoDB primary is reachable
+func MongoPingCheck(name string, client *mongo.Client) HealthCheck {
+	return HealthCheck{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			return client.Ping(ctx, readpref.Primary())
+		},
+	}
+}
+
+// GRPCConnCheck checks that a client connection is, or becomes, ready
+// before the check times out
+func GRPCConnCheck(name string, conn *grpc.ClientConn) HealthCheck {
+	return HealthCheck{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			if conn == nil {
+				return errors.New("connection was not established")
+			}
+			for {
+				state := conn.GetState()
+				switch state {
+				case connectivity.Ready:
+					return nil
+				case connectivity.Shutdown:
+					return errors.New("connection is shut down")
+				case connectivity.Idle:
+					conn.Connect()
+				}
+				if !conn.WaitForStateChange(ctx, state) {
+					return fmt.Errorf("connection is %s", state)
+				}
+			}
+		},
+	}
+}
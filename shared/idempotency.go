@@ -0,0 +1,175 @@
+package shared
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Headers of idempotent requests
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// Defaults applied to zero valued IdempotencyConfig fields
+const (
+	DefaultIdempotencyTTL        = 24 * time.Hour
+	DefaultIdempotencyMaxEntries = 10000
+)
+
+// MaxIdempotencyKeyLength bounds the keys clients may send
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyState is the outcome of IdempotencyStore.Begin
+type IdempotencyState int
+
+const (
+	// IdempotencyNew means the key was unused; the caller now holds it and
+	// must Complete or Release it
+	IdempotencyNew IdempotencyState = iota
+	// IdempotencyReplay means the same request already completed; its
+	// response is returned
+	IdempotencyReplay
+	// IdempotencyInProgress means the same request is still running
+	IdempotencyInProgress
+	// IdempotencyMismatch means the key was used for a different request
+	IdempotencyMismatch
+)
+
+// IdempotencyConfig bounds an IdempotencyStore
+type IdempotencyConfig struct {
+	Name string
+	// TTL is how long a completed response is replayed
+	TTL        time.Duration
+	MaxEntries int
+}
+
+// IdempotencyStore remembers requests by idempotency key so a retried
+// request gets the first response instead of running again. Each key holds
+// the fingerprint of the request that claimed it and, once that request
+// completed, its response. Keys expire TTL after they complete, and the
+// oldest are evicted beyond MaxEntries.
+type IdempotencyStore struct {
+	config IdempotencyConfig
+
+	mutex sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type idempotencyRecord struct {
+	key         string
+	fingerprint string
+	resp        *CachedResponse
+	expires     time.Time
+}
+
+// NewIdempotencyStore creates an empty store
+func NewIdempotencyStore(config IdempotencyConfig) *IdempotencyStore {
+	if config.TTL <= 0 {
+		config.TTL = DefaultIdempotencyTTL
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultIdempotencyMaxEntries
+	}
+	s := &IdempotencyStore{
+		config: config,
+		order:  list.New(),
+		items:  make(map[string]*list.Element),
+	}
+	s.recordSize()
+	return s
+}
+
+// Begin claims key for the request with fingerprint. The stored response is
+// returned with IdempotencyReplay.
+func (s *IdempotencyStore) Begin(key, fingerprint string) (IdempotencyState, *CachedResponse) {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.removeExpired(now)
+
+	if element, ok := s.items[key]; ok {
+		record := element.Value.(*idempotencyRecord)
+		switch {
+		case record.fingerprint != fingerprint:
+			return IdempotencyMismatch, nil
+		case record.resp == nil:
+			return IdempotencyInProgress, nil
+		default:
+			return IdempotencyReplay, record.resp
+		}
+	}
+
+	// Claimed keys expire too, in case the request never finishes
+	s.items[key] = s.order.PushFront(&idempotencyRecord{key: key, fingerprint: fingerprint, expires: now.Add(s.config.TTL)})
+	for s.order.Len() > s.config.MaxEntries {
+		s.removeElement(s.order.Back())
+	}
+	s.recordSize()
+	return IdempotencyNew, nil
+}
+
+// Complete stores the response of the request holding key and starts its TTL
+func (s *IdempotencyStore) Complete(key, fingerprint string, resp *CachedResponse) {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.removeElement(element)
+	}
+	s.items[key] = s.order.PushFront(&idempotencyRecord{key: key, fingerprint: fingerprint, resp: resp, expires: now.Add(s.config.TTL)})
+	for s.order.Len() > s.config.MaxEntries {
+		s.removeElement(s.order.Back())
+	}
+	s.recordSize()
+}
+
+// Release gives up key without storing a response, so the request can be retried
+func (s *IdempotencyStore) Release(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, ok := s.items[key]; ok && element.Value.(*idempotencyRecord).resp == nil {
+		s.removeElement(element)
+		s.recordSize()
+	}
+}
+
+// Len returns the number of keys held
+func (s *IdempotencyStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.order.Len()
+}
+
+// RecordResult counts a lookup outcome such as "replayed" or "mismatch"
+func (s *IdempotencyStore) RecordResult(result string) {
+	idempotencyRequests.WithLabelValues(s.config.Name, result).Inc()
+}
+
+// removeExpired drops expired keys from the back of the list. Every key has
+// the same TTL, so the list is ordered by expiry.
+func (s *IdempotencyStore) removeExpired(now time.Time) {
+	removed := false
+	for element := s.order.Back(); element != nil && !now.Before(element.Value.(*idempotencyRecord).expires); element = s.order.Back() {
+		s.removeElement(element)
+		removed = true
+	}
+	if removed {
+		s.recordSize()
+	}
+}
+
+func (s *IdempotencyStore) removeElement(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.items, element.Value.(*idempotencyRecord).key)
+}
+
+func (s *IdempotencyStore) recordSize() {
+	idempotencyEntries.WithLabelValues(s.config.Name).Set(float64(s.order.Len()))
+}
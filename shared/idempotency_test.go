@@ -0,0 +1,63 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	s := NewIdempotencyStore(IdempotencyConfig{Name: "test-store"})
+	resp := &CachedResponse{Status: 201, Body: []byte("created")}
+
+	if state, _ := s.Begin("k", "a"); state != IdempotencyNew {
+		t.Fatalf("first Begin = %v, want new", state)
+	}
+	if state, _ := s.Begin("k", "a"); state != IdempotencyInProgress {
+		t.Errorf("Begin while running = %v, want in progress", state)
+	}
+	if state, _ := s.Begin("k", "b"); state != IdempotencyMismatch {
+		t.Errorf("Begin with another body while running = %v, want mismatch", state)
+	}
+
+	s.Complete("k", "a", resp)
+	state, stored := s.Begin("k", "a")
+	if state != IdempotencyReplay || stored != resp {
+		t.Errorf("Begin after Complete = %v, %v; want replay of the stored response", state, stored)
+	}
+	if state, _ := s.Begin("k", "b"); state != IdempotencyMismatch {
+		t.Errorf("Begin with another body = %v, want mismatch", state)
+	}
+
+	// A completed key is kept; only a claim is released
+	s.Release("k")
+	if state, _ := s.Begin("k", "a"); state != IdempotencyReplay {
+		t.Errorf("Begin after releasing a completed key = %v, want replay", state)
+	}
+	s.Begin("other", "a")
+	s.Release("other")
+	if state, _ := s.Begin("other", "a"); state != IdempotencyNew {
+		t.Errorf("Begin after Release = %v, want new", state)
+	}
+}
+
+func TestIdempotencyStoreExpiresAndEvicts(t *testing.T) {
+	s := NewIdempotencyStore(IdempotencyConfig{Name: "test-store-bounds", TTL: 20 * time.Millisecond, MaxEntries: 2})
+	for _, key := range []string{"a", "b", "c"} {
+		s.Begin(key, "f")
+		s.Complete(key, "f", &CachedResponse{Status: 201})
+	}
+	if got := s.Len(); got != 2 {
+		t.Fatalf("Len = %d, want 2", got)
+	}
+	if state, _ := s.Begin("a", "f"); state != IdempotencyNew {
+		t.Errorf("evicted key state = %v, want new", state)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if state, _ := s.Begin("c", "f"); state != IdempotencyNew {
+		t.Errorf("expired key state = %v, want new", state)
+	}
+	if got := s.Len(); got != 1 {
+		t.Errorf("Len after expiry = %d, want 1", got)
+	}
+}
@@ -0,0 +1,269 @@
+package shared
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Identity headers injected by the API gateway once it has verified the caller's token
+const (
+	HeaderUserID            = "X-User-ID"
+	HeaderUserEmail         = "X-User-Email"
+	HeaderUserRoles         = "X-User-Roles"
+	HeaderSchoolScope       = "X-School-Scope"
+	HeaderRequestID         = "X-Request-ID"
+	HeaderIdentityTimestamp = "X-Identity-Timestamp"
+	HeaderIdentitySignature = "X-Identity-Signature"
+)
+
+// Roles carried by identities. RoleAdmin is allowed to use operational admin
+// endpoints; RoleUser is given to every self-registered account.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// DefaultIdentityMaxSkew bounds how old signed identity headers may be
+const DefaultIdentityMaxSkew = 30 * time.Second
+
+var identityHeaders = []string{
+	HeaderUserID,
+	HeaderUserEmail,
+	HeaderUserRoles,
+	HeaderSchoolScope,
+	HeaderIdentityTimestamp,
+	HeaderIdentitySignature,
+}
+
+var (
+	ErrMissingIdentity          = errors.New("identity headers are missing")
+	ErrInvalidIdentitySignature = errors.New("identity signature is invalid")
+	ErrIdentityExpired          = errors.New("identity headers have expired")
+)
+
+// Identity describes the authenticated caller of a request
+type Identity struct {
+	UserID    string   `json:"user_id"`
+	Email     string   `json:"email"`
+	Roles     []string `json:"roles,omitempty"`
+	SchoolIDs []string `json:"school_ids,omitempty"`
+	RequestID string   `json:"request_id,omitempty"`
+}
+
+// IdentityFromClaims builds an identity from verified JWT claims
+func IdentityFromClaims(claims *JWTClaims) *Identity {
+	identity := &Identity{
+		UserID:    claims.UserID,
+		Email:     claims.Email,
+		SchoolIDs: claims.SchoolIDs,
+	}
+	if claims.Role != "" {
+		identity.Roles = []string{claims.Role}
+	}
+	return identity
+}
+
+// HasRole reports whether the identity carries the given role
+func (id *Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessSchool reports whether the identity may access the given school.
+// Admins access every school; anyone else only the schools in their scope,
+// so an empty scope grants none.
+func (id *Identity) CanAccessSchool(schoolID string) bool {
+	if id.HasRole(RoleAdmin) {
+		return true
+	}
+	for _, s := range id.SchoolIDs {
+		if s == schoolID {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeSchoolIDs narrows ids to the schools the caller in ctx may access.
+// Nil ids stand for every school, so they narrow to the caller's whole scope;
+// admins are not narrowed and get ids back unchanged. A context without an
+// identity may access no school.
+func ScopeSchoolIDs(ctx context.Context, ids []int) []int {
+	identity, ok := IdentityFromContext(ctx)
+	if ok && identity.HasRole(RoleAdmin) {
+		return ids
+	}
+
+	scoped := []int{}
+	if !ok {
+		return scoped
+	}
+	for _, raw := range identity.SchoolIDs {
+		id, err := strconv.Atoi(raw)
+		if err != nil || (ids != nil && !slices.Contains(ids, id)) || slices.Contains(scoped, id) {
+			continue
+		}
+		scoped = append(scoped, id)
+	}
+	return scoped
+}
+
+// CheckSchoolAccess answers 403 when the caller in the request context may
+// not access schoolID, and reports whether it may
+func CheckSchoolAccess(w http.ResponseWriter, r *http.Request, schoolID int) bool {
+	identity, ok := IdentityFromContext(r.Context())
+	if !ok || !identity.CanAccessSchool(strconv.Itoa(schoolID)) {
+		WriteErrorResponse(w, http.StatusForbidden, "FORBIDDEN", fmt.Sprintf("You do not have access to school %d", schoolID))
+		return false
+	}
+	return true
+}
+
+// IdentitySigner signs and verifies the identity headers exchanged between
+// the gateway and the downstream services
+type IdentitySigner struct {
+	secret  []byte
+	maxSkew time.Duration
+}
+
+// NewIdentitySigner creates a signer using the shared gateway secret
+func NewIdentitySigner(secret string, maxSkew time.Duration) *IdentitySigner {
+	return &IdentitySigner{
+		secret:  []byte(secret),
+		maxSkew: maxSkew,
+	}
+}
+
+// Sign replaces any identity headers on h with a freshly signed set for id
+func (s *IdentitySigner) Sign(h http.Header, id *Identity) {
+	StripIdentityHeaders(h)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	h.Set(HeaderUserID, id.UserID)
+	h.Set(HeaderUserEmail, id.Email)
+	h.Set(HeaderUserRoles, strings.Join(id.Roles, ","))
+	h.Set(HeaderSchoolScope, strings.Join(id.SchoolIDs, ","))
+	h.Set(HeaderRequestID, id.RequestID)
+	h.Set(HeaderIdentityTimestamp, timestamp)
+	h.Set(HeaderIdentitySignature, s.signature(h))
+}
+
+// Verify checks the identity headers on h and returns the identity they carry
+func (s *IdentitySigner) Verify(h http.Header) (*Identity, error) {
+	signature := h.Get(HeaderIdentitySignature)
+	if signature == "" || h.Get(HeaderUserID) == "" {
+		return nil, ErrMissingIdentity
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.signature(h))) {
+		return nil, ErrInvalidIdentitySignature
+	}
+
+	timestamp, err := strconv.ParseInt(h.Get(HeaderIdentityTimestamp), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidIdentitySignature
+	}
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > s.maxSkew || age < -s.maxSkew {
+		return nil, ErrIdentityExpired
+	}
+
+	return &Identity{
+		UserID:    h.Get(HeaderUserID),
+		Email:     h.Get(HeaderUserEmail),
+		Roles:     splitHeaderList(h.Get(HeaderUserRoles)),
+		SchoolIDs: splitHeaderList(h.Get(HeaderSchoolScope)),
+		RequestID: h.Get(HeaderRequestID),
+	}, nil
+}
+
+func (s *IdentitySigner) signature(h http.Header) string {
+	payload := strings.Join([]string{
+		h.Get(HeaderIdentityTimestamp),
+		h.Get(HeaderUserID),
+		h.Get(HeaderUserEmail),
+		h.Get(HeaderUserRoles),
+		h.Get(HeaderSchoolScope),
+		h.Get(HeaderRequestID),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// StripIdentityHeaders removes identity headers so clients cannot spoof them
+func StripIdentityHeaders(h http.Header) {
+	for _, header := range identityHeaders {
+		h.Del(header)
+	}
+}
+
+func splitHeaderList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	parts := strings.Split(value, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+type identityContextKey struct{}
+
+// identityHolderKey marks a holder an outer middleware such as
+// AccessLogHandler reads the verified identity from once the request is done
+type identityHolderKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the caller identity. It must only
+// be called with an identity the caller has verified.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	if holder, ok := ctx.Value(identityHolderKey{}).(*atomic.Pointer[Identity]); ok {
+		holder.Store(id)
+	}
+	return context.WithValue(ctx, identityContextKey{}, id)
+}
+
+// withIdentityHolder returns a copy of ctx whose later WithIdentity calls
+// are recorded in holder
+func withIdentityHolder(ctx context.Context, holder *atomic.Pointer[Identity]) context.Context {
+	return context.WithValue(ctx, identityHolderKey{}, holder)
+}
+
+// IdentityFromContext returns the caller identity stored in ctx, if any
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityContextKey{}).(*Identity)
+	return id, ok
+}
+
+// RequireRole rejects requests whose context identity lacks the given role.
+// It must run after a middleware that stores the identity in the context.
+func RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := IdentityFromContext(r.Context())
+		if !ok {
+			WriteErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication is required")
+			return
+		}
+		if !identity.HasRole(role) {
+			WriteErrorResponse(w, http.StatusForbidden, "FORBIDDEN", "Insufficient permissions")
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,83 @@
+package shared
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestCanAccessSchool(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity Identity
+		schoolID string
+		want     bool
+	}{
+		{"admin without scope", Identity{Roles: []string{RoleAdmin}}, "1", true},
+		{"admin outside scope", Identity{Roles: []string{RoleAdmin}, SchoolIDs: []string{"2"}}, "1", true},
+		{"user in scope", Identity{Roles: []string{RoleUser}, SchoolIDs: []string{"1", "2"}}, "2", true},
+		{"user outside scope", Identity{Roles: []string{RoleUser}, SchoolIDs: []string{"2"}}, "1", false},
+		{"user without scope", Identity{Roles: []string{RoleUser}}, "1", false},
+		{"no roles", Identity{}, "1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.identity.CanAccessSchool(tt.schoolID); got != tt.want {
+				t.Errorf("CanAccessSchool(%q) = %v, want %v", tt.schoolID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeSchoolIDs(t *testing.T) {
+	admin := &Identity{Roles: []string{RoleAdmin}, SchoolIDs: []string{"2"}}
+	user := &Identity{Roles: []string{RoleUser}, SchoolIDs: []string{"1", "x", "3", "1"}}
+	tests := []struct {
+		name     string
+		identity *Identity
+		ids      []int
+		want     []int
+	}{
+		{"admin listing everything", admin, nil, nil},
+		{"admin asking for schools", admin, []int{1, 5}, []int{1, 5}},
+		{"user listing everything", user, nil, []int{1, 3}},
+		{"user asking inside scope", user, []int{3}, []int{3}},
+		{"user asking outside scope", user, []int{2, 3}, []int{3}},
+		{"user without scope", &Identity{Roles: []string{RoleUser}}, nil, []int{}},
+		{"no identity", nil, nil, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.identity != nil {
+				ctx = WithIdentity(ctx, tt.identity)
+			}
+			got := ScopeSchoolIDs(ctx, tt.ids)
+			if (got == nil) != (tt.want == nil) || !slices.Equal(got, tt.want) {
+				t.Errorf("ScopeSchoolIDs(%v) = %#v, want %#v", tt.ids, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSchoolAccess(t *testing.T) {
+	user := &Identity{Roles: []string{RoleUser}, SchoolIDs: []string{"1"}}
+	for schoolID, want := range map[int]int{1: http.StatusOK, 2: http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(WithIdentity(req.Context(), user))
+		rec := httptest.NewRecorder()
+		if CheckSchoolAccess(rec, req, schoolID) {
+			rec.WriteHeader(http.StatusOK)
+		}
+		if rec.Code != want {
+			t.Errorf("school %d: status = %d, want %d", schoolID, rec.Code, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	if CheckSchoolAccess(rec, httptest.NewRequest(http.MethodGet, "/", nil), 1) || rec.Code != http.StatusForbidden {
+		t.Errorf("request without identity: status = %d, want 403", rec.Code)
+	}
+}
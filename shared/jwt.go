@@ -2,14 +2,17 @@ package shared
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type JWTClaims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    string   `json:"user_id"`
+	Email     string   `json:"email"`
+	Role      string   `json:"role,omitempty"`
+	SchoolIDs []string `json:"school_ids,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -29,10 +32,12 @@ func NewJWTManager(secretKey, refreshSecretKey string, tokenDuration, refreshDur
 	}
 }
 
-func (manager *JWTManager) GenerateToken(userID, email string) (string, error) {
+func (manager *JWTManager) GenerateToken(userID, email, role string, schoolIDs []string) (string, error) {
 	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SchoolIDs: schoolIDs,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(manager.TokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -43,10 +48,12 @@ func (manager *JWTManager) GenerateToken(userID, email string) (string, error) {
 	return token.SignedString([]byte(manager.SecretKey))
 }
 
-func (manager *JWTManager) GenerateRefreshToken(userID, email string) (string, error) {
+func (manager *JWTManager) GenerateRefreshToken(userID, email, role string, schoolIDs []string) (string, error) {
 	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SchoolIDs: schoolIDs,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(manager.RefreshDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -91,6 +98,11 @@ func (manager *JWTManager) VerifyRefreshToken(tokenString string) (*JWTClaims, e
 	return claims, nil
 }
 
+// ExtractBearerToken strips the "Bearer " prefix from an Authorization header value
+func ExtractBearerToken(authHeader string) string {
+	return strings.TrimPrefix(authHeader, "Bearer ")
+}
+
 // ValidateJWT validates a JWT token using the default secret
 func ValidateJWT(tokenString string) bool {
 	jwtSecret := GetEnv("JWT_SECRET", "your-secret-key")
@@ -0,0 +1,238 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Defaults applied to zero valued ServerTimeouts fields
+const (
+	DefaultReadTimeout     = 15 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+	DefaultIdleTimeout     = 60 * time.Second
+	DefaultShutdownTimeout = 15 * time.Second
+)
+
+// ServerTimeouts configures HTTP servers and the shutdown sequence
+type ServerTimeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	Idle  time.Duration
+	// Shutdown bounds how long in-flight requests and RPCs may take to finish
+	Shutdown time.Duration
+	// DrainDelay is how long the service reports not-ready before it stops
+	// accepting connections, giving load balancers time to notice
+	DrainDelay time.Duration
+}
+
+func withServerTimeoutDefaults(t ServerTimeouts) ServerTimeouts {
+	if t.Read <= 0 {
+		t.Read = DefaultReadTimeout
+	}
+	if t.Write <= 0 {
+		t.Write = DefaultWriteTimeout
+	}
+	if t.Idle <= 0 {
+		t.Idle = DefaultIdleTimeout
+	}
+	if t.Shutdown <= 0 {
+		t.Shutdown = DefaultShutdownTimeout
+	}
+	if t.DrainDelay < 0 {
+		t.DrainDelay = 0
+	}
+	return t
+}
+
+// Lifecycle runs a service's servers and shuts them down in order on SIGINT
+// or SIGTERM: readiness flips to not-ready, the drain delay passes, HTTP
+// servers drain and gRPC servers stop gracefully, then the registered
+// closers run in reverse registration order.
+type Lifecycle struct {
+	timeouts ServerTimeouts
+	draining atomic.Bool
+	errs     chan error
+	signals  chan os.Signal
+
+	mutex       sync.Mutex
+	httpServers []*http.Server
+	grpcServers []*grpc.Server
+	closers     []closer
+}
+
+type closer struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewLifecycle creates a lifecycle using the given timeouts. Shutdown
+// signals are captured from this point on and handled by Wait.
+func NewLifecycle(timeouts ServerTimeouts) *Lifecycle {
+	l := &Lifecycle{
+		timeouts: withServerTimeoutDefaults(timeouts),
+		errs:     make(chan error, 1),
+		signals:  make(chan os.Signal, 1),
+	}
+	signal.Notify(l.signals, syscall.SIGINT, syscall.SIGTERM)
+	return l
+}
+
+// NewHTTPServer creates a server using the lifecycle's read, write and idle timeouts
+func (l *Lifecycle) NewHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: l.timeouts.Read,
+		ReadTimeout:       l.timeouts.Read,
+		WriteTimeout:      l.timeouts.Write,
+		IdleTimeout:       l.timeouts.Idle,
+	}
+}
+
+// ServeHTTP starts srv in the background; it is drained on shutdown. A
+// server with a TLSConfig serves HTTPS using the config's certificates.
+func (l *Lifecycle) ServeHTTP(srv *http.Server) {
+	l.mutex.Lock()
+	l.httpServers = append(l.httpServers, srv)
+	l.mutex.Unlock()
+
+	go func() {
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.fail(fmt.Errorf("HTTP server on %s: %w", srv.Addr, err))
+		}
+	}()
+}
+
+// ServeGRPC starts srv on lis in the background; it is stopped gracefully on shutdown
+func (l *Lifecycle) ServeGRPC(srv *grpc.Server, lis net.Listener) {
+	l.mutex.Lock()
+	l.grpcServers = append(l.grpcServers, srv)
+	l.mutex.Unlock()
+
+	go func() {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			l.fail(fmt.Errorf("gRPC server on %s: %w", lis.Addr(), err))
+		}
+	}()
+}
+
+// OnShutdown registers fn to run after all servers have stopped. Like
+// deferred calls, closers run in reverse registration order, so register
+// each resource right after creating it.
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.closers = append(l.closers, closer{name: name, fn: fn})
+}
+
+// ReadinessCheck fails once shutdown has started
+func (l *Lifecycle) ReadinessCheck() HealthCheck {
+	return HealthCheck{
+		Name: "lifecycle",
+		Check: func(context.Context) error {
+			if l.draining.Load() {
+				return errors.New("shutting down")
+			}
+			return nil
+		},
+	}
+}
+
+func (l *Lifecycle) fail(err error) {
+	select {
+	case l.errs <- err:
+	default:
+	}
+}
+
+// Wait blocks until a shutdown signal arrives or a server fails, then shuts
+// everything down. It returns the server error, if any.
+func (l *Lifecycle) Wait() error {
+	defer signal.Stop(l.signals)
+
+	var serveErr error
+	select {
+	case sig := <-l.signals:
+		slog.Info("shutdown signal received", "signal", sig.String())
+	case serveErr = <-l.errs:
+		slog.Error("server failed, shutting down", "error", serveErr)
+	}
+
+	l.shutdown(serveErr == nil)
+	return serveErr
+}
+
+func (l *Lifecycle) shutdown(drain bool) {
+	l.draining.Store(true)
+	if drain && l.timeouts.DrainDelay > 0 {
+		slog.Info("reporting not ready before draining", "delay", l.timeouts.DrainDelay)
+		time.Sleep(l.timeouts.DrainDelay)
+	}
+
+	l.mutex.Lock()
+	httpServers, grpcServers, closers := l.httpServers, l.grpcServers, l.closers
+	l.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeouts.Shutdown)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, srv := range httpServers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				slog.Warn("HTTP server did not drain in time, closing connections", "addr", srv.Addr, "error", err)
+				srv.Close()
+			}
+		}(srv)
+	}
+	for _, srv := range grpcServers {
+		wg.Add(1)
+		go func(srv *grpc.Server) {
+			defer wg.Done()
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				slog.Warn("gRPC server did not drain in time, stopping")
+				srv.Stop()
+			}
+		}(srv)
+	}
+	wg.Wait()
+	slog.Info("servers stopped")
+
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), l.timeouts.Shutdown)
+	defer cancelClose()
+	for i := len(closers) - 1; i >= 0; i-- {
+		c := closers[i]
+		if err := c.fn(closeCtx); err != nil {
+			slog.Error("shutdown step failed", "step", c.name, "error", err)
+			continue
+		}
+		slog.Info("shutdown step completed", "step", c.name)
+	}
+}
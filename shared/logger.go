@@ -0,0 +1,148 @@
+package shared
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeyFragments mark attribute keys whose values must never be logged
+var sensitiveKeyFragments = []string{
+	"password",
+	"token",
+	"secret",
+	"authorization",
+	"cookie",
+	"signature",
+}
+
+// InitLogger installs a JSON slog logger as the process default. The level is
+// read from level (debug, info, warn, error) and falls back to info.
+func InitLogger(service, level string) *slog.Logger {
+	logger := NewLogger(os.Stdout, service, ParseLogLevel(level))
+	slog.SetDefault(logger)
+	return logger
+}
+
+// NewLogger creates a JSON logger that tags every record with the service
+// name, adds request and trace fields from the context and redacts secrets
+func NewLogger(w io.Writer, service string, level slog.Level) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	})
+	return slog.New(contextHandler{handler}).With(slog.String("service", service))
+}
+
+// ParseLogLevel converts a level name into a slog level
+func ParseLogLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// contextHandler adds request ID, user ID and trace ID from the context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if identity, ok := IdentityFromContext(ctx); ok {
+		record.AddAttrs(slog.String("user_id", identity.UserID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// redactAttr hides secrets and masks personal data before records are written
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, fragment := range sensitiveKeyFragments {
+		if strings.Contains(key, fragment) {
+			return slog.String(attr.Key, redactedValue)
+		}
+	}
+
+	if attr.Value.Kind() != slog.KindString {
+		return attr
+	}
+
+	value := attr.Value.String()
+	switch {
+	case key == "email":
+		return slog.String(attr.Key, MaskEmail(value))
+	case strings.HasPrefix(value, "Bearer "):
+		return slog.String(attr.Key, redactedValue)
+	}
+	return attr
+}
+
+// MaskEmail keeps the first character of the local part and the domain
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return redactedValue
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// AccessLogHandler writes one structured line per request with the route,
+// status and latency, and the user ID once an auth middleware has verified
+// the caller
+func AccessLogHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		identity := new(atomic.Pointer[Identity])
+		r, route := withRouteRecorder(r)
+
+		next.ServeHTTP(recorder, r.WithContext(withIdentityHolder(r.Context(), identity)))
+
+		attrs := []any{
+			slog.String("method", r.Method),
+			slog.String("route", route.name(recorder.status)),
+			slog.Int("status", recorder.status),
+			slog.Duration("latency", time.Since(start)),
+		}
+		// Raw identity headers are never logged: a direct caller can send any
+		// user ID, so only an identity stored by WithIdentity is trusted
+		if verified := identity.Load(); verified != nil {
+			attrs = append(attrs, slog.String("user_id", verified.UserID))
+		}
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(r.Context(), level, "request completed", attrs...)
+	})
+}
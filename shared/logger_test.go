@@ -0,0 +1,59 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAccessLogOnlyLogsVerifiedIdentity(t *testing.T) {
+	signer := NewIdentitySigner("secret", time.Minute)
+	// authenticate mimics the services' auth middleware
+	authenticate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := signer.Verify(r.Header)
+		if err != nil {
+			WriteErrorResponse(w, http.StatusUnauthorized, "INVALID_IDENTITY", "Identity headers could not be verified")
+			return
+		}
+		_ = WithIdentity(r.Context(), identity)
+	})
+
+	signed := httptest.NewRequest(http.MethodGet, "/schools", nil)
+	signer.Sign(signed.Header, &Identity{UserID: "alice"})
+	forged := httptest.NewRequest(http.MethodGet, "/schools", nil)
+	forged.Header.Set(HeaderUserID, "mallory")
+	forged.Header.Set(HeaderIdentitySignature, "forged")
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{"verified identity", signed, "alice"},
+		{"forged identity", forged, ""},
+		{"anonymous", httptest.NewRequest(http.MethodGet, "/schools", nil), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			previous := slog.Default()
+			slog.SetDefault(NewLogger(&buf, "test", slog.LevelInfo))
+			defer slog.SetDefault(previous)
+
+			AccessLogHandler(authenticate).ServeHTTP(httptest.NewRecorder(), tt.req)
+
+			var line map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("access log %q: %v", buf.String(), err)
+			}
+			got, _ := line["user_id"].(string)
+			if got != tt.want {
+				t.Errorf("user_id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
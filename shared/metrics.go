@@ -0,0 +1,332 @@
+package shared
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total HTTP requests by method, route and status code",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route and status code",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	circuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state (0=CLOSED, 1=HALF_OPEN, 2=OPEN)",
+		},
+		[]string{"breaker_name"},
+	)
+
+	circuitBreakerTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_transitions_total",
+			Help: "Total circuit breaker state transitions",
+		},
+		[]string{"breaker_name", "from", "to"},
+	)
+
+	circuitBreakerFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "circuit_breaker_failures_total",
+			Help: "Total failures recorded by circuit breakers",
+		},
+		[]string{"breaker_name"},
+	)
+
+	bulkheadInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bulkhead_in_flight",
+			Help: "Calls currently running inside a bulkhead",
+		},
+		[]string{"bulkhead_name"},
+	)
+
+	bulkheadQueued = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bulkhead_queued",
+			Help: "Calls currently waiting for a bulkhead slot",
+		},
+		[]string{"bulkhead_name"},
+	)
+
+	bulkheadRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bulkhead_rejections_total",
+			Help: "Total calls rejected by a bulkhead by reason",
+		},
+		[]string{"bulkhead_name", "reason"},
+	)
+
+	responseCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "response_cache_requests_total",
+			Help: "Total cacheable requests by cache and result (hit, miss, revalidated, bypass)",
+		},
+		[]string{"cache_name", "result"},
+	)
+
+	responseCacheEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "response_cache_entries",
+			Help: "Responses currently stored in a response cache",
+		},
+		[]string{"cache_name"},
+	)
+
+	responseCacheBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "response_cache_bytes",
+			Help: "Approximate size of the responses stored in a response cache",
+		},
+		[]string{"cache_name"},
+	)
+
+	responseCacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "response_cache_evictions_total",
+			Help: "Total responses removed from a response cache by reason (capacity, invalidated)",
+		},
+		[]string{"cache_name", "reason"},
+	)
+
+	idempotencyRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "idempotency_requests_total",
+			Help: "Total requests carrying an idempotency key by store and result (new, replayed, in_progress, mismatch)",
+		},
+		[]string{"idempotency_store", "result"},
+	)
+
+	idempotencyEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "idempotency_entries",
+			Help: "Idempotency keys currently held by a store",
+		},
+		[]string{"idempotency_store"},
+	)
+
+	apiVersionRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_version_requests_total",
+			Help: "Total requests by resolved API version and whether that version is deprecated",
+		},
+		[]string{"api_version", "deprecated"},
+	)
+
+	eventsPublished = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "events_published_total",
+			Help: "Total change events appended to an event log by type",
+		},
+		[]string{"event_log", "type"},
+	)
+
+	eventSubscribers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "event_subscribers",
+			Help: "Subscribers currently following an event log",
+		},
+		[]string{"event_log"},
+	)
+
+	eventSubscribersDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "event_subscribers_dropped_total",
+			Help: "Total subscribers disconnected for falling too far behind an event log",
+		},
+		[]string{"event_log"},
+	)
+
+	tlsCertificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tls_certificate_expiry_timestamp_seconds",
+			Help: "Expiry time of the currently loaded TLS certificate",
+		},
+		[]string{"tls_name"},
+	)
+
+	grpcServerHandled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Total RPCs completed on the server by method and status code",
+		},
+		[]string{"method", "code"},
+	)
+
+	grpcServerHandlingSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "RPC handling latency on the server",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+
+	grpcClientHandled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_client_handled_total",
+			Help: "Total RPCs completed by the client by method and status code",
+		},
+		[]string{"method", "code"},
+	)
+
+	grpcClientHandlingSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_client_handling_seconds",
+			Help:    "RPC latency observed by the client",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		httpRequestsTotal,
+		httpRequestDuration,
+		circuitBreakerState,
+		circuitBreakerTransitions,
+		circuitBreakerFailures,
+		bulkheadInFlight,
+		bulkheadQueued,
+		bulkheadRejections,
+		responseCacheRequests,
+		responseCacheEntries,
+		responseCacheBytes,
+		responseCacheEvictions,
+		idempotencyRequests,
+		idempotencyEntries,
+		apiVersionRequests,
+		eventsPublished,
+		eventSubscribers,
+		eventSubscribersDropped,
+		tlsCertificateExpiry,
+		grpcServerHandled,
+		grpcServerHandlingSeconds,
+		grpcClientHandled,
+		grpcClientHandlingSeconds,
+	)
+}
+
+// MetricsHandler serves every registered metric, including the Go runtime
+// and process collectors, in the Prometheus exposition format
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// InstrumentHandler records request counts and latency for every request
+func InstrumentHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		r, route := withRouteRecorder(r)
+		next.ServeHTTP(recorder, r)
+
+		code := strconv.Itoa(recorder.status)
+
+		name := route.name(recorder.status)
+		httpRequestsTotal.WithLabelValues(r.Method, name, code).Inc()
+		httpRequestDuration.WithLabelValues(r.Method, name, code).Observe(time.Since(start).Seconds())
+	})
+}
+
+// RegisterDBStats exports connection pool statistics from sql.DB.Stats()
+func RegisterDBStats(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// UnaryServerMetricsInterceptor records RPC counts and latency on the server
+func UnaryServerMetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		grpcServerHandled.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		grpcServerHandlingSeconds.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+// UnaryClientMetricsInterceptor records RPC counts and latency on the client
+func UnaryClientMetricsInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		grpcClientHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+		grpcClientHandlingSeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func recordCircuitBreakerState(name string, state CircuitBreakerState) {
+	circuitBreakerState.WithLabelValues(name).Set(float64(state))
+}
+
+func recordCircuitBreakerTransition(name string, from, to CircuitBreakerState) {
+	circuitBreakerTransitions.WithLabelValues(name, from.String(), to.String()).Inc()
+	recordCircuitBreakerState(name, to)
+}
+
+func recordCircuitBreakerFailure(name string) {
+	circuitBreakerFailures.WithLabelValues(name).Inc()
+}
+
+func recordCertificateExpiry(name string, notAfter time.Time) {
+	tlsCertificateExpiry.WithLabelValues(name).Set(float64(notAfter.Unix()))
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
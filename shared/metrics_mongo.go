@@ -0,0 +1,59 @@
+package shared
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	mongoPoolOpenConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "mongodb_pool_open_connections",
+		Help: "Connections currently open in the MongoDB driver pool",
+	})
+
+	mongoPoolInUseConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "mongodb_pool_in_use_connections",
+		Help: "Connections currently checked out of the MongoDB driver pool",
+	})
+
+	mongoPoolCheckoutFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "mongodb_pool_checkout_failures_total",
+		Help: "Total failed attempts to check a connection out of the MongoDB pool",
+	})
+
+	mongoPoolCleared = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "mongodb_pool_cleared_total",
+		Help: "Total times the MongoDB pool was cleared",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		mongoPoolOpenConnections,
+		mongoPoolInUseConnections,
+		mongoPoolCheckoutFailures,
+		mongoPoolCleared,
+	)
+}
+
+// MongoPoolMonitor returns a driver pool monitor that exports pool statistics
+func MongoPoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				mongoPoolOpenConnections.Inc()
+			case event.ConnectionClosed:
+				mongoPoolOpenConnections.Dec()
+			case event.GetSucceeded:
+				mongoPoolInUseConnections.Inc()
+			case event.ConnectionReturned:
+				mongoPoolInUseConnections.Dec()
+			case event.GetFailed:
+				mongoPoolCheckoutFailures.Inc()
+			case event.PoolCleared:
+				mongoPoolCleared.Inc()
+			}
+		},
+	}
+}
@@ -0,0 +1,501 @@
+package shared
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the OpenAPI version of every generated document
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIPath is where services and the gateway serve their document
+const OpenAPIPath = "/openapi.json"
+
+// bearerAuthScheme is the security scheme name used by authenticated operations
+const bearerAuthScheme = "bearerAuth"
+
+// OpenAPIDocument is an OpenAPI 3.1 document
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Servers    []OpenAPIServer                         `json:"servers,omitempty"`
+	Tags       []OpenAPITag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPITag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]*Schema                `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+	// Internal operations are served by a service directly and are left out
+	// when the gateway aggregates documents
+	Internal bool `json:"x-internal,omitempty"`
+	// Strict operations reject request body fields and query parameters the
+	// document does not describe
+	Strict bool `json:"x-strict-validation,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is the JSON Schema (2020-12) subset used by the generated documents
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+}
+
+// Endpoint describes one operation in terms of the Go models it reads and
+// writes; the document derives every schema from those models
+type Endpoint struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tag         string
+	// Authenticated operations require a bearer token
+	Authenticated bool
+	// PathParams maps path parameter names to JSON Schema types; parameters
+	// not listed are strings
+	PathParams map[string]string
+	// QueryParams maps optional query parameter names to JSON Schema types
+	QueryParams map[string]string
+	// Request is a zero value of the request body model, if any
+	Request any
+	// Response is a zero value of the model returned in the "data" field of
+	// the success envelope; nil documents an envelope without data
+	Response any
+	// Unwrapped documents Response as the whole body rather than the "data"
+	// field of the success envelope
+	Unwrapped bool
+	// RawResponse documents a non-JSON response body of the given media type
+	RawResponse string
+	// Status is the success status code, 200 when zero
+	Status int
+	// Errors lists the documented error status codes
+	Errors []int
+	// ErrorBody replaces ErrorResponse as the body of the error responses
+	ErrorBody any
+	// Internal operations are not exposed through the gateway
+	Internal bool
+	// Strict operations reject unknown body fields and query parameters
+	Strict bool
+	// Idempotent operations accept an Idempotency-Key header; the gateway
+	// answers repeats with the first response
+	Idempotent bool
+}
+
+// NewOpenAPIDocument creates an empty document with the shared components
+func NewOpenAPIDocument(title, version, description string) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    OpenAPIInfo{Title: title, Version: version, Description: description},
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+		Components: OpenAPIComponents{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]*OpenAPISecurityScheme{
+				bearerAuthScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	doc.SchemaRef(ErrorResponse{})
+	return doc
+}
+
+// AddEndpoints adds an operation for every endpoint
+func (d *OpenAPIDocument) AddEndpoints(endpoints ...Endpoint) *OpenAPIDocument {
+	for _, e := range endpoints {
+		d.addEndpoint(e)
+	}
+	return d
+}
+
+func (d *OpenAPIDocument) addEndpoint(e Endpoint) {
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	op := &OpenAPIOperation{
+		OperationID: operationID(e.Method, e.Path),
+		Summary:     e.Summary,
+		Description: e.Description,
+		Responses:   make(map[string]*OpenAPIResponse),
+		Internal:    e.Internal,
+		Strict:      e.Strict,
+	}
+	if e.Tag != "" {
+		op.Tags = []string{e.Tag}
+		d.addTag(e.Tag)
+	}
+	if e.Authenticated {
+		op.Security = []map[string][]string{{bearerAuthScheme: {}}}
+	}
+
+	for _, name := range PathParams(e.Path) {
+		paramType := e.PathParams[name]
+		if paramType == "" {
+			paramType = "string"
+		}
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name: name, In: "path", Required: true, Schema: &Schema{Type: paramType},
+		})
+	}
+	queryNames := make([]string, 0, len(e.QueryParams))
+	for name := range e.QueryParams {
+		queryNames = append(queryNames, name)
+	}
+	sort.Strings(queryNames)
+	for _, name := range queryNames {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name: name, In: "query", Schema: &Schema{Type: e.QueryParams[name]},
+		})
+	}
+
+	if e.Idempotent {
+		maxLength := MaxIdempotencyKeyLength
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name: HeaderIdempotencyKey, In: "header", Schema: &Schema{Type: "string", MaxLength: &maxLength},
+		})
+	}
+
+	if e.Request != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  map[string]*OpenAPIMediaType{"application/json": {Schema: d.SchemaRef(e.Request)}},
+		}
+	}
+
+	success := &OpenAPIResponse{Description: http.StatusText(status)}
+	if e.RawResponse != "" {
+		success.Content = map[string]*OpenAPIMediaType{e.RawResponse: {Schema: &Schema{Type: "string"}}}
+	} else if e.Unwrapped {
+		success.Content = map[string]*OpenAPIMediaType{"application/json": {Schema: d.SchemaRef(e.Response)}}
+	} else {
+		success.Content = map[string]*OpenAPIMediaType{"application/json": {Schema: d.envelope(e.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	errorSchema := &Schema{Ref: componentRef("ErrorResponse")}
+	if e.ErrorBody != nil {
+		errorSchema = d.SchemaRef(e.ErrorBody)
+	}
+	errors := e.Errors
+	if e.Idempotent {
+		errors = append(slices.Clone(errors), http.StatusConflict, http.StatusUnprocessableEntity)
+	}
+	for _, code := range errors {
+		op.Responses[strconv.Itoa(code)] = &OpenAPIResponse{
+			Description: http.StatusText(code),
+			Content:     map[string]*OpenAPIMediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+
+	method := strings.ToLower(e.Method)
+	if d.Paths[e.Path] == nil {
+		d.Paths[e.Path] = make(map[string]*OpenAPIOperation)
+	}
+	d.Paths[e.Path][method] = op
+}
+
+// envelope describes the SuccessResponse wrapper around data
+func (d *OpenAPIDocument) envelope(data any) *Schema {
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"message": {Type: "string"},
+		},
+		Required: []string{"message"},
+	}
+	if data != nil {
+		schema.Properties["data"] = d.SchemaRef(data)
+		schema.Required = append(schema.Required, "data")
+	}
+	return schema
+}
+
+func (d *OpenAPIDocument) addTag(name string) {
+	for _, tag := range d.Tags {
+		if tag.Name == name {
+			return
+		}
+	}
+	d.Tags = append(d.Tags, OpenAPITag{Name: name})
+}
+
+// HasOperation reports whether the document describes method on path
+func (d *OpenAPIDocument) HasOperation(method, path string) bool {
+	_, ok := d.Paths[path][strings.ToLower(method)]
+	return ok
+}
+
+// Handler serves the document as JSON
+func (d *OpenAPIDocument) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		WriteJSONResponse(w, http.StatusOK, d)
+	}
+}
+
+// Merge copies the operations of src into d under prefix, skipping internal
+// operations. Component schemas are copied unless d already defines them.
+func (d *OpenAPIDocument) Merge(src *OpenAPIDocument, prefix string) {
+	for path, operations := range src.Paths {
+		for method, op := range operations {
+			if op.Internal {
+				continue
+			}
+			target := strings.TrimSuffix(prefix+path, "/")
+			if target == "" {
+				target = "/"
+			}
+			if d.Paths[target] == nil {
+				d.Paths[target] = make(map[string]*OpenAPIOperation)
+			}
+			copied := *op
+			copied.OperationID = operationID(method, target)
+			d.Paths[target][method] = &copied
+			for _, tag := range op.Tags {
+				d.addTag(tag)
+			}
+		}
+	}
+	for name, schema := range src.Components.Schemas {
+		if _, exists := d.Components.Schemas[name]; !exists {
+			d.Components.Schemas[name] = schema
+		}
+	}
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// PathParams returns the parameter names of an OpenAPI path template
+func PathParams(path string) []string {
+	var names []string
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// operationID turns "GET /schools/{id}" into "getSchoolsById"
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			segment = "by_" + strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func componentRef(name string) string {
+	return "#/components/schemas/" + name
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaRef returns a schema for v. Named struct types are registered as
+// components and referenced; other types are described inline.
+func (d *OpenAPIDocument) SchemaRef(v any) *Schema {
+	return d.schemaFor(reflect.TypeOf(v))
+}
+
+func (d *OpenAPIDocument) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(jsonMarshalerType), reflect.PointerTo(t).Implements(jsonMarshalerType),
+		t.Implements(textMarshalerType), reflect.PointerTo(t).Implements(textMarshalerType):
+		// Custom marshalers such as ObjectID encode as strings
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		name := t.Name()
+		if _, exists := d.Components.Schemas[name]; !exists {
+			// Reserve the name first so recursive types terminate
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.structSchema(t)
+		}
+		return &Schema{Ref: componentRef(name)}
+	default:
+		// interface{} and anything else accept any JSON value
+		return &Schema{}
+	}
+}
+
+// structSchema describes a struct from its json and jsonschema tags. The
+// jsonschema tag is a comma separated list of: required, readonly,
+// format=<format>, minLength=<n>, maxLength=<n>, minimum=<n>,
+// pattern=<regexp>, enum=<value> (repeatable) and description=<text>.
+func (d *OpenAPIDocument) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := d.schemaFor(field.Type)
+		if tag := field.Tag.Get("jsonschema"); tag != "" {
+			// Constraints cannot sit next to a $ref, so copy inline types only
+			if property.Ref == "" {
+				copied := *property
+				property = &copied
+			}
+			if applySchemaTag(property, tag) {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		schema.Properties[name] = property
+	}
+
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// applySchemaTag applies jsonschema tag options and reports whether the
+// field is required
+func applySchemaTag(schema *Schema, tag string) bool {
+	required := false
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		switch key {
+		case "required":
+			required = true
+		case "readonly":
+			schema.ReadOnly = true
+		case "format":
+			schema.Format = value
+		case "description":
+			schema.Description = value
+		case "pattern":
+			schema.Pattern = value
+		case "enum":
+			schema.Enum = append(schema.Enum, value)
+		case "minLength":
+			if n, err := strconv.Atoi(value); err == nil {
+				schema.MinLength = &n
+			}
+		case "maxLength":
+			if n, err := strconv.Atoi(value); err == nil {
+				schema.MaxLength = &n
+			}
+		case "minimum":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Minimum = &n
+			}
+		}
+	}
+	return required
+}
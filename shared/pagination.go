@@ -0,0 +1,99 @@
+package shared
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page size limits for paginated list endpoints
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// PageRequest is the page a client asked for
+type PageRequest struct {
+	Page     int
+	PageSize int
+}
+
+// Offset returns the number of items before the page
+func (p PageRequest) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// ParsePageRequest reads the page and page_size query parameters, defaulting
+// to the first page of DefaultPageSize items
+func ParsePageRequest(query url.Values) (PageRequest, []FieldError) {
+	page := PageRequest{Page: 1, PageSize: DefaultPageSize}
+	var errs []FieldError
+
+	if raw := query.Get("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			errs = append(errs, FieldError{In: "query", Field: "page", Message: "must be an integer of at least 1"})
+		} else {
+			page.Page = n
+		}
+	}
+	if raw := query.Get("page_size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > MaxPageSize {
+			errs = append(errs, FieldError{In: "query", Field: "page_size", Message: "must be an integer between 1 and " + strconv.Itoa(MaxPageSize)})
+		} else {
+			page.PageSize = n
+		}
+	}
+	return page, errs
+}
+
+// ParseIDList reads a comma separated list of positive IDs, such as
+// ids=1,2,3, from the named query parameter. Duplicates are dropped and at
+// most MaxPageSize IDs are accepted; an absent parameter returns nil.
+func ParseIDList(query url.Values, name string) ([]int, []FieldError) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	seen := make(map[int]bool)
+	ids := []int{}
+	for _, item := range strings.Split(raw, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || id < 1 {
+			return nil, []FieldError{{In: "query", Field: name, Message: "must be a comma separated list of positive integers"}}
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > MaxPageSize {
+		return nil, []FieldError{{In: "query", Field: name, Message: "must list at most " + strconv.Itoa(MaxPageSize) + " IDs"}}
+	}
+	return ids, nil
+}
+
+// PaginationQueryParams documents the query parameters ParsePageRequest reads
+func PaginationQueryParams() map[string]string {
+	return map[string]string{"page": "integer", "page_size": "integer"}
+}
+
+// Pagination describes where a page sits in the full list
+type Pagination struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// NewPagination describes req within a list of total items
+func NewPagination(req PageRequest, total int) Pagination {
+	return Pagination{
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		Total:      total,
+		TotalPages: (total + req.PageSize - 1) / req.PageSize,
+	}
+}
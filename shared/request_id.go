@@ -0,0 +1,98 @@
+package shared
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDMetadataKey carries the request ID in gRPC metadata
+const requestIDMetadataKey = "x-request-id"
+
+const maxRequestIDLength = 128
+
+type requestIDContextKey struct{}
+
+// NewRequestID generates a random request identifier
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// ValidRequestID reports whether a client supplied request ID is safe to reuse
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDHandler accepts or generates an X-Request-ID, stores it in the
+// request context and echoes it back on the response
+func RequestIDHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(HeaderRequestID)
+		if !ValidRequestID(requestID) {
+			requestID = NewRequestID()
+			r.Header.Set(HeaderRequestID, requestID)
+		}
+
+		w.Header().Set(HeaderRequestID, requestID)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("request.id", requestID))
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// UnaryClientRequestIDInterceptor forwards the request ID from the context as gRPC metadata
+func UnaryClientRequestIDInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if requestID := RequestIDFromContext(ctx); requestID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, requestID)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryServerRequestIDInterceptor restores the request ID from incoming gRPC metadata
+func UnaryServerRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(requestIDMetadataKey); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		if !ValidRequestID(requestID) {
+			requestID = NewRequestID()
+		}
+		return handler(WithRequestID(ctx, requestID), req)
+	}
+}
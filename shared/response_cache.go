@@ -0,0 +1,188 @@
+package shared
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults applied to zero valued ResponseCacheConfig fields
+const (
+	DefaultResponseCacheMaxEntries    = 10000
+	DefaultResponseCacheMaxBytes      = 64 << 20
+	DefaultResponseCacheMaxEntryBytes = 1 << 20
+)
+
+// ResponseCacheConfig bounds a ResponseCache
+type ResponseCacheConfig struct {
+	Name          string
+	MaxEntries    int
+	MaxBytes      int64
+	MaxEntryBytes int64
+}
+
+// CachedResponse is a response held by a ResponseCache
+type CachedResponse struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	ETag     string
+	StoredAt time.Time
+	Expires  time.Time
+}
+
+// Fresh reports whether the response may be served without revalidation
+func (c *CachedResponse) Fresh(now time.Time) bool {
+	return now.Before(c.Expires)
+}
+
+func (c *CachedResponse) size() int64 {
+	size := int64(len(c.Body))
+	for key, values := range c.Header {
+		size += int64(len(key))
+		for _, value := range values {
+			size += int64(len(value))
+		}
+	}
+	return size
+}
+
+// ResponseCache is an in-memory LRU store for HTTP responses bounded by
+// entry count and total size. Entries remember the request path they were
+// stored for so writes can invalidate them by resource.
+type ResponseCache struct {
+	config ResponseCacheConfig
+
+	mutex sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+	bytes int64
+}
+
+type responseCacheItem struct {
+	key  string
+	path string
+	resp *CachedResponse
+	size int64
+}
+
+// NewResponseCache creates an empty cache
+func NewResponseCache(config ResponseCacheConfig) *ResponseCache {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultResponseCacheMaxEntries
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultResponseCacheMaxBytes
+	}
+	if config.MaxEntryBytes <= 0 {
+		config.MaxEntryBytes = DefaultResponseCacheMaxEntryBytes
+	}
+	c := &ResponseCache{
+		config: config,
+		order:  list.New(),
+		items:  make(map[string]*list.Element),
+	}
+	c.recordSize()
+	return c
+}
+
+// Name returns the cache name used in metrics
+func (c *ResponseCache) Name() string {
+	return c.config.Name
+}
+
+// Get returns the response stored under key and marks it recently used
+func (c *ResponseCache) Get(key string) (*CachedResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*responseCacheItem).resp, true
+}
+
+// Set stores resp under key for path, evicting the least recently used
+// entries to stay within the limits. Responses larger than MaxEntryBytes are
+// not stored and Set reports false.
+func (c *ResponseCache) Set(key, path string, resp *CachedResponse) bool {
+	item := &responseCacheItem{key: key, path: path, resp: resp, size: resp.size() + int64(len(key))}
+	if item.size > c.config.MaxEntryBytes {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+	c.items[key] = c.order.PushFront(item)
+	c.bytes += item.size
+
+	for c.order.Len() > c.config.MaxEntries || c.bytes > c.config.MaxBytes {
+		c.removeElement(c.order.Back())
+		responseCacheEvictions.WithLabelValues(c.config.Name, "capacity").Inc()
+	}
+	c.recordSize()
+	return true
+}
+
+// InvalidatePath removes every entry stored for path, for a path above it
+// and for a path below it, so a write to /schools/5 drops /schools/5,
+// /schools and /schools/5/students. It returns the number of entries removed.
+func (c *ResponseCache) InvalidatePath(path string) int {
+	path = strings.TrimSuffix(path, "/")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := 0
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if relatedPaths(element.Value.(*responseCacheItem).path, path) {
+			c.removeElement(element)
+			removed++
+		}
+		element = next
+	}
+	if removed > 0 {
+		responseCacheEvictions.WithLabelValues(c.config.Name, "invalidated").Add(float64(removed))
+		c.recordSize()
+	}
+	return removed
+}
+
+// Len returns the number of stored responses
+func (c *ResponseCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+// RecordResult counts a cache lookup outcome such as "hit" or "miss"
+func (c *ResponseCache) RecordResult(result string) {
+	responseCacheRequests.WithLabelValues(c.config.Name, result).Inc()
+}
+
+func (c *ResponseCache) removeElement(element *list.Element) {
+	item := element.Value.(*responseCacheItem)
+	c.order.Remove(element)
+	delete(c.items, item.key)
+	c.bytes -= item.size
+}
+
+func (c *ResponseCache) recordSize() {
+	responseCacheEntries.WithLabelValues(c.config.Name).Set(float64(c.order.Len()))
+	responseCacheBytes.WithLabelValues(c.config.Name).Set(float64(c.bytes))
+}
+
+// relatedPaths reports whether one path equals, contains or is contained by the other
+func relatedPaths(a, b string) bool {
+	a = strings.TrimSuffix(a, "/")
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
@@ -0,0 +1,71 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ResponseCacheConfig
+		body   int
+		want   []string
+	}{
+		// Reading "a" after storing it keeps it over "b"
+		{"entry count", ResponseCacheConfig{MaxEntries: 2}, 1, []string{"a", "c"}},
+		{"total size", ResponseCacheConfig{MaxBytes: 25}, 10, []string{"a", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Name = "test-" + tt.name
+			c := NewResponseCache(tt.config)
+			resp := &CachedResponse{Body: []byte(strings.Repeat("x", tt.body))}
+			c.Set("a", "/a", resp)
+			c.Set("b", "/b", resp)
+			c.Get("a")
+			c.Set("c", "/c", resp)
+
+			var kept []string
+			for _, key := range []string{"a", "b", "c"} {
+				if _, ok := c.Get(key); ok {
+					kept = append(kept, key)
+				}
+			}
+			if strings.Join(kept, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("kept %v, want %v", kept, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCacheRejectsLargeEntries(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{Name: "test-large", MaxEntryBytes: 8})
+	if c.Set("k", "/k", &CachedResponse{Body: []byte("too large")}) {
+		t.Error("stored an entry over MaxEntryBytes")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len = %d, want 0", c.Len())
+	}
+}
+
+func TestResponseCacheInvalidatePath(t *testing.T) {
+	c := NewResponseCache(ResponseCacheConfig{Name: "test-invalidate"})
+	for _, path := range []string{"/schools", "/schools/5", "/schools/5/students", "/schools/50", "/students"} {
+		// Entries of several scopes share a path
+		c.Set(path+"|one", path, &CachedResponse{})
+		c.Set(path+"|two", path, &CachedResponse{})
+	}
+
+	if removed := c.InvalidatePath("/schools/5/"); removed != 6 {
+		t.Errorf("removed %d entries, want 6", removed)
+	}
+	for _, path := range []string{"/schools/50", "/students"} {
+		if _, ok := c.Get(path + "|one"); !ok {
+			t.Errorf("%s was invalidated", path)
+		}
+	}
+	if c.Len() != 4 {
+		t.Errorf("Len = %d, want 4", c.Len())
+	}
+}
@@ -0,0 +1,215 @@
+package shared
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// UnmatchedRoute is the route name of requests no route pattern matched.
+// Metrics, span names and access logs use it instead of the raw path so
+// clients cannot create a new series per URL.
+const UnmatchedRoute = "unmatched"
+
+type routeRecorderKey struct{}
+
+// routeRecorder holds the pattern of the route serving a request, for the
+// middleware outside the mux that name metrics, spans and log lines after it
+type routeRecorder struct {
+	pattern atomic.Pointer[string]
+}
+
+// withRouteRecorder returns r with a route recorder in its context, reusing
+// the one an outer middleware added
+func withRouteRecorder(r *http.Request) (*http.Request, *routeRecorder) {
+	if recorder, ok := r.Context().Value(routeRecorderKey{}).(*routeRecorder); ok {
+		return r, recorder
+	}
+	recorder := &routeRecorder{}
+	return r.WithContext(context.WithValue(r.Context(), routeRecorderKey{}, recorder)), recorder
+}
+
+// name returns the recorded pattern, or UnmatchedRoute when no route matched
+// or the response was a 404
+func (rr *routeRecorder) name(status int) string {
+	pattern := rr.pattern.Load()
+	if pattern == nil || *pattern == "" || status == http.StatusNotFound {
+		return UnmatchedRoute
+	}
+	return *pattern
+}
+
+// RouteHandler serves mux after recording the pattern of the route that
+// matches the request, such as "/schools/{id}", for InstrumentHandler,
+// TraceHandler and AccessLogHandler. Wrap the mux with it inside those
+// middleware and inside any that rewrite the path, like APIVersioning.
+func RouteHandler(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if recorder, ok := r.Context().Value(routeRecorderKey{}).(*routeRecorder); ok {
+			_, pattern := mux.Handler(r)
+			recorder.pattern.Store(&pattern)
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// Route binds a handler to a method and an OpenAPI style path template such
+// as "/schools/{id}"; the template doubles as the ServeMux pattern
+type Route struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+	// Version restricts the handler to one API version, overriding the
+	// unversioned route for the same method and path. Versioned routes are
+	// documented under their prefixed path, such as "/v2/schools".
+	Version string
+}
+
+// DocumentedPath is the path the route's OpenAPI operation is listed under
+func (r Route) DocumentedPath() string {
+	if r.Version == "" {
+		return r.Path
+	}
+	return "/" + r.Version + r.Path
+}
+
+// RegisterRoutes registers routes on mux. Routes sharing a path are served by
+// one handler that dispatches on the method and the request's API version and
+// answers other methods with 405 METHOD_NOT_ALLOWED.
+func RegisterRoutes(mux *http.ServeMux, routes []Route) {
+	byPath := make(map[string]map[string]map[string]http.HandlerFunc)
+	var paths []string
+	for _, route := range routes {
+		if byPath[route.Path] == nil {
+			byPath[route.Path] = make(map[string]map[string]http.HandlerFunc)
+			paths = append(paths, route.Path)
+		}
+		if byPath[route.Path][route.Method] == nil {
+			byPath[route.Path][route.Method] = make(map[string]http.HandlerFunc)
+		}
+		byPath[route.Path][route.Method][route.Version] = route.Handler
+	}
+
+	for _, path := range paths {
+		handlers := byPath[path]
+		allowed := make([]string, 0, len(handlers))
+		for method := range handlers {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		allow := strings.Join(allowed, ", ")
+
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			versions, ok := handlers[r.Method]
+			if !ok {
+				w.Header().Set("Allow", allow)
+				WriteErrorResponse(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+				return
+			}
+			handler, ok := versions[APIVersionFromContext(r.Context())]
+			if !ok {
+				handler = versions[""]
+			}
+			if handler == nil {
+				WriteErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Endpoint not found in this API version")
+				return
+			}
+			handler(w, r)
+		})
+	}
+}
+
+// OperationalRoutes are the health, metrics, circuit breaker admin and
+// OpenAPI routes every service serves. admin must already be wrapped with
+// authentication and RequireRole(RoleAdmin, ...).
+func OperationalRoutes(readiness, admin http.HandlerFunc, spec *OpenAPIDocument) []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/livez", Handler: LivenessHandler()},
+		{Method: http.MethodGet, Path: "/readyz", Handler: readiness},
+		{Method: http.MethodGet, Path: "/health", Handler: readiness},
+		{Method: http.MethodGet, Path: "/metrics", Handler: MetricsHandler().ServeHTTP},
+		{Method: http.MethodGet, Path: CircuitBreakerAdminPath, Handler: admin},
+		{Method: http.MethodGet, Path: CircuitBreakerAdminPath + "/{name}", Handler: admin},
+		{Method: http.MethodPost, Path: CircuitBreakerAdminPath + "/{name}/{action}", Handler: admin},
+		{Method: http.MethodGet, Path: OpenAPIPath, Handler: spec.Handler()},
+	}
+}
+
+// OperationalEndpoints documents OperationalRoutes
+func OperationalEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Method: http.MethodGet, Path: "/livez", Tag: "operations", Internal: true,
+			Summary:  "Liveness probe",
+			Response: HealthReport{}, Unwrapped: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/readyz", Tag: "operations", Internal: true,
+			Summary:  "Readiness probe including dependency checks",
+			Response: HealthReport{}, Unwrapped: true,
+			Errors: []int{http.StatusServiceUnavailable}, ErrorBody: HealthReport{},
+		},
+		{
+			Method: http.MethodGet, Path: "/health", Tag: "operations", Internal: true,
+			Summary:  "Alias of /readyz",
+			Response: HealthReport{}, Unwrapped: true,
+			Errors: []int{http.StatusServiceUnavailable}, ErrorBody: HealthReport{},
+		},
+		{
+			Method: http.MethodGet, Path: "/metrics", Tag: "operations", Internal: true,
+			Summary:     "Prometheus metrics",
+			RawResponse: "text/plain",
+		},
+		{
+			Method: http.MethodGet, Path: CircuitBreakerAdminPath, Tag: "admin", Internal: true,
+			Summary: "List circuit breakers", Authenticated: true,
+			Response: []CircuitBreakerView{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden},
+		},
+		{
+			Method: http.MethodGet, Path: CircuitBreakerAdminPath + "/{name}", Tag: "admin", Internal: true,
+			Summary: "Show a circuit breaker", Authenticated: true,
+			Response: CircuitBreakerView{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodPost, Path: CircuitBreakerAdminPath + "/{name}/{action}", Tag: "admin", Internal: true,
+			Summary:       "Force a circuit breaker open or closed, or reset it",
+			Description:   "action is one of open, close or reset.",
+			Authenticated: true,
+			Response:      CircuitBreakerView{},
+			Errors:        []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: OpenAPIPath, Tag: "operations", Internal: true,
+			Summary:  "This OpenAPI document",
+			Response: map[string]any{}, Unwrapped: true,
+		},
+	}
+}
+
+// CheckRouteCoverage compares routes with the operations in doc and returns
+// a description of every route without an operation and every operation
+// without a route
+func CheckRouteCoverage(routes []Route, doc *OpenAPIDocument) []string {
+	var problems []string
+	routed := make(map[string]bool)
+	for _, route := range routes {
+		path := route.DocumentedPath()
+		routed[route.Method+" "+path] = true
+		if !doc.HasOperation(route.Method, path) {
+			problems = append(problems, "route "+route.Method+" "+path+" has no OpenAPI operation")
+		}
+	}
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			if !routed[strings.ToUpper(method)+" "+path] {
+				problems = append(problems, "OpenAPI operation "+strings.ToUpper(method)+" "+path+" has no route")
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
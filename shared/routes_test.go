@@ -0,0 +1,86 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRouteNamesAreBounded(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, []Route{
+		{Method: http.MethodGet, Path: "/schools/{id}", Handler: func(w http.ResponseWriter, r *http.Request) {
+			WriteErrorResponse(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication is required")
+		}},
+	})
+	mux.HandleFunc("/schools/", func(w http.ResponseWriter, r *http.Request) {
+		WriteErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request")
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+	}{
+		{"path parameter", http.MethodGet, "/schools/4f2a-random", "/schools/{id}"},
+		{"other method", http.MethodPost, "/schools/anything", "/schools/{id}"},
+		{"prefix pattern", http.MethodGet, "/schools/a/b/c", "/schools/"},
+		{"no route", http.MethodGet, "/random-" + t.Name(), UnmatchedRoute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			previous := slog.Default()
+			slog.SetDefault(NewLogger(&buf, "test", slog.LevelInfo))
+			defer slog.SetDefault(previous)
+
+			handler := AccessLogHandler(RouteHandler(mux))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			var line map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("access log %q: %v", buf.String(), err)
+			}
+			if line["route"] != tt.want {
+				t.Errorf("route = %v, want %q", line["route"], tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteNameWithoutRecorder(t *testing.T) {
+	_, recorder := withRouteRecorder(httptest.NewRequest(http.MethodGet, "/x", nil))
+	if got := recorder.name(http.StatusOK); got != UnmatchedRoute {
+		t.Errorf("name = %q, want %q", got, UnmatchedRoute)
+	}
+	pattern := "/schools/{id}"
+	recorder.pattern.Store(&pattern)
+	if got := recorder.name(http.StatusNotFound); got != UnmatchedRoute {
+		t.Errorf("404 name = %q, want %q", got, UnmatchedRoute)
+	}
+}
+
+func TestInstrumentHandlerLabelsByRoute(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, []Route{
+		{Method: http.MethodGet, Path: "/metrics-test/{id}", Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}},
+	})
+	handler := InstrumentHandler(RouteHandler(mux))
+	series := httpRequestsTotal.WithLabelValues(http.MethodGet, "/metrics-test/{id}", "401")
+	before := testutil.ToFloat64(series)
+
+	for _, path := range []string{"/metrics-test/a", "/metrics-test/b", "/metrics-test/c"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if got := testutil.ToFloat64(series) - before; got != 3 {
+		t.Errorf("route series counted %v requests, want 3", got)
+	}
+}
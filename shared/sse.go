@@ -0,0 +1,164 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// HeaderLastEventID is sent by Server-Sent Events clients when they reconnect
+const HeaderLastEventID = "Last-Event-ID"
+
+// EventStreamPath is where services stream their change events to the gateway
+const EventStreamPath = "/events"
+
+// Defaults applied to zero valued EventStreamConfig fields
+const (
+	DefaultEventHeartbeat    = 15 * time.Second
+	DefaultEventWriteTimeout = 10 * time.Second
+	DefaultEventRetry        = 3 * time.Second
+)
+
+// EventStreamConfig configures an event stream
+type EventStreamConfig struct {
+	// Heartbeat is how often an idle stream sends a comment, keeping proxies
+	// from closing it and letting the server notice gone clients
+	Heartbeat time.Duration
+	// WriteTimeout bounds each write. It replaces the server's WriteTimeout,
+	// which would otherwise end every stream after a fixed time.
+	WriteTimeout time.Duration
+	// Retry is the reconnection delay suggested to clients
+	Retry time.Duration
+}
+
+// WithDefaults fills zero valued fields with the package defaults
+func (c EventStreamConfig) WithDefaults() EventStreamConfig {
+	if c.Heartbeat <= 0 {
+		c.Heartbeat = DefaultEventHeartbeat
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = DefaultEventWriteTimeout
+	}
+	if c.Retry <= 0 {
+		c.Retry = DefaultEventRetry
+	}
+	return c
+}
+
+// EventStreamHandler streams every event of log as Server-Sent Events,
+// resuming after the Last-Event-ID header or last_event_id query parameter
+func EventStreamHandler(log *EventLog, config EventStreamConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ServeEventStream(w, r, log, LastEventID(r), nil, config)
+	}
+}
+
+// LastEventID returns the event a client resumes after: the Last-Event-ID
+// header sent by EventSource, or the last_event_id query parameter for
+// clients that cannot set headers
+func LastEventID(r *http.Request) string {
+	if id := r.Header.Get(HeaderLastEventID); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("last_event_id")
+}
+
+// ServeEventStream streams the events of log that pass filter, a nil filter
+// passing all, until the client goes away or the log closes. Reset events
+// always pass.
+func ServeEventStream(w http.ResponseWriter, r *http.Request, log *EventLog, lastEventID string, filter func(*Event) bool, config EventStreamConfig) {
+	config = config.WithDefaults()
+	replay, subscription, err := log.Subscribe(lastEventID)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Service is shutting down")
+		return
+	}
+	defer subscription.Close()
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	// Ask reverse proxies such as nginx not to buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(fn func() error) bool {
+		// Deadlines are best effort; recorders used in tests have none
+		controller.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+		if err := fn(); err != nil {
+			return false
+		}
+		return controller.Flush() == nil
+	}
+	send := func(event *Event) bool {
+		if filter != nil && event.Type != EventTypeReset && !filter(event) {
+			return true
+		}
+		return write(func() error { return WriteServerSentEvent(w, event) })
+	}
+
+	if !write(func() error {
+		_, err := fmt.Fprintf(w, "retry: %d\n\n", config.Retry.Milliseconds())
+		return err
+	}) {
+		return
+	}
+	for i := range replay {
+		if !send(&replay[i]) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(config.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// Dropped for falling behind, or shutting down; the client
+				// reconnects with its last event ID
+				slog.DebugContext(r.Context(), "event stream ended by server")
+				return
+			}
+			if !send(&event) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(func() error {
+				_, err := io.WriteString(w, ": heartbeat\n\n")
+				return err
+			}) {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// WriteServerSentEvent writes event in the Server-Sent Events format, with
+// the event as JSON in the data field
+func WriteServerSentEvent(w io.Writer, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// EventStreamEndpoint documents a service's event stream
+func EventStreamEndpoint() Endpoint {
+	return Endpoint{
+		Method: http.MethodGet, Path: EventStreamPath, Tag: "events", Internal: true,
+		Summary:       "Stream change events",
+		Description:   "Server-Sent Events of every change made by the service, for the gateway to fan out. Send Last-Event-ID to resume. Requires the admin role.",
+		Authenticated: true,
+		QueryParams:   map[string]string{"last_event_id": "string"},
+		RawResponse:   "text/event-stream",
+		Errors:        []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusServiceUnavailable},
+	}
+}
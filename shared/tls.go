@@ -0,0 +1,212 @@
+package shared
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTLSReloadInterval is how often certificate files are checked for rotation
+const DefaultTLSReloadInterval = 30 * time.Second
+
+// TLSFiles locates the PEM files of one side of a TLS connection
+type TLSFiles struct {
+	// CertFile and KeyFile hold the certificate presented to peers
+	CertFile string
+	KeyFile  string
+	// CAFile holds the certificates peers are verified against: client
+	// certificates on a server, which turns on mutual TLS, and server
+	// certificates on a client
+	CAFile string
+}
+
+// certState is one loaded generation of the files
+type certState struct {
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// certReloader keeps the most recently loaded certificate and CA pool and
+// polls the files for changes, so rotated certificates are used by new
+// connections without a restart
+type certReloader struct {
+	name     string
+	files    TLSFiles
+	state    atomic.Pointer[certState]
+	modTimes map[string]time.Time
+}
+
+func newCertReloader(name string, files TLSFiles, interval time.Duration) (*certReloader, error) {
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return nil, errors.New("TLS certificate and key files must be set together")
+	}
+	r := &certReloader{name: name, files: files, modTimes: make(map[string]time.Time)}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		interval = DefaultTLSReloadInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !r.changed() {
+				continue
+			}
+			if err := r.load(); err != nil {
+				// Keep serving the previous certificate; the files may be
+				// half way through being replaced
+				slog.Error("TLS certificate reload failed", "tls", r.name, "error", err)
+				continue
+			}
+			slog.Info("TLS certificates reloaded", "tls", r.name)
+		}
+	}()
+	return r, nil
+}
+
+func (r *certReloader) paths() []string {
+	var paths []string
+	for _, path := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// changed reports whether any file's modification time moved since the last load
+func (r *certReloader) changed() bool {
+	for _, path := range r.paths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *certReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, path := range r.paths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	state := &certState{}
+	if r.files.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+		if err != nil {
+			return fmt.Errorf("load certificate %s: %w", r.files.CertFile, err)
+		}
+		if cert.Leaf != nil {
+			recordCertificateExpiry(r.name, cert.Leaf.NotAfter)
+		}
+		state.cert = &cert
+	}
+	if r.files.CAFile != "" {
+		pem, err := os.ReadFile(r.files.CAFile)
+		if err != nil {
+			return fmt.Errorf("read CA file: %w", err)
+		}
+		state.pool = x509.NewCertPool()
+		if !state.pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA file %s", r.files.CAFile)
+		}
+	}
+
+	r.state.Store(state)
+	r.modTimes = modTimes
+	return nil
+}
+
+// NewServerTLSConfig returns the TLS configuration for a listener, or nil
+// when files names no certificate and the listener should stay plain text.
+// With a CAFile, clients must present a certificate signed by it.
+func NewServerTLSConfig(name string, files TLSFiles, reloadInterval time.Duration) (*tls.Config, error) {
+	if files.CertFile == "" && files.KeyFile == "" {
+		if files.CAFile != "" {
+			return nil, errors.New("a client CA file requires a server certificate")
+		}
+		return nil, nil
+	}
+	r, err := newCertReloader(name, files, reloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		// Resolve the current generation for every handshake
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			state := r.state.Load()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2", "http/1.1"},
+				Certificates: []tls.Certificate{*state.cert},
+			}
+			if state.pool != nil {
+				config.ClientCAs = state.pool
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}, nil
+}
+
+// NewClientTLSConfig returns the TLS configuration for calls to internal
+// services, or nil when files is empty and calls use plain text. The
+// certificate, when set, is presented for mutual TLS; servers are verified
+// against CAFile, or the system roots without one.
+func NewClientTLSConfig(name string, files TLSFiles, reloadInterval time.Duration) (*tls.Config, error) {
+	if files == (TLSFiles{}) {
+		return nil, nil
+	}
+	r, err := newCertReloader(name, files, reloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := r.state.Load().cert; cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		// The standard verification only reads a fixed RootCAs pool, so it is
+		// replaced by VerifyConnection, which checks the chain and host name
+		// against the current pool
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			opts := x509.VerifyOptions{
+				Roots:         r.state.Load().pool,
+				DNSName:       cs.ServerName,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}, nil
+}
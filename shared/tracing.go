@@ -0,0 +1,176 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+const tracerName = "skool-management"
+
+// Database systems recorded on DB spans
+const (
+	DBSystemPostgreSQL = "postgresql"
+	DBSystemMongoDB    = "mongodb"
+)
+
+// InitTracing installs the global tracer provider and W3C trace context propagator.
+//
+// The exporter is selected with OTEL_TRACES_EXPORTER:
+//   - "otlp": OTLP over HTTP, configured by the standard OTEL_EXPORTER_OTLP_* variables
+//   - "stdout": pretty printed spans on standard output
+//   - "file": JSON spans appended to OTEL_TRACES_FILE (default traces.json)
+//   - "none" or unset: spans are created for propagation but not exported
+//
+// The returned function flushes and shuts the provider down.
+func InitTracing(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(traceSampleRatio()))),
+	}
+
+	var closer io.Closer
+	switch exporterName := strings.ToLower(GetEnv("OTEL_TRACES_EXPORTER", "none")); exporterName {
+	case "none":
+	case "otlp":
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case "file":
+		file, err := os.OpenFile(GetEnv("OTEL_TRACES_FILE", "traces.json"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		closer = file
+		options = append(options, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", exporterName)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+		return err
+	}, nil
+}
+
+func traceSampleRatio() float64 {
+	ratio, err := strconv.ParseFloat(GetEnv("OTEL_TRACES_SAMPLER_ARG", "1"), 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return 1
+	}
+	return ratio
+}
+
+// Tracer returns the tracer used for spans created by this module
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// TraceDB runs fn inside a client span describing a database call
+func TraceDB(ctx context.Context, system, operation, statement string, fn func(ctx context.Context) error) error {
+	ctx, span := Tracer().Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameKey.String(system),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(strings.Join(strings.Fields(statement), " ")),
+		),
+	)
+	defer span.End()
+
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// TraceHandler wraps an HTTP handler so every request gets a server span.
+// The span is renamed after the route pattern that served the request once
+// it is known, so span names stay bounded whatever paths clients send.
+func TraceHandler(handler http.Handler, serviceName string) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, route := withRouteRecorder(r)
+		handler.ServeHTTP(w, r)
+		trace.SpanFromContext(r.Context()).SetName(r.Method + " " + route.name(0))
+	})
+	return otelhttp.NewHandler(named, serviceName,
+		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+}
+
+// TracingTransport wraps a round tripper so outgoing requests get client spans
+// and carry the W3C traceparent header
+func TracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base,
+		// Outgoing paths mirror what clients sent, so name spans by host only
+		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+			return r.Method + " " + r.URL.Host
+		}),
+	)
+}
+
+// GRPCServerTracing returns the server option that creates spans for incoming RPCs
+func GRPCServerTracing() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler())
+}
+
+// GRPCClientTracing returns the dial option that creates spans for outgoing RPCs
+func GRPCClientTracing() grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler())
+}
@@ -2,14 +2,15 @@ package shared
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"os"
 )
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error     string       `json:"error"`
+	Message   string       `json:"message"`
+	RequestID string       `json:"request_id,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
 }
 
 type SuccessResponse struct {
@@ -25,12 +26,24 @@ func WriteJSONResponse(w http.ResponseWriter, statusCode int, data interface{})
 
 func WriteErrorResponse(w http.ResponseWriter, statusCode int, error, message string) {
 	response := ErrorResponse{
-		Error:   error,
-		Message: message,
+		Error:     error,
+		Message:   message,
+		RequestID: w.Header().Get(HeaderRequestID),
 	}
 	WriteJSONResponse(w, statusCode, response)
 }
 
+// WriteValidationErrorResponse writes a 400 VALIDATION_ERROR listing every invalid field
+func WriteValidationErrorResponse(w http.ResponseWriter, fields []FieldError) {
+	response := ErrorResponse{
+		Error:     "VALIDATION_ERROR",
+		Message:   "Request validation failed",
+		RequestID: w.Header().Get(HeaderRequestID),
+		Fields:    fields,
+	}
+	WriteJSONResponse(w, http.StatusBadRequest, response)
+}
+
 func WriteSuccessResponse(w http.ResponseWriter, statusCode int, message string, data interface{}) {
 	response := SuccessResponse{
 		Message: message,
@@ -39,14 +52,6 @@ func WriteSuccessResponse(w http.ResponseWriter, statusCode int, message string,
 	WriteJSONResponse(w, statusCode, response)
 }
 
-func LogError(service, operation string, err error) {
-	fmt.Printf("[%s] Error in %s: %v\n", service, operation, err)
-}
-
-func LogInfo(service, message string) {
-	fmt.Printf("[%s] %s\n", service, message)
-}
-
 // GetEnv gets an environment variable with a default value
 func GetEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,371 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes one invalid part of a request
+type FieldError struct {
+	// In is where the field was found: body, path or query
+	In string `json:"in"`
+	// Field is the parameter name or the dotted path into the body, such as
+	// "school_id" or "school_ids[1]"; empty for the body as a whole
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// FindOperation returns the operation serving method on a concrete request
+// path along with its path parameters. When several templates match, the one
+// with the most literal segments wins, so "/students/school/{school_id}" is
+// preferred over "/students/{id}".
+func (d *OpenAPIDocument) FindOperation(method, path string) (*OpenAPIOperation, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	method = strings.ToLower(method)
+
+	var (
+		best       *OpenAPIOperation
+		bestParams map[string]string
+		bestScore  = -1
+	)
+	for template, operations := range d.Paths {
+		op, ok := operations[method]
+		if !ok {
+			continue
+		}
+		params, score, ok := matchPathTemplate(template, segments)
+		if ok && score > bestScore {
+			best, bestParams, bestScore = op, params, score
+		}
+	}
+	return best, bestParams, best != nil
+}
+
+// MatchPath matches a concrete request path against an OpenAPI style path
+// template and returns the path parameters
+func MatchPath(template, path string) (map[string]string, bool) {
+	params, _, ok := matchPathTemplate(template, strings.Split(strings.Trim(path, "/"), "/"))
+	return params, ok
+}
+
+// matchPathTemplate matches segments against template and returns the
+// parameter values and the number of literal segments
+func matchPathTemplate(template string, segments []string) (map[string]string, int, bool) {
+	parts := strings.Split(strings.Trim(template, "/"), "/")
+	if len(parts) != len(segments) {
+		return nil, 0, false
+	}
+	params := make(map[string]string)
+	literals := 0
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, 0, false
+			}
+			value, err := url.PathUnescape(segments[i])
+			if err != nil {
+				return nil, 0, false
+			}
+			params[part[1:len(part)-1]] = value
+			continue
+		}
+		if part != segments[i] {
+			return nil, 0, false
+		}
+		literals++
+	}
+	return params, literals, true
+}
+
+// ValidateRequest checks path parameters, the query string and the JSON body
+// of a request against op. Strict operations also reject query parameters
+// and body fields op does not describe.
+func (d *OpenAPIDocument) ValidateRequest(op *OpenAPIOperation, pathParams map[string]string, query url.Values, body []byte) []FieldError {
+	var errs []FieldError
+
+	declaredQuery := make(map[string]bool)
+	for _, param := range op.Parameters {
+		switch param.In {
+		case "path":
+			errs = d.validateParameter(errs, param, pathParams[param.Name])
+		case "query":
+			declaredQuery[param.Name] = true
+			if values, ok := query[param.Name]; ok {
+				errs = d.validateParameter(errs, param, values[0])
+			} else if param.Required {
+				errs = append(errs, FieldError{In: "query", Field: param.Name, Message: "is required"})
+			}
+		}
+	}
+	if op.Strict {
+		var unknown []string
+		for name := range query {
+			if !declaredQuery[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			errs = append(errs, FieldError{In: "query", Field: name, Message: "is not a known parameter"})
+		}
+	}
+
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["application/json"]; ok {
+			errs = append(errs, d.validateBody(media.Schema, body, op.RequestBody.Required, op.Strict)...)
+		}
+	}
+	return errs
+}
+
+// validateParameter converts a path or query string to the parameter's type
+// before checking it against the parameter schema
+func (d *OpenAPIDocument) validateParameter(errs []FieldError, param OpenAPIParameter, raw string) []FieldError {
+	schema := d.resolve(param.Schema)
+	var value any = raw
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return append(errs, FieldError{In: param.In, Field: param.Name, Message: "must be an integer"})
+		}
+		value = json.Number(raw)
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return append(errs, FieldError{In: param.In, Field: param.Name, Message: "must be a number"})
+		}
+		value = json.Number(raw)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return append(errs, FieldError{In: param.In, Field: param.Name, Message: "must be true or false"})
+		}
+		value = b
+	}
+
+	v := schemaValidator{doc: d, in: param.In}
+	v.validate(schema, value, param.Name)
+	return append(errs, v.errs...)
+}
+
+func (d *OpenAPIDocument) validateBody(schema *Schema, body []byte, required, strict bool) []FieldError {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required {
+			return []FieldError{{In: "body", Message: "request body is required"}}
+		}
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []FieldError{{In: "body", Message: "malformed JSON: " + err.Error()}}
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return []FieldError{{In: "body", Message: "malformed JSON: unexpected data after the top-level value"}}
+	}
+
+	v := schemaValidator{doc: d, in: "body", strict: strict}
+	v.validate(schema, value, "")
+	return v.errs
+}
+
+func (d *OpenAPIDocument) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	if schema == nil {
+		return &Schema{}
+	}
+	return schema
+}
+
+// schemaValidator collects the errors found while walking a value decoded
+// with json.Decoder.UseNumber
+type schemaValidator struct {
+	doc    *OpenAPIDocument
+	in     string
+	strict bool
+	errs   []FieldError
+}
+
+func (v *schemaValidator) fail(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{In: v.in, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(schema *Schema, value any, field string) {
+	schema = v.doc.resolve(schema)
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			v.fail(field, "must be an object")
+			return
+		}
+		v.validateObject(schema, object, field)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			v.fail(field, "must be an array")
+			return
+		}
+		for i, item := range items {
+			v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i))
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			v.fail(field, "must be a string")
+			return
+		}
+		v.validateString(schema, s, field)
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			v.fail(field, "must be an integer")
+			return
+		}
+		i, err := n.Int64()
+		if err != nil {
+			v.fail(field, "must be an integer")
+			return
+		}
+		if schema.Minimum != nil && float64(i) < *schema.Minimum {
+			v.fail(field, "must be at least %s", strconv.FormatFloat(*schema.Minimum, 'f', -1, 64))
+		}
+	case "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			v.fail(field, "must be a number")
+			return
+		}
+		f, err := n.Float64()
+		if err != nil {
+			v.fail(field, "must be a number")
+			return
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			v.fail(field, "must be at least %s", strconv.FormatFloat(*schema.Minimum, 'f', -1, 64))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(field, "must be true or false")
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(schema *Schema, object map[string]any, field string) {
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+		if value, ok := object[name]; !ok || value == nil {
+			v.fail(joinField(field, name), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := object[name]
+		property, known := schema.Properties[name]
+		switch {
+		case known:
+			// Like encoding/json, treat null as an absent optional field
+			if value != nil {
+				v.validate(property, value, joinField(field, name))
+			}
+		case schema.AdditionalProperties != nil:
+			v.validate(schema.AdditionalProperties, value, joinField(field, name))
+		case v.strict && schema.Properties != nil:
+			v.fail(joinField(field, name), "is not a known field")
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(schema *Schema, s, field string) {
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		if *schema.MinLength == 1 {
+			v.fail(field, "must not be empty")
+		} else {
+			v.fail(field, "must be at least %d characters", *schema.MinLength)
+		}
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(field, "must be at most %d characters", *schema.MaxLength)
+	}
+	if len(schema.Enum) > 0 && !containsString(schema.Enum, s) {
+		v.fail(field, "must be one of %s", strings.Join(schema.Enum, ", "))
+	}
+	if schema.Pattern != "" && s != "" {
+		if pattern, err := compilePattern(schema.Pattern); err == nil && !pattern.MatchString(s) {
+			v.fail(field, "must match %s", schema.Pattern)
+		}
+	}
+	// Services treat empty optional strings as absent, so formats only apply
+	// to non-empty values
+	if s == "" {
+		return
+	}
+	switch schema.Format {
+	case "email":
+		if address, err := mail.ParseAddress(s); err != nil || address.Address != s {
+			v.fail(field, "must be a valid email address")
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			v.fail(field, "must be a date in YYYY-MM-DD format")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			v.fail(field, "must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+var patternCache sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := patternCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, compiled)
+	return compiled, nil
+}
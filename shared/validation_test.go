@@ -0,0 +1,158 @@
+package shared
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type validationTestAddress struct {
+	City string `json:"city" jsonschema:"required,minLength=1"`
+}
+
+type validationTestRequest struct {
+	Name     string                `json:"name" jsonschema:"required,minLength=1,maxLength=5"`
+	Email    string                `json:"email,omitempty" jsonschema:"format=email"`
+	Age      int                   `json:"age,omitempty" jsonschema:"minimum=1"`
+	Active   bool                  `json:"active,omitempty"`
+	Status   string                `json:"status,omitempty" jsonschema:"enum=active,enum=inactive"`
+	Born     string                `json:"born,omitempty" jsonschema:"format=date"`
+	Tags     []string              `json:"tags,omitempty"`
+	Address  validationTestAddress `json:"address,omitempty"`
+	Metadata map[string]int        `json:"metadata,omitempty"`
+}
+
+func validationTestDocument() *OpenAPIDocument {
+	endpoint := func(path string, strict bool) Endpoint {
+		return Endpoint{
+			Method:      http.MethodPut,
+			Path:        path,
+			PathParams:  map[string]string{"id": "integer"},
+			QueryParams: map[string]string{"limit": "integer", "ratio": "number", "dry_run": "boolean"},
+			Request:     validationTestRequest{},
+			Strict:      strict,
+		}
+	}
+	return NewOpenAPIDocument("test", "1", "").AddEndpoints(
+		endpoint("/items/{id}", false),
+		endpoint("/strict/{id}", true),
+		Endpoint{Method: http.MethodGet, Path: "/items/{id}/parts/{part}"},
+		Endpoint{Method: http.MethodGet, Path: "/items/special/parts/{part}"},
+	)
+}
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		query string
+		body  string
+		want  []FieldError
+	}{
+		{"valid", "/items/1", "limit=10&ratio=0.5&dry_run=true", `{"name":"Ann","age":3,"active":true,"status":"active","born":"2020-01-31","tags":["a"],"address":{"city":"Oslo"},"metadata":{"a":1}}`, nil},
+		{"null optional field", "/items/1", "", `{"name":"Ann","email":null}`, nil},
+		{"empty optional formats", "/items/1", "", `{"name":"Ann","email":"","born":""}`, nil},
+		{"non-integer path parameter", "/items/abc", "", `{"name":"Ann"}`, []FieldError{
+			{In: "path", Field: "id", Message: "must be an integer"},
+		}},
+		{"query types", "/items/1", "limit=ten&ratio=half&dry_run=maybe", `{"name":"Ann"}`, []FieldError{
+			{In: "query", Field: "dry_run", Message: "must be true or false"},
+			{In: "query", Field: "limit", Message: "must be an integer"},
+			{In: "query", Field: "ratio", Message: "must be a number"},
+		}},
+		{"missing body", "/items/1", "", "  ", []FieldError{
+			{In: "body", Message: "request body is required"},
+		}},
+		{"malformed body", "/items/1", "", `{"name":`, []FieldError{
+			{In: "body", Message: "malformed JSON: unexpected EOF"},
+		}},
+		{"trailing data", "/items/1", "", `{"name":"Ann"} {}`, []FieldError{
+			{In: "body", Message: "malformed JSON: unexpected data after the top-level value"},
+		}},
+		{"body not an object", "/items/1", "", `["Ann"]`, []FieldError{
+			{In: "body", Message: "must be an object"},
+		}},
+		{"required fields", "/items/1", "", `{"name":null,"address":{}}`, []FieldError{
+			{In: "body", Field: "name", Message: "is required"},
+			{In: "body", Field: "address.city", Message: "is required"},
+		}},
+		{"strings are not coerced", "/items/1", "", `{"name":"Ann","age":"3","active":"true","tags":"a"}`, []FieldError{
+			{In: "body", Field: "active", Message: "must be true or false"},
+			{In: "body", Field: "age", Message: "must be an integer"},
+			{In: "body", Field: "tags", Message: "must be an array"},
+		}},
+		{"fractional integer", "/items/1", "", `{"name":"Ann","age":1.5}`, []FieldError{
+			{In: "body", Field: "age", Message: "must be an integer"},
+		}},
+		{"constraints", "/items/1", "", `{"name":"Annabel","email":"ann","age":0,"status":"gone","born":"31/01/2020","tags":[1],"metadata":{"a":"x"}}`, []FieldError{
+			{In: "body", Field: "age", Message: "must be at least 1"},
+			{In: "body", Field: "born", Message: "must be a date in YYYY-MM-DD format"},
+			{In: "body", Field: "email", Message: "must be a valid email address"},
+			{In: "body", Field: "metadata.a", Message: "must be an integer"},
+			{In: "body", Field: "name", Message: "must be at most 5 characters"},
+			{In: "body", Field: "status", Message: "must be one of active, inactive"},
+			{In: "body", Field: "tags[0]", Message: "must be a string"},
+		}},
+		{"empty required string", "/items/1", "", `{"name":""}`, []FieldError{
+			{In: "body", Field: "name", Message: "must not be empty"},
+		}},
+		{"unknown fields allowed", "/items/1", "extra=1", `{"name":"Ann","nickname":"A","address":{"city":"Oslo","zip":"1"}}`, nil},
+		{"strict unknown fields", "/strict/1", "", `{"name":"Ann","nickname":"A","address":{"city":"Oslo","zip":"1"}}`, []FieldError{
+			{In: "body", Field: "address.zip", Message: "is not a known field"},
+			{In: "body", Field: "nickname", Message: "is not a known field"},
+		}},
+		{"strict map keys", "/strict/1", "", `{"name":"Ann","metadata":{"anything":1}}`, nil},
+		{"strict unknown query parameters", "/strict/1", "limit=1&sort=name&extra=1", `{"name":"Ann"}`, []FieldError{
+			{In: "query", Field: "extra", Message: "is not a known parameter"},
+			{In: "query", Field: "sort", Message: "is not a known parameter"},
+		}},
+	}
+	doc := validationTestDocument()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, params, ok := doc.FindOperation(http.MethodPut, tt.path)
+			if !ok {
+				t.Fatalf("no operation for %s", tt.path)
+			}
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := doc.ValidateRequest(op, params, query, []byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %+v\nwant     %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindOperationPrefersLiteralSegments(t *testing.T) {
+	doc := validationTestDocument()
+	tests := []struct {
+		path       string
+		wantPath   string
+		wantParams map[string]string
+	}{
+		{"/items/7/parts/a%20b", "/items/{id}/parts/{part}", map[string]string{"id": "7", "part": "a b"}},
+		{"/items/special/parts/x", "/items/special/parts/{part}", map[string]string{"part": "x"}},
+	}
+	for _, tt := range tests {
+		op, params, ok := doc.FindOperation(http.MethodGet, tt.path)
+		if !ok {
+			t.Errorf("%s: no operation", tt.path)
+			continue
+		}
+		if want := operationID(http.MethodGet, tt.wantPath); op.OperationID != want {
+			t.Errorf("%s: operation = %s, want %s", tt.path, op.OperationID, want)
+		}
+		if !reflect.DeepEqual(params, tt.wantParams) {
+			t.Errorf("%s: params = %v, want %v", tt.path, params, tt.wantParams)
+		}
+	}
+	if _, _, ok := doc.FindOperation(http.MethodGet, "/items//parts/x"); ok {
+		t.Error("an empty path parameter matched")
+	}
+	if _, _, ok := doc.FindOperation(http.MethodDelete, "/items/1"); ok {
+		t.Error("an undocumented method matched")
+	}
+}
@@ -0,0 +1,273 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API version headers. Clients pick a version with Accept-Version or a /v1,
+// /v2 path prefix; responses name the version that served them.
+const (
+	HeaderAcceptVersion = "Accept-Version"
+	HeaderAPIVersion    = "X-API-Version"
+	// HeaderClientID optionally identifies the calling app in deprecation logs
+	HeaderClientID = "X-Client-ID"
+)
+
+// API versions
+const (
+	APIVersion1 = "v1"
+	APIVersion2 = "v2"
+)
+
+// Defaults applied to zero valued APIVersionConfig fields
+var DefaultAPIVersions = []string{APIVersion1, APIVersion2}
+
+const (
+	DefaultAPIVersion             = APIVersion1
+	DefaultDeprecationLogInterval = time.Hour
+)
+
+// maxDeprecatedUsageClients bounds the clients tracked for usage logs
+const maxDeprecatedUsageClients = 10000
+
+// APIDeprecation schedules the retirement of an API version
+type APIDeprecation struct {
+	// Since is when the version was deprecated, sent in the Deprecation header
+	Since time.Time
+	// Sunset is when the version stops being served; requests after it get
+	// 410 API_VERSION_RETIRED. Zero means no date has been set.
+	Sunset time.Time
+	// Link points at migration notes, sent as a rel="deprecation" Link
+	Link string
+}
+
+// APIVersionConfig lists the versions served and their deprecation schedule
+type APIVersionConfig struct {
+	Supported []string
+	// Default serves requests that name no version
+	Default    string
+	Deprecated map[string]APIDeprecation
+	// LogInterval limits deprecated usage logs to one per client and version
+	// per interval; each log reports the requests counted since the last one
+	LogInterval time.Duration
+}
+
+// APIVersioning resolves the API version of each request
+type APIVersioning struct {
+	config    APIVersionConfig
+	supported map[string]bool
+
+	mutex sync.Mutex
+	usage map[string]*deprecatedUsage
+}
+
+type deprecatedUsage struct {
+	loggedAt time.Time
+	requests int
+}
+
+// NewAPIVersioning creates the version resolver
+func NewAPIVersioning(config APIVersionConfig) *APIVersioning {
+	if len(config.Supported) == 0 {
+		config.Supported = DefaultAPIVersions
+	}
+	if config.Default == "" {
+		config.Default = DefaultAPIVersion
+	}
+	if config.LogInterval <= 0 {
+		config.LogInterval = DefaultDeprecationLogInterval
+	}
+	v := &APIVersioning{
+		config:    config,
+		supported: make(map[string]bool),
+		usage:     make(map[string]*deprecatedUsage),
+	}
+	for _, version := range config.Supported {
+		v.supported[version] = true
+	}
+	return v
+}
+
+type apiVersionKey struct{}
+
+// WithAPIVersion returns a context carrying version
+func WithAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+// APIVersionFromContext returns the version resolved for the request, or
+// DefaultAPIVersion outside a versioned handler
+func APIVersionFromContext(ctx context.Context) string {
+	if version, ok := ctx.Value(apiVersionKey{}).(string); ok {
+		return version
+	}
+	return DefaultAPIVersion
+}
+
+// ParseAPIVersion normalises "2", "v2" and "V2" to "v2"
+func ParseAPIVersion(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "v")
+	if s == "" {
+		return "", false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return "v" + s, true
+}
+
+// Handler resolves the version from a /vN path prefix, which is stripped,
+// or the Accept-Version header, and stores it in the request context and in
+// Accept-Version so proxied requests carry it. Deprecated versions get
+// Deprecation, Sunset and Link headers; retired ones are refused.
+func (v *APIVersioning) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version, ok := v.resolve(w, r)
+		if !ok {
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", HeaderAcceptVersion)
+		h.Set(HeaderAPIVersion, version)
+
+		if deprecation, deprecated := v.config.Deprecated[version]; deprecated {
+			apiVersionRequests.WithLabelValues(version, "true").Inc()
+			if !deprecation.Sunset.IsZero() && !time.Now().Before(deprecation.Sunset) {
+				WriteErrorResponse(w, http.StatusGone, "API_VERSION_RETIRED",
+					fmt.Sprintf("API %s was retired on %s; use %s", version, deprecation.Sunset.Format(time.DateOnly), v.latest()))
+				return
+			}
+			v.setDeprecationHeaders(h, deprecation)
+			v.logDeprecatedUsage(r, version)
+		} else {
+			apiVersionRequests.WithLabelValues(version, "false").Inc()
+		}
+
+		r.Header.Set(HeaderAcceptVersion, version)
+		next.ServeHTTP(w, r.WithContext(WithAPIVersion(r.Context(), version)))
+	})
+}
+
+// resolve works out the request's version, writing an error response when
+// it names an unsupported version or two different ones
+func (v *APIVersioning) resolve(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var fromPath, fromHeader string
+
+	segment, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if version, ok := ParseAPIVersion(segment); ok && strings.HasPrefix(segment, "v") {
+		fromPath = version
+		r.URL.Path = "/" + rest
+		r.URL.RawPath = ""
+	}
+
+	if header := r.Header.Get(HeaderAcceptVersion); header != "" {
+		version, ok := ParseAPIVersion(header)
+		if !ok {
+			WriteErrorResponse(w, http.StatusBadRequest, "INVALID_API_VERSION", "Accept-Version must look like v1 or 1")
+			return "", false
+		}
+		fromHeader = version
+	}
+
+	version := v.config.Default
+	switch {
+	case fromPath != "" && fromHeader != "" && fromPath != fromHeader:
+		WriteErrorResponse(w, http.StatusBadRequest, "API_VERSION_CONFLICT",
+			fmt.Sprintf("Path requests %s but Accept-Version requests %s", fromPath, fromHeader))
+		return "", false
+	case fromPath != "":
+		version = fromPath
+	case fromHeader != "":
+		version = fromHeader
+	}
+
+	if !v.supported[version] {
+		WriteErrorResponse(w, http.StatusBadRequest, "UNSUPPORTED_API_VERSION",
+			fmt.Sprintf("API %s is not supported; supported versions: %s", version, strings.Join(v.config.Supported, ", ")))
+		return "", false
+	}
+	return version, true
+}
+
+// latest returns the newest supported version that is not deprecated
+func (v *APIVersioning) latest() string {
+	latest := v.config.Default
+	for _, version := range v.config.Supported {
+		if _, deprecated := v.config.Deprecated[version]; !deprecated {
+			latest = version
+		}
+	}
+	return latest
+}
+
+func (v *APIVersioning) setDeprecationHeaders(h http.Header, deprecation APIDeprecation) {
+	if deprecation.Since.IsZero() {
+		h.Set("Deprecation", "true")
+	} else {
+		// RFC 9745 structured date
+		h.Set("Deprecation", fmt.Sprintf("@%d", deprecation.Since.Unix()))
+	}
+	if !deprecation.Sunset.IsZero() {
+		h.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if deprecation.Link != "" {
+		h.Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"; type=\"text/html\"", deprecation.Link))
+	}
+}
+
+// logDeprecatedUsage logs the first request of each client on a deprecated
+// version, then at most once per LogInterval with the number of requests
+// made in between
+func (v *APIVersioning) logDeprecatedUsage(r *http.Request, version string) {
+	client := r.Header.Get(HeaderClientID)
+	if client == "" {
+		client = r.UserAgent()
+	}
+	if client == "" {
+		client = "unknown"
+	}
+	key := version + "|" + client
+	now := time.Now()
+
+	v.mutex.Lock()
+	usage, ok := v.usage[key]
+	if !ok {
+		if len(v.usage) >= maxDeprecatedUsageClients {
+			v.usage = make(map[string]*deprecatedUsage)
+		}
+		usage = &deprecatedUsage{}
+		v.usage[key] = usage
+	}
+	usage.requests++
+	if ok && now.Sub(usage.loggedAt) < v.config.LogInterval {
+		v.mutex.Unlock()
+		return
+	}
+	requests := usage.requests
+	usage.requests = 0
+	usage.loggedAt = now
+	v.mutex.Unlock()
+
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+	slog.WarnContext(r.Context(), "deprecated API version used",
+		"api_version", version,
+		"client", client,
+		"remote_ip", remoteIP,
+		"path", r.URL.Path,
+		"requests", requests,
+	)
+}
@@ -0,0 +1,145 @@
+package shared
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"v2", "v2", true},
+		{"V2", "v2", true},
+		{" 2 ", "v2", true},
+		{"v", "", false},
+		{"", "", false},
+		{"v2.1", "", false},
+		{"latest", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseAPIVersion(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseAPIVersion(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// serveVersioned runs a request through v and reports the version and path
+// the next handler saw, if it was reached
+func serveVersioned(v *APIVersioning, path, acceptVersion string) (rec *httptest.ResponseRecorder, version, gotPath string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptVersion != "" {
+		req.Header.Set(HeaderAcceptVersion, acceptVersion)
+	}
+	rec = httptest.NewRecorder()
+	v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version = APIVersionFromContext(r.Context())
+		gotPath = r.URL.Path
+		if r.Header.Get(HeaderAcceptVersion) != version {
+			w.WriteHeader(http.StatusTeapot)
+		}
+	})).ServeHTTP(rec, req)
+	return rec, version, gotPath
+}
+
+func TestAPIVersioningResolves(t *testing.T) {
+	v := NewAPIVersioning(APIVersionConfig{})
+	tests := []struct {
+		name          string
+		path          string
+		acceptVersion string
+		wantCode      int
+		wantErr       string
+		wantVersion   string
+		wantPath      string
+	}{
+		{"default", "/schools", "", http.StatusOK, "", "v1", "/schools"},
+		{"path prefix", "/v2/schools/5", "", http.StatusOK, "", "v2", "/schools/5"},
+		{"bare prefix", "/v2", "", http.StatusOK, "", "v2", "/"},
+		{"header", "/schools", "2", http.StatusOK, "", "v2", "/schools"},
+		{"path and header agree", "/v2/schools", "v2", http.StatusOK, "", "v2", "/schools"},
+		// Neither source silently wins over the other
+		{"path and header differ", "/v1/schools", "v2", http.StatusBadRequest, "API_VERSION_CONFLICT", "", ""},
+		{"unknown path version", "/v9/schools", "", http.StatusBadRequest, "UNSUPPORTED_API_VERSION", "", ""},
+		{"unknown header version", "/schools", "v9", http.StatusBadRequest, "UNSUPPORTED_API_VERSION", "", ""},
+		{"malformed header", "/schools", "latest", http.StatusBadRequest, "INVALID_API_VERSION", "", ""},
+		// Only a v-prefixed segment is a version; numeric IDs are not
+		{"numeric segment", "/2/schools", "", http.StatusOK, "", "v1", "/2/schools"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, version, path := serveVersioned(v, tt.path, tt.acceptVersion)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantErr != "" {
+				if !strings.Contains(rec.Body.String(), tt.wantErr) || version != "" {
+					t.Errorf("body = %s, reached handler %v; want %s", rec.Body, version != "", tt.wantErr)
+				}
+				return
+			}
+			if version != tt.wantVersion || path != tt.wantPath {
+				t.Errorf("handler saw %s %s, want %s %s", version, path, tt.wantVersion, tt.wantPath)
+			}
+			if rec.Header().Get(HeaderAPIVersion) != tt.wantVersion || rec.Header().Get("Vary") != HeaderAcceptVersion {
+				t.Errorf("headers = %v", rec.Header())
+			}
+		})
+	}
+}
+
+func TestAPIVersioningDeprecation(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	v := NewAPIVersioning(APIVersionConfig{Deprecated: map[string]APIDeprecation{
+		APIVersion1: {Since: since, Sunset: sunset, Link: "https://docs.example.com/v2"},
+	}})
+
+	rec, version, _ := serveVersioned(v, "/v1/schools", "")
+	if rec.Code != http.StatusOK || version != APIVersion1 {
+		t.Fatalf("deprecated version = %d %s, want it served", rec.Code, version)
+	}
+	want := map[string]string{
+		"Deprecation": "@1704067200",
+		"Sunset":      sunset.UTC().Format(http.TimeFormat),
+		"Link":        `<https://docs.example.com/v2>; rel="deprecation"; type="text/html"`,
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	rec, _, _ = serveVersioned(v, "/v2/schools", "")
+	for name := range want {
+		if got := rec.Header().Get(name); got != "" {
+			t.Errorf("current version got %s %q", name, got)
+		}
+	}
+
+	// Without dates the version is only flagged
+	v = NewAPIVersioning(APIVersionConfig{Deprecated: map[string]APIDeprecation{APIVersion1: {}}})
+	rec, _, _ = serveVersioned(v, "/schools", "")
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Sunset") != "" {
+		t.Errorf("undated deprecation headers = %v", rec.Header())
+	}
+}
+
+func TestAPIVersioningRefusesRetiredVersions(t *testing.T) {
+	v := NewAPIVersioning(APIVersionConfig{Deprecated: map[string]APIDeprecation{
+		APIVersion1: {Sunset: time.Now().Add(-time.Minute)},
+	}})
+	rec, version, _ := serveVersioned(v, "/v1/schools", "")
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "API_VERSION_RETIRED") || version != "" {
+		t.Fatalf("retired version = %d %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "use v2") {
+		t.Errorf("body = %s, want it to name v2", rec.Body)
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
+	LogLevel          string
 	HTTPPort          string
 	GRPCPort          string
 	DBHost            string
@@ -11,11 +16,32 @@ type Config struct {
 	DBPassword        string
 	DBName            string
 	JWTSecret         string
+	IdentitySecret    string
 	SchoolServiceGRPC string
+	// TLS for the service's listeners; a client CA turns on mutual TLS
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string
+	TLSReloadInterval time.Duration
+	// TLS for calls to internal services; the certificate is presented for mutual TLS
+	UpstreamTLSCAFile   string
+	UpstreamTLSCertFile string
+	UpstreamTLSKeyFile  string
+	// HTTP server timeouts and shutdown sequence
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	DrainDelay      time.Duration
+	// Change events kept for the gateway to resume from, and the heartbeat
+	// interval of the event stream
+	EventLogSize   int
+	EventHeartbeat time.Duration
 }
 
 func Load() *Config {
 	return &Config{
+		LogLevel:          getEnv("LOG_LEVEL", "info"),
 		HTTPPort:          getEnv("HTTP_PORT", "8083"),
 		GRPCPort:          getEnv("GRPC_PORT", "50052"),
 		DBHost:            getEnv("DB_HOST", "localhost"),
@@ -24,7 +50,25 @@ func Load() *Config {
 		DBPassword:        getEnv("DB_PASSWORD", "studentpass"),
 		DBName:            getEnv("DB_NAME", "studentdb"),
 		JWTSecret:         getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+		IdentitySecret:    getEnv("IDENTITY_SECRET", "your-super-secret-identity-key-change-in-production"),
 		SchoolServiceGRPC: getEnv("SCHOOL_SERVICE_GRPC", "localhost:50051"),
+
+		TLSCertFile:         getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:          getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile:     getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSReloadInterval:   getDurationEnv("TLS_RELOAD_INTERVAL", 30*time.Second),
+		UpstreamTLSCAFile:   getEnv("UPSTREAM_TLS_CA_FILE", ""),
+		UpstreamTLSCertFile: getEnv("UPSTREAM_TLS_CERT_FILE", ""),
+		UpstreamTLSKeyFile:  getEnv("UPSTREAM_TLS_KEY_FILE", ""),
+
+		ReadTimeout:     getDurationEnv("HTTP_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:    getDurationEnv("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:     getDurationEnv("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 15*time.Second),
+		DrainDelay:      getDurationEnv("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+
+		EventLogSize:   getIntEnv("EVENT_LOG_SIZE", 1000),
+		EventHeartbeat: getDurationEnv("EVENT_HEARTBEAT_INTERVAL", 15*time.Second),
 	}
 }
 
@@ -39,3 +83,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
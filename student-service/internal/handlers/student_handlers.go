@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -27,9 +29,15 @@ func (h *StudentHandlers) CreateStudent(w http.ResponseWriter, r *http.Request)
 		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
 		return
 	}
+	if req.SchoolID != 0 && !shared.CheckSchoolAccess(w, r, req.SchoolID) {
+		return
+	}
 
-	student, err := h.studentService.CreateStudent(&req)
+	student, err := h.studentService.CreateStudent(r.Context(), &req)
 	if err != nil {
+		if writeSchoolServiceError(w, err) {
+			return
+		}
 		switch err.Error() {
 		case "roll number, first name, last name, and school ID are required":
 			shared.WriteErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
@@ -42,7 +50,7 @@ func (h *StudentHandlers) CreateStudent(w http.ResponseWriter, r *http.Request)
 		case "failed to validate school":
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "VALIDATION_ERROR", err.Error())
 		default:
-			shared.LogError("STUDENT_SERVICE", "create student", err)
+			slog.ErrorContext(r.Context(), "create student failed", "error", err)
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create student")
 		}
 		return
@@ -52,16 +60,53 @@ func (h *StudentHandlers) CreateStudent(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *StudentHandlers) GetStudents(w http.ResponseWriter, r *http.Request) {
-	students, err := h.studentService.GetAllStudents()
+	// ?school_ids=1,2,3 fetches the students of several schools in one call
+	schoolIDs, fields := shared.ParseIDList(r.URL.Query(), "school_ids")
+	if len(fields) > 0 {
+		shared.WriteValidationErrorResponse(w, fields)
+		return
+	}
+
+	// Callers limited to some schools only ever see those schools' students
+	schoolIDs = shared.ScopeSchoolIDs(r.Context(), schoolIDs)
+
+	var students []models.Student
+	var err error
+	if schoolIDs != nil {
+		students, err = h.studentService.GetStudentsBySchoolIDs(r.Context(), schoolIDs)
+	} else {
+		students, err = h.studentService.GetAllStudents(r.Context())
+	}
 	if err != nil {
-		shared.LogError("STUDENT_SERVICE", "get students", err)
+		slog.ErrorContext(r.Context(), "get students failed", "error", err)
 		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get students")
 		return
 	}
 
+	if shared.NotModified(w, r, studentsETag(students...)) {
+		return
+	}
 	shared.WriteSuccessResponse(w, http.StatusOK, "Students retrieved successfully", students)
 }
 
+// ListStudents serves GET /students in API v2 with a paginated envelope
+func (h *StudentHandlers) ListStudents(w http.ResponseWriter, r *http.Request) {
+	page, fields := shared.ParsePageRequest(r.URL.Query())
+	if len(fields) > 0 {
+		shared.WriteValidationErrorResponse(w, fields)
+		return
+	}
+
+	students, total, err := h.studentService.ListStudents(r.Context(), shared.ScopeSchoolIDs(r.Context(), nil), page)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "list students failed", "error", err)
+		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get students")
+		return
+	}
+
+	writeStudentPage(w, r, students, shared.NewPagination(page, total))
+}
+
 func (h *StudentHandlers) GetStudent(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/students/")
 	id, err := strconv.Atoi(idStr)
@@ -70,17 +115,23 @@ func (h *StudentHandlers) GetStudent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	student, err := h.studentService.GetStudentByID(id)
+	student, err := h.studentService.GetStudentByID(r.Context(), id)
 	if err != nil {
 		if err.Error() == "student not found" {
 			shared.WriteErrorResponse(w, http.StatusNotFound, "STUDENT_NOT_FOUND", err.Error())
 			return
 		}
-		shared.LogError("STUDENT_SERVICE", "get student", err)
+		slog.ErrorContext(r.Context(), "get student failed", "error", err)
 		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get student")
 		return
 	}
+	if !shared.CheckSchoolAccess(w, r, student.SchoolID) {
+		return
+	}
 
+	if shared.NotModified(w, r, studentsETag(*student)) {
+		return
+	}
 	shared.WriteSuccessResponse(w, http.StatusOK, "Student retrieved successfully", student)
 }
 
@@ -91,24 +142,72 @@ func (h *StudentHandlers) GetStudentsBySchool(w http.ResponseWriter, r *http.Req
 		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_SCHOOL_ID", "Invalid school ID")
 		return
 	}
+	if !shared.CheckSchoolAccess(w, r, schoolID) {
+		return
+	}
 
-	students, _, err := h.studentService.GetStudentsBySchoolID(schoolID)
+	students, _, err := h.studentService.GetStudentsBySchoolID(r.Context(), schoolID)
 	if err != nil {
+		if writeSchoolServiceError(w, err) {
+			return
+		}
 		switch err.Error() {
 		case "school not found":
 			shared.WriteErrorResponse(w, http.StatusNotFound, "SCHOOL_NOT_FOUND", err.Error())
 		case "failed to validate school":
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "VALIDATION_ERROR", err.Error())
 		default:
-			shared.LogError("STUDENT_SERVICE", "get students by school", err)
+			slog.ErrorContext(r.Context(), "get students by school failed", "error", err)
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get students")
 		}
 		return
 	}
 
+	if shared.NotModified(w, r, studentsETag(students...)) {
+		return
+	}
 	shared.WriteSuccessResponse(w, http.StatusOK, "Students retrieved successfully", students)
 }
 
+// ListStudentsBySchool serves GET /students/school/{school_id} in API v2
+// with a paginated envelope
+func (h *StudentHandlers) ListStudentsBySchool(w http.ResponseWriter, r *http.Request) {
+	schoolIDStr := strings.TrimPrefix(r.URL.Path, "/students/school/")
+	schoolID, err := strconv.Atoi(schoolIDStr)
+	if err != nil {
+		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_SCHOOL_ID", "Invalid school ID")
+		return
+	}
+	if !shared.CheckSchoolAccess(w, r, schoolID) {
+		return
+	}
+
+	page, fields := shared.ParsePageRequest(r.URL.Query())
+	if len(fields) > 0 {
+		shared.WriteValidationErrorResponse(w, fields)
+		return
+	}
+
+	students, total, err := h.studentService.ListStudentsBySchoolID(r.Context(), schoolID, page)
+	if err != nil {
+		if writeSchoolServiceError(w, err) {
+			return
+		}
+		switch err.Error() {
+		case "school not found":
+			shared.WriteErrorResponse(w, http.StatusNotFound, "SCHOOL_NOT_FOUND", err.Error())
+		case "failed to validate school":
+			shared.WriteErrorResponse(w, http.StatusInternalServerError, "VALIDATION_ERROR", err.Error())
+		default:
+			slog.ErrorContext(r.Context(), "list students by school failed", "error", err)
+			shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get students")
+		}
+		return
+	}
+
+	writeStudentPage(w, r, students, shared.NewPagination(page, total))
+}
+
 func (h *StudentHandlers) UpdateStudent(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/students/")
 	id, err := strconv.Atoi(idStr)
@@ -122,9 +221,16 @@ func (h *StudentHandlers) UpdateStudent(w http.ResponseWriter, r *http.Request)
 		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
 		return
 	}
+	// Moving a student needs access to both schools
+	if !h.checkStudentAccess(w, r, id) || (req.SchoolID != 0 && !shared.CheckSchoolAccess(w, r, req.SchoolID)) {
+		return
+	}
 
-	student, err := h.studentService.UpdateStudent(id, &req)
+	student, err := h.studentService.UpdateStudent(r.Context(), id, &req)
 	if err != nil {
+		if writeSchoolServiceError(w, err) {
+			return
+		}
 		switch err.Error() {
 		case "roll number, first name, last name, and school ID are required":
 			shared.WriteErrorResponse(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
@@ -139,7 +245,7 @@ func (h *StudentHandlers) UpdateStudent(w http.ResponseWriter, r *http.Request)
 		case "failed to validate school":
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "VALIDATION_ERROR", err.Error())
 		default:
-			shared.LogError("STUDENT_SERVICE", "update student", err)
+			slog.ErrorContext(r.Context(), "update student failed", "error", err)
 			shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update student")
 		}
 		return
@@ -155,14 +261,17 @@ func (h *StudentHandlers) DeleteStudent(w http.ResponseWriter, r *http.Request)
 		shared.WriteErrorResponse(w, http.StatusBadRequest, "INVALID_ID", "Invalid student ID")
 		return
 	}
+	if !h.checkStudentAccess(w, r, id) {
+		return
+	}
 
-	err = h.studentService.DeleteStudent(id)
+	err = h.studentService.DeleteStudent(r.Context(), id)
 	if err != nil {
 		if err.Error() == "student not found" {
 			shared.WriteErrorResponse(w, http.StatusNotFound, "STUDENT_NOT_FOUND", err.Error())
 			return
 		}
-		shared.LogError("STUDENT_SERVICE", "delete student", err)
+		slog.ErrorContext(r.Context(), "delete student failed", "error", err)
 		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete student")
 		return
 	}
@@ -170,6 +279,60 @@ func (h *StudentHandlers) DeleteStudent(w http.ResponseWriter, r *http.Request)
 	shared.WriteSuccessResponse(w, http.StatusOK, "Student deleted successfully", nil)
 }
 
-func (h *StudentHandlers) Health(w http.ResponseWriter, r *http.Request) {
-	shared.WriteSuccessResponse(w, http.StatusOK, "Student service is healthy", nil)
+// checkStudentAccess answers 404 or 403 unless the caller may access the
+// school of student id, and reports whether it may. Admins access every
+// school, so their requests skip the lookup.
+func (h *StudentHandlers) checkStudentAccess(w http.ResponseWriter, r *http.Request, id int) bool {
+	if identity, ok := shared.IdentityFromContext(r.Context()); ok && identity.HasRole(shared.RoleAdmin) {
+		return true
+	}
+
+	schoolID, err := h.studentService.GetStudentSchoolID(r.Context(), id)
+	if err != nil {
+		if err.Error() == "student not found" {
+			shared.WriteErrorResponse(w, http.StatusNotFound, "STUDENT_NOT_FOUND", err.Error())
+			return false
+		}
+		slog.ErrorContext(r.Context(), "get student school failed", "error", err)
+		shared.WriteErrorResponse(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get student")
+		return false
+	}
+	return shared.CheckSchoolAccess(w, r, schoolID)
+}
+
+// studentsETag versions a response by the students' IDs and update times.
+// The school name is joined from the school service, so a rename changes
+// the response without touching the student.
+func studentsETag(students ...models.Student) string {
+	return addStudents(shared.NewETagBuilder(), students).ETag()
+}
+
+func addStudents(etag *shared.ETagBuilder, students []models.Student) *shared.ETagBuilder {
+	for _, student := range students {
+		etag.Add(student.ID, student.UpdatedAt, student.SchoolName)
+	}
+	return etag
+}
+
+// writeStudentPage answers conditional requests for a page, whose tag also
+// covers its position in the list, or writes the v2 envelope
+func writeStudentPage(w http.ResponseWriter, r *http.Request, students []models.Student, pagination shared.Pagination) {
+	if shared.NotModified(w, r, addStudents(shared.NewETagBuilder(), students).AddPagination(pagination).ETag()) {
+		return
+	}
+	shared.WriteSuccessResponse(w, http.StatusOK, "Students retrieved successfully", models.StudentPage{Items: students, Pagination: pagination})
+}
+
+// writeSchoolServiceError answers with a retryable 503 when a school lookup
+// could not reach the school service, and reports whether it did
+func writeSchoolServiceError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrSchoolServiceBusy):
+		shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "SCHOOL_SERVICE_BUSY", service.ErrSchoolServiceBusy.Error())
+	case errors.Is(err, service.ErrSchoolServiceUnavailable):
+		shared.WriteErrorResponse(w, http.StatusServiceUnavailable, "SCHOOL_SERVICE_UNAVAILABLE", service.ErrSchoolServiceUnavailable.Error())
+	default:
+		return false
+	}
+	return true
 }
@@ -1,46 +1,56 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"skool-management/shared"
+)
 
 type Student struct {
-	ID             int       `json:"id" db:"id"`
+	ID             int       `json:"id" db:"id" jsonschema:"readonly"`
 	RollNumber     string    `json:"roll_number" db:"roll_number"`
 	FirstName      string    `json:"first_name" db:"first_name"`
 	LastName       string    `json:"last_name" db:"last_name"`
-	Email          string    `json:"email" db:"email"`
+	Email          string    `json:"email" db:"email" jsonschema:"format=email"`
 	Phone          string    `json:"phone" db:"phone"`
 	DateOfBirth    string    `json:"date_of_birth" db:"date_of_birth"`
 	Address        string    `json:"address" db:"address"`
 	SchoolID       int       `json:"school_id" db:"school_id"`
-	SchoolName     string    `json:"school_name,omitempty"`
+	SchoolName     string    `json:"school_name,omitempty" jsonschema:"readonly"`
 	EnrollmentDate string    `json:"enrollment_date" db:"enrollment_date"`
 	Status         string    `json:"status" db:"status"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at" jsonschema:"readonly"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at" jsonschema:"readonly"`
+}
+
+// StudentPage is the v2 list response
+type StudentPage struct {
+	Items      []Student         `json:"items"`
+	Pagination shared.Pagination `json:"pagination"`
 }
 
 type CreateStudentRequest struct {
-	RollNumber     string `json:"roll_number"`
-	FirstName      string `json:"first_name"`
-	LastName       string `json:"last_name"`
-	Email          string `json:"email"`
+	RollNumber     string `json:"roll_number" jsonschema:"required,minLength=1"`
+	FirstName      string `json:"first_name" jsonschema:"required,minLength=1"`
+	LastName       string `json:"last_name" jsonschema:"required,minLength=1"`
+	Email          string `json:"email" jsonschema:"format=email"`
 	Phone          string `json:"phone"`
-	DateOfBirth    string `json:"date_of_birth"`
+	DateOfBirth    string `json:"date_of_birth" jsonschema:"format=date"`
 	Address        string `json:"address"`
-	SchoolID       int    `json:"school_id"`
-	EnrollmentDate string `json:"enrollment_date"`
-	Status         string `json:"status"`
+	SchoolID       int    `json:"school_id" jsonschema:"required,minimum=1"`
+	EnrollmentDate string `json:"enrollment_date" jsonschema:"format=date"`
+	Status         string `json:"status" jsonschema:"description=Defaults to active"`
 }
 
 type UpdateStudentRequest struct {
-	RollNumber     string `json:"roll_number"`
-	FirstName      string `json:"first_name"`
-	LastName       string `json:"last_name"`
-	Email          string `json:"email"`
+	RollNumber     string `json:"roll_number" jsonschema:"required,minLength=1"`
+	FirstName      string `json:"first_name" jsonschema:"required,minLength=1"`
+	LastName       string `json:"last_name" jsonschema:"required,minLength=1"`
+	Email          string `json:"email" jsonschema:"format=email"`
 	Phone          string `json:"phone"`
-	DateOfBirth    string `json:"date_of_birth"`
+	DateOfBirth    string `json:"date_of_birth" jsonschema:"format=date"`
 	Address        string `json:"address"`
-	SchoolID       int    `json:"school_id"`
-	EnrollmentDate string `json:"enrollment_date"`
-	Status         string `json:"status"`
+	SchoolID       int    `json:"school_id" jsonschema:"required,minimum=1"`
+	EnrollmentDate string `json:"enrollment_date" jsonschema:"format=date"`
+	Status         string `json:"status" jsonschema:"description=Defaults to active"`
 }
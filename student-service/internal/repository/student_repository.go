@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
+	"skool-management/shared"
 	"skool-management/student-service/internal/models"
+
+	"github.com/lib/pq"
 )
 
 type StudentRepository struct {
@@ -15,7 +19,7 @@ func NewStudentRepository(db *sql.DB) *StudentRepository {
 	return &StudentRepository{db: db}
 }
 
-func (r *StudentRepository) Create(student *models.CreateStudentRequest) (*models.Student, error) {
+func (r *StudentRepository) Create(ctx context.Context, student *models.CreateStudentRequest) (*models.Student, error) {
 	query := `
 		INSERT INTO students (roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
@@ -24,13 +28,15 @@ func (r *StudentRepository) Create(student *models.CreateStudentRequest) (*model
 
 	now := time.Now()
 	var result models.Student
-	err := r.db.QueryRow(query, student.RollNumber, student.FirstName, student.LastName,
-		student.Email, student.Phone, student.DateOfBirth, student.Address, student.SchoolID,
-		student.EnrollmentDate, student.Status, now, now).Scan(
-		&result.ID, &result.RollNumber, &result.FirstName, &result.LastName, &result.Email,
-		&result.Phone, &result.DateOfBirth, &result.Address, &result.SchoolID,
-		&result.EnrollmentDate, &result.Status, &result.CreatedAt, &result.UpdatedAt,
-	)
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "StudentRepository.Create", query, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, query, student.RollNumber, student.FirstName, student.LastName,
+			student.Email, student.Phone, student.DateOfBirth, student.Address, student.SchoolID,
+			student.EnrollmentDate, student.Status, now, now).Scan(
+			&result.ID, &result.RollNumber, &result.FirstName, &result.LastName, &result.Email,
+			&result.Phone, &result.DateOfBirth, &result.Address, &result.SchoolID,
+			&result.EnrollmentDate, &result.Status, &result.CreatedAt, &result.UpdatedAt,
+		)
+	})
 
 	if err != nil {
 		return nil, err
@@ -39,37 +45,40 @@ func (r *StudentRepository) Create(student *models.CreateStudentRequest) (*model
 	return &result, nil
 }
 
-func (r *StudentRepository) GetAll() ([]models.Student, error) {
+func (r *StudentRepository) GetAll(ctx context.Context) ([]models.Student, error) {
 	query := `
 		SELECT id, roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at
 		FROM students
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+	var students []models.Student
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "StudentRepository.GetAll", query, func(ctx context.Context) error {
+		var err error
+		students, err = r.queryStudents(ctx, query)
+		return err
+	})
+
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var students []models.Student
-	for rows.Next() {
-		var student models.Student
-		err := rows.Scan(
-			&student.ID, &student.RollNumber, &student.FirstName, &student.LastName, &student.Email,
-			&student.Phone, &student.DateOfBirth, &student.Address, &student.SchoolID,
-			&student.EnrollmentDate, &student.Status, &student.CreatedAt, &student.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		students = append(students, student)
-	}
 
 	return students, nil
 }
 
-func (r *StudentRepository) GetByID(id int) (*models.Student, error) {
+// GetPage returns one page of students, newest first, and the total number of students
+func (r *StudentRepository) GetPage(ctx context.Context, page shared.PageRequest) ([]models.Student, int, error) {
+	countQuery := `SELECT COUNT(*) FROM students`
+	query := `
+		SELECT id, roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at
+		FROM students
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+	return r.queryPage(ctx, "StudentRepository.GetPage", countQuery, nil, query, page)
+}
+
+func (r *StudentRepository) GetByID(ctx context.Context, id int) (*models.Student, error) {
 	query := `
 		SELECT id, roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at
 		FROM students
@@ -77,11 +86,13 @@ func (r *StudentRepository) GetByID(id int) (*models.Student, error) {
 	`
 
 	var student models.Student
-	err := r.db.QueryRow(query, id).Scan(
-		&student.ID, &student.RollNumber, &student.FirstName, &student.LastName, &student.Email,
-		&student.Phone, &student.DateOfBirth, &student.Address, &student.SchoolID,
-		&student.EnrollmentDate, &student.Status, &student.CreatedAt, &student.UpdatedAt,
-	)
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "StudentRepository.GetByID", query, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, query, id).Scan(
+			&student.ID, &student.RollNumber, &student.FirstName, &student.LastName, &student.Email,
+			&student.Phone, &student.DateOfBirth, &student.Address, &student.SchoolID,
+			&student.EnrollmentDate, &student.Status, &student.CreatedAt, &student.UpdatedAt,
+		)
+	})
 
 	if err != nil {
 		return nil, err
@@ -90,7 +101,7 @@ func (r *StudentRepository) GetByID(id int) (*models.Student, error) {
 	return &student, nil
 }
 
-func (r *StudentRepository) GetBySchoolID(schoolID int) ([]models.Student, error) {
+func (r *StudentRepository) GetBySchoolID(ctx context.Context, schoolID int) ([]models.Student, error) {
 	query := `
 		SELECT id, roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at
 		FROM students
@@ -98,65 +109,181 @@ func (r *StudentRepository) GetBySchoolID(schoolID int) ([]models.Student, error
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, schoolID)
+	var students []models.Student
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "StudentRepository.GetBySchoolID", query, func(ctx context.Context) error {
+		var err error
+		students, err = r.queryStudents(ctx, query, schoolID)
+		return err
+	})
+
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+
+	return students, nil
+}
+
+// GetBySchoolIDs returns the students of several schools, newest first
+func (r *StudentRepository) GetBySchoolIDs(ctx context.Context, schoolIDs []int) ([]models.Student, error) {
+	query := `
+		SELECT id, roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at
+		FROM students
+		WHERE school_id = ANY($1)
+		ORDER BY created_at DESC
+	`
 
 	var students []models.Student
-	for rows.Next() {
-		var student models.Student
-		err := rows.Scan(
-			&student.ID, &student.RollNumber, &student.FirstName, &student.LastName, &student.Email,
-			&student.Phone, &student.DateOfBirth, &student.Address, &student.SchoolID,
-			&student.EnrollmentDate, &student.Status, &student.CreatedAt, &student.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		students = append(students, student)
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "StudentRepository.GetBySchoolIDs", query, func(ctx context.Context) error {
+		var err error
+		students, err = r.queryStudents(ctx, query, pq.Array(schoolIDs))
+		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return students, nil
 }
 
-func (r *StudentRepository) Update(id int, student *models.UpdateStudentRequest) (*models.Student, error) {
+// GetPageBySchoolID returns one page of a school's students, newest first,
+// and the school's total number of students
+func (r *StudentRepository) GetPageBySchoolID(ctx context.Context, schoolID int, page shared.PageRequest) ([]models.Student, int, error) {
+	countQuery := `SELECT COUNT(*) FROM students WHERE school_id = $1`
+	query := `
+		SELECT id, roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at
+		FROM students
+		WHERE school_id = $3
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+	return r.queryPage(ctx, "StudentRepository.GetPageBySchoolID", countQuery, []interface{}{schoolID}, query, page)
+}
+
+// GetPageBySchoolIDs returns one page of the students of several schools,
+// newest first, and those schools' total number of students
+func (r *StudentRepository) GetPageBySchoolIDs(ctx context.Context, schoolIDs []int, page shared.PageRequest) ([]models.Student, int, error) {
+	countQuery := `SELECT COUNT(*) FROM students WHERE school_id = ANY($1)`
 	query := `
-		UPDATE students
+		SELECT id, roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at
+		FROM students
+		WHERE school_id = ANY($3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+	return r.queryPage(ctx, "StudentRepository.GetPageBySchoolIDs", countQuery, []interface{}{pq.Array(schoolIDs)}, query, page)
+}
+
+// GetSchoolID returns the school a student belongs to
+func (r *StudentRepository) GetSchoolID(ctx context.Context, id int) (int, error) {
+	query := `SELECT school_id FROM students WHERE id = $1`
+	var schoolID int
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "StudentRepository.GetSchoolID", query, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, query, id).Scan(&schoolID)
+	})
+	if err != nil {
+		// sql.ErrNoRows when there is no such student
+		return 0, err
+	}
+
+	return schoolID, nil
+}
+
+// Update replaces a student's fields and also returns the school the
+// student belonged to before, which differs when the student moved
+func (r *StudentRepository) Update(ctx context.Context, id int, student *models.UpdateStudentRequest) (*models.Student, int, error) {
+	query := `
+		WITH previous AS (SELECT school_id FROM students WHERE id = $12 FOR UPDATE)
+		UPDATE students s
 		SET roll_number = $1, first_name = $2, last_name = $3, email = $4, phone = $5, date_of_birth = $6,
 		    address = $7, school_id = $8, enrollment_date = $9, status = $10, updated_at = $11
-		WHERE id = $12
-		RETURNING id, roll_number, first_name, last_name, email, phone, date_of_birth, address, school_id, enrollment_date, status, created_at, updated_at
+		FROM previous
+		WHERE s.id = $12
+		RETURNING s.id, s.roll_number, s.first_name, s.last_name, s.email, s.phone, s.date_of_birth, s.address, s.school_id, s.enrollment_date, s.status, s.created_at, s.updated_at, previous.school_id
 	`
 
 	var result models.Student
-	err := r.db.QueryRow(query, student.RollNumber, student.FirstName, student.LastName,
-		student.Email, student.Phone, student.DateOfBirth, student.Address, student.SchoolID,
-		student.EnrollmentDate, student.Status, time.Now(), id).Scan(
-		&result.ID, &result.RollNumber, &result.FirstName, &result.LastName, &result.Email,
-		&result.Phone, &result.DateOfBirth, &result.Address, &result.SchoolID,
-		&result.EnrollmentDate, &result.Status, &result.CreatedAt, &result.UpdatedAt,
-	)
+	var previousSchoolID int
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "StudentRepository.Update", query, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, query, student.RollNumber, student.FirstName, student.LastName,
+			student.Email, student.Phone, student.DateOfBirth, student.Address, student.SchoolID,
+			student.EnrollmentDate, student.Status, time.Now(), id).Scan(
+			&result.ID, &result.RollNumber, &result.FirstName, &result.LastName, &result.Email,
+			&result.Phone, &result.DateOfBirth, &result.Address, &result.SchoolID,
+			&result.EnrollmentDate, &result.Status, &result.CreatedAt, &result.UpdatedAt, &previousSchoolID,
+		)
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return &result, nil
+	return &result, previousSchoolID, nil
+}
+
+// Delete removes a student and returns the school it belonged to
+func (r *StudentRepository) Delete(ctx context.Context, id int) (int, error) {
+	query := `DELETE FROM students WHERE id = $1 RETURNING school_id`
+	var schoolID int
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, "StudentRepository.Delete", query, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, query, id).Scan(&schoolID)
+	})
+	if err != nil {
+		// sql.ErrNoRows when there is no such student
+		return 0, err
+	}
+
+	return schoolID, nil
 }
 
-func (r *StudentRepository) Delete(id int) error {
-	query := `DELETE FROM students WHERE id = $1`
-	result, err := r.db.Exec(query, id)
+// queryPage counts the rows matching filter, then runs query with LIMIT $1
+// OFFSET $2 followed by the filter arguments
+func (r *StudentRepository) queryPage(ctx context.Context, operation, countQuery string, filter []interface{}, query string, page shared.PageRequest) ([]models.Student, int, error) {
+	var total int
+	err := shared.TraceDB(ctx, shared.DBSystemPostgreSQL, operation+".Count", countQuery, func(ctx context.Context) error {
+		return r.db.QueryRowContext(ctx, countQuery, filter...).Scan(&total)
+	})
 	if err != nil {
+		return nil, 0, err
+	}
+
+	var students []models.Student
+	err = shared.TraceDB(ctx, shared.DBSystemPostgreSQL, operation, query, func(ctx context.Context) error {
+		var err error
+		students, err = r.queryStudents(ctx, query, append([]interface{}{page.PageSize, page.Offset()}, filter...)...)
 		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if students == nil {
+		students = []models.Student{}
 	}
+	return students, total, nil
+}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+// queryStudents runs a SELECT returning full student rows
+func (r *StudentRepository) queryStudents(ctx context.Context, query string, args ...interface{}) ([]models.Student, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	var students []models.Student
+	for rows.Next() {
+		var student models.Student
+		err := rows.Scan(
+			&student.ID, &student.RollNumber, &student.FirstName, &student.LastName, &student.Email,
+			&student.Phone, &student.DateOfBirth, &student.Address, &student.SchoolID,
+			&student.EnrollmentDate, &student.Status, &student.CreatedAt, &student.UpdatedAt,
+		)
+		if err != nil {
+			continue
+		}
+		students = append(students, student)
+	}
+
+	return students, nil
 }
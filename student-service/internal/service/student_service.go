@@ -1,12 +1,16 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
+	"skool-management/proto/schoolpb"
 	"skool-management/shared"
 	"skool-management/student-service/internal/models"
 	"skool-management/student-service/internal/repository"
@@ -14,77 +18,119 @@ import (
 	grpcLib "google.golang.org/grpc"
 )
 
+// Errors returned when a school lookup cannot reach the school service. Both
+// are transient, so handlers report them as retryable 503s.
+var (
+	// ErrSchoolServiceBusy means the bulkhead around school lookups is full
+	ErrSchoolServiceBusy = errors.New("school service is busy")
+	// ErrSchoolServiceUnavailable means the school service circuit breaker is open
+	ErrSchoolServiceUnavailable = errors.New("school service temporarily unavailable")
+)
+
 type StudentService struct {
 	studentRepo          *repository.StudentRepository
-	schoolServiceConn    *grpcLib.ClientConn
+	schoolClient         schoolpb.SchoolServiceClient
 	schoolCircuitBreaker *shared.CircuitBreaker
+	schoolBulkhead       *shared.Bulkhead
+	events               *shared.EventLog
 }
 
-func NewStudentService(studentRepo *repository.StudentRepository, schoolServiceConn *grpcLib.ClientConn) *StudentService {
+// NewStudentService creates the service; every change it makes is published
+// to events. Schools are not validated when schoolServiceConn is nil.
+func NewStudentService(studentRepo *repository.StudentRepository, schoolServiceConn *grpcLib.ClientConn, events *shared.EventLog) *StudentService {
+	var schoolClient schoolpb.SchoolServiceClient
+	if schoolServiceConn != nil {
+		schoolClient = schoolpb.NewSchoolServiceClient(schoolServiceConn)
+	}
 	return &StudentService{
-		studentRepo:       studentRepo,
-		schoolServiceConn: schoolServiceConn,
+		studentRepo:  studentRepo,
+		schoolClient: schoolClient,
+		events:       events,
 		// Initialize circuit breaker for school service gRPC calls
 		schoolCircuitBreaker: shared.NewCircuitBreaker(shared.CircuitBreakerConfig{
-			Name:         "school-service-grpc",
-			MaxFailures:  3,
-			ResetTimeout: 30 * time.Second,
+			Name:                 "school-service-grpc",
+			ResetTimeout:         30 * time.Second,
+			WindowType:           shared.CountBasedWindow,
+			WindowSize:           10,
+			MinimumCalls:         5,
+			FailureRateThreshold: 50,
+			SlowCallDuration:     time.Second,
+			HalfOpenMaxCalls:     2,
+		}),
+		// Bound concurrent school lookups so a slow school service cannot
+		// tie up every request goroutine
+		schoolBulkhead: shared.NewBulkhead(shared.BulkheadConfig{
+			Name:          "school-service-grpc",
+			MaxConcurrent: 20,
+			MaxQueue:      20,
+			QueueTimeout:  500 * time.Millisecond,
 		}),
 	}
 }
 
+// schoolValidation is the result of a school lookup via gRPC
+type schoolValidation struct {
+	exists bool
+	name   string
+}
+
+// schoolValidationError hides validateSchool failures from callers except
+// ErrSchoolServiceBusy and ErrSchoolServiceUnavailable
+func schoolValidationError(err error) error {
+	if errors.Is(err, ErrSchoolServiceBusy) || errors.Is(err, ErrSchoolServiceUnavailable) {
+		return err
+	}
+	return errors.New("failed to validate school")
+}
+
 // Helper function to validate school existence via gRPC
-func (s *StudentService) validateSchool(schoolID int) (bool, string, error) {
-	if s.schoolServiceConn == nil {
+func (s *StudentService) validateSchool(ctx context.Context, schoolID int) (bool, string, error) {
+	if s.schoolClient == nil {
 		return true, "", nil // Skip validation if gRPC connection is not available
 	}
 
-	var exists bool
-	var name string
+	release, err := s.schoolBulkhead.Acquire(ctx)
+	if err != nil {
+		if errors.Is(err, shared.ErrBulkheadFull) {
+			slog.WarnContext(ctx, "school validation bulkhead saturated", "error", err)
+			return false, "", fmt.Errorf("%w: %w", ErrSchoolServiceBusy, err)
+		}
+		return false, "", err
+	}
+	defer release()
 
 	// Use circuit breaker for gRPC calls
-	err := s.schoolCircuitBreaker.Execute(func() error {
-		// Temporarily disable gRPC validation due to protobuf marshaling issues
-		// In a production environment, this would use proper protobuf generated code
-		shared.LogInfo("STUDENT_SERVICE", fmt.Sprintf("Temporarily skipping gRPC validation for school ID %d", schoolID))
-		exists = true
-		name = "Test School"
-		return nil
-
-		// TODO: Re-enable once proper protobuf code is generated
-		// client := grpc.NewSchoolServiceClient(s.schoolServiceConn)
-		// resp, err := client.ValidateSchool(context.Background(), &grpc.ValidateSchoolRequest{
-		// 	Id: strconv.Itoa(schoolID),
-		// })
-		// if err != nil {
-		// 	return err
-		// }
-		// exists = resp.Exists
-		// name = resp.Name
-		// return nil
+	result, err := shared.ExecuteCtx(ctx, s.schoolCircuitBreaker, func(ctx context.Context) (schoolValidation, error) {
+		resp, err := s.schoolClient.ValidateSchool(ctx, &schoolpb.ValidateSchoolRequest{
+			Id: strconv.Itoa(schoolID),
+		})
+		if err != nil {
+			return schoolValidation{}, err
+		}
+		return schoolValidation{exists: resp.GetExists(), name: resp.GetName()}, nil
 	})
 
 	if err != nil {
-		if err.Error() == "circuit breaker is OPEN" {
-			shared.LogError("STUDENT_SERVICE", "school validation circuit breaker", err)
-			return false, "", errors.New("school service temporarily unavailable")
+		if errors.Is(err, shared.ErrCircuitOpen) {
+			slog.ErrorContext(ctx, "school validation circuit breaker rejected call", "error", err)
+			return false, "", fmt.Errorf("%w: %w", ErrSchoolServiceUnavailable, err)
 		}
 		return false, "", err
 	}
 
-	return exists, name, nil
+	return result.exists, result.name, nil
 }
 
-func (s *StudentService) CreateStudent(req *models.CreateStudentRequest) (*models.Student, error) {
+func (s *StudentService) CreateStudent(ctx context.Context, req *models.CreateStudentRequest) (*models.Student, error) {
 	if req.RollNumber == "" || req.FirstName == "" || req.LastName == "" || req.SchoolID == 0 {
 		return nil, errors.New("roll number, first name, last name, and school ID are required")
 	}
 
 	// Validate school exists
-	schoolExists, schoolName, err := s.validateSchool(req.SchoolID)
+	schoolExists, schoolName, err := s.validateSchool(ctx, req.SchoolID)
 	if err != nil {
-		shared.LogError("STUDENT_SERVICE", "school validation", err)
-		return nil, errors.New("failed to validate school")
+		slog.ErrorContext(ctx, "school validation failed", "school_id", req.SchoolID, "error", err)
+		return nil, schoolValidationError(err)
 	}
 
 	if !schoolExists {
@@ -99,7 +145,7 @@ func (s *StudentService) CreateStudent(req *models.CreateStudentRequest) (*model
 		req.EnrollmentDate = time.Now().Format("2006-01-02")
 	}
 
-	student, err := s.studentRepo.Create(req)
+	student, err := s.studentRepo.Create(ctx, req)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key value") {
 			if strings.Contains(err.Error(), "students_roll_number_school_id_key") {
@@ -112,26 +158,76 @@ func (s *StudentService) CreateStudent(req *models.CreateStudentRequest) (*model
 	}
 
 	student.SchoolName = schoolName
+	s.events.Publish(ctx, studentEvent(shared.EventStudentCreated, student.ID, student.SchoolID), student)
 	return student, nil
 }
 
-func (s *StudentService) GetAllStudents() ([]models.Student, error) {
-	students, err := s.studentRepo.GetAll()
+func (s *StudentService) GetAllStudents(ctx context.Context) ([]models.Student, error) {
+	students, err := s.studentRepo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Optionally fetch school names for each student
-	for i := range students {
-		_, schoolName, _ := s.validateSchool(students[i].SchoolID)
-		students[i].SchoolName = schoolName
+	s.setSchoolNames(ctx, students)
+	return students, nil
+}
+
+// GetStudentsBySchoolIDs returns the students of several schools
+func (s *StudentService) GetStudentsBySchoolIDs(ctx context.Context, schoolIDs []int) ([]models.Student, error) {
+	students, err := s.studentRepo.GetBySchoolIDs(ctx, schoolIDs)
+	if err != nil {
+		return nil, err
 	}
 
+	s.setSchoolNames(ctx, students)
 	return students, nil
 }
 
-func (s *StudentService) GetStudentByID(id int) (*models.Student, error) {
-	student, err := s.studentRepo.GetByID(id)
+// setSchoolNames fills in the students' school names, looking each school up
+// once however many students it has. Names are best effort: a failed lookup
+// leaves them empty, and once the school service is busy or unavailable the
+// remaining schools are not looked up at all.
+func (s *StudentService) setSchoolNames(ctx context.Context, students []models.Student) {
+	schoolNames := make(map[int]string)
+	for i := range students {
+		schoolID := students[i].SchoolID
+		name, ok := schoolNames[schoolID]
+		if !ok {
+			var err error
+			_, name, err = s.validateSchool(ctx, schoolID)
+			if err != nil {
+				slog.WarnContext(ctx, "school name lookup failed", "school_id", schoolID, "error", err)
+				if errors.Is(err, ErrSchoolServiceBusy) || errors.Is(err, ErrSchoolServiceUnavailable) || ctx.Err() != nil {
+					return
+				}
+			}
+			schoolNames[schoolID] = name
+		}
+		students[i].SchoolName = name
+	}
+}
+
+// ListStudents returns one page of the students of the given schools, or of
+// every school when schoolIDs is nil, and their total number
+func (s *StudentService) ListStudents(ctx context.Context, schoolIDs []int, page shared.PageRequest) ([]models.Student, int, error) {
+	var students []models.Student
+	var total int
+	var err error
+	if schoolIDs != nil {
+		students, total, err = s.studentRepo.GetPageBySchoolIDs(ctx, schoolIDs, page)
+	} else {
+		students, total, err = s.studentRepo.GetPage(ctx, page)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.setSchoolNames(ctx, students)
+	return students, total, nil
+}
+
+func (s *StudentService) GetStudentByID(ctx context.Context, id int) (*models.Student, error) {
+	student, err := s.studentRepo.GetByID(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("student not found")
@@ -140,25 +236,37 @@ func (s *StudentService) GetStudentByID(id int) (*models.Student, error) {
 	}
 
 	// Get school name via gRPC
-	_, schoolName, _ := s.validateSchool(student.SchoolID)
+	_, schoolName, _ := s.validateSchool(ctx, student.SchoolID)
 	student.SchoolName = schoolName
 
 	return student, nil
 }
 
-func (s *StudentService) GetStudentsBySchoolID(schoolID int) ([]models.Student, string, error) {
+// GetStudentSchoolID returns the school a student belongs to
+func (s *StudentService) GetStudentSchoolID(ctx context.Context, id int) (int, error) {
+	schoolID, err := s.studentRepo.GetSchoolID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("student not found")
+		}
+		return 0, errors.New("failed to get student")
+	}
+	return schoolID, nil
+}
+
+func (s *StudentService) GetStudentsBySchoolID(ctx context.Context, schoolID int) ([]models.Student, string, error) {
 	// Validate school exists
-	schoolExists, schoolName, err := s.validateSchool(schoolID)
+	schoolExists, schoolName, err := s.validateSchool(ctx, schoolID)
 	if err != nil {
-		shared.LogError("STUDENT_SERVICE", "school validation", err)
-		return nil, "", errors.New("failed to validate school")
+		slog.ErrorContext(ctx, "school validation failed", "school_id", schoolID, "error", err)
+		return nil, "", schoolValidationError(err)
 	}
 
 	if !schoolExists {
 		return nil, "", errors.New("school not found")
 	}
 
-	students, err := s.studentRepo.GetBySchoolID(schoolID)
+	students, err := s.studentRepo.GetBySchoolID(ctx, schoolID)
 	if err != nil {
 		return nil, "", errors.New("failed to get students")
 	}
@@ -171,23 +279,48 @@ func (s *StudentService) GetStudentsBySchoolID(schoolID int) ([]models.Student,
 	return students, schoolName, nil
 }
 
-func (s *StudentService) UpdateStudent(id int, req *models.UpdateStudentRequest) (*models.Student, error) {
+// ListStudentsBySchoolID returns one page of a school's students and the
+// school's total number of students
+func (s *StudentService) ListStudentsBySchoolID(ctx context.Context, schoolID int, page shared.PageRequest) ([]models.Student, int, error) {
+	schoolExists, schoolName, err := s.validateSchool(ctx, schoolID)
+	if err != nil {
+		slog.ErrorContext(ctx, "school validation failed", "school_id", schoolID, "error", err)
+		return nil, 0, schoolValidationError(err)
+	}
+
+	if !schoolExists {
+		return nil, 0, errors.New("school not found")
+	}
+
+	students, total, err := s.studentRepo.GetPageBySchoolID(ctx, schoolID, page)
+	if err != nil {
+		return nil, 0, errors.New("failed to get students")
+	}
+
+	for i := range students {
+		students[i].SchoolName = schoolName
+	}
+
+	return students, total, nil
+}
+
+func (s *StudentService) UpdateStudent(ctx context.Context, id int, req *models.UpdateStudentRequest) (*models.Student, error) {
 	if req.RollNumber == "" || req.FirstName == "" || req.LastName == "" || req.SchoolID == 0 {
 		return nil, errors.New("roll number, first name, last name, and school ID are required")
 	}
 
 	// Validate school exists
-	schoolExists, schoolName, err := s.validateSchool(req.SchoolID)
+	schoolExists, schoolName, err := s.validateSchool(ctx, req.SchoolID)
 	if err != nil {
-		shared.LogError("STUDENT_SERVICE", "school validation", err)
-		return nil, errors.New("failed to validate school")
+		slog.ErrorContext(ctx, "school validation failed", "school_id", req.SchoolID, "error", err)
+		return nil, schoolValidationError(err)
 	}
 
 	if !schoolExists {
 		return nil, errors.New("school does not exist")
 	}
 
-	student, err := s.studentRepo.Update(id, req)
+	student, previousSchoolID, err := s.studentRepo.Update(ctx, id, req)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("student not found")
@@ -203,16 +336,27 @@ func (s *StudentService) UpdateStudent(id int, req *models.UpdateStudentRequest)
 	}
 
 	student.SchoolName = schoolName
+	event := studentEvent(shared.EventStudentUpdated, student.ID, student.SchoolID)
+	if previousSchoolID != student.SchoolID {
+		event.PreviousSchoolID = previousSchoolID
+	}
+	s.events.Publish(ctx, event, student)
 	return student, nil
 }
 
-func (s *StudentService) DeleteStudent(id int) error {
-	err := s.studentRepo.Delete(id)
+func (s *StudentService) DeleteStudent(ctx context.Context, id int) error {
+	schoolID, err := s.studentRepo.Delete(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return errors.New("student not found")
 		}
 		return errors.New("failed to delete student")
 	}
+
+	s.events.Publish(ctx, studentEvent(shared.EventStudentDeleted, id, schoolID), nil)
 	return nil
 }
+
+func studentEvent(eventType string, id, schoolID int) shared.Event {
+	return shared.Event{Type: eventType, Entity: shared.EventEntityStudent, EntityID: id, SchoolID: schoolID}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"skool-management/proto/schoolpb"
+	"skool-management/shared"
+	"skool-management/student-service/internal/models"
+
+	"google.golang.org/grpc"
+)
+
+// fakeSchoolClient answers ValidateSchool with "School <id>" and counts calls
+type fakeSchoolClient struct {
+	schoolpb.SchoolServiceClient
+	calls atomic.Int32
+	err   error
+}
+
+func (c *fakeSchoolClient) ValidateSchool(_ context.Context, in *schoolpb.ValidateSchoolRequest, _ ...grpc.CallOption) (*schoolpb.ValidateSchoolResponse, error) {
+	c.calls.Add(1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &schoolpb.ValidateSchoolResponse{Exists: true, Name: "School " + in.GetId()}, nil
+}
+
+func newTestService(t *testing.T, client schoolpb.SchoolServiceClient) *StudentService {
+	return &StudentService{
+		schoolClient:         client,
+		schoolCircuitBreaker: shared.NewCircuitBreaker(shared.CircuitBreakerConfig{Name: "test-" + t.Name()}),
+		schoolBulkhead:       shared.NewBulkhead(shared.BulkheadConfig{Name: "test-" + t.Name(), MaxConcurrent: 1}),
+	}
+}
+
+func TestSetSchoolNamesLooksUpEachSchoolOnce(t *testing.T) {
+	client := &fakeSchoolClient{}
+	s := newTestService(t, client)
+	students := []models.Student{{SchoolID: 1}, {SchoolID: 2}, {SchoolID: 1}, {SchoolID: 2}, {SchoolID: 1}}
+
+	s.setSchoolNames(context.Background(), students)
+	if got := client.calls.Load(); got != 2 {
+		t.Errorf("made %d lookups, want 2", got)
+	}
+	for _, student := range students {
+		if want := "School " + strconv.Itoa(student.SchoolID); student.SchoolName != want {
+			t.Errorf("school %d name = %q, want %q", student.SchoolID, student.SchoolName, want)
+		}
+	}
+}
+
+func TestOpenBreakerLeavesSchoolNamesEmpty(t *testing.T) {
+	client := &fakeSchoolClient{}
+	s := newTestService(t, client)
+	s.schoolCircuitBreaker.ForceOpen()
+	students := []models.Student{{SchoolID: 1}, {SchoolID: 2}, {SchoolID: 3}}
+
+	s.setSchoolNames(context.Background(), students)
+	if got := client.calls.Load(); got != 0 {
+		t.Errorf("made %d lookups through an open breaker", got)
+	}
+	for _, student := range students {
+		if student.SchoolName != "" {
+			t.Errorf("school %d name = %q, want empty", student.SchoolID, student.SchoolName)
+		}
+	}
+	_, _, err := s.validateSchool(context.Background(), 1)
+	if !errors.Is(err, ErrSchoolServiceUnavailable) || !errors.Is(err, shared.ErrCircuitOpen) {
+		t.Errorf("validateSchool err = %v, want ErrSchoolServiceUnavailable wrapping ErrCircuitOpen", err)
+	}
+}
@@ -1,12 +1,12 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
-	"strings"
 
 	"skool-management/shared"
 	"skool-management/student-service/internal/config"
@@ -16,6 +16,7 @@ import (
 	"skool-management/student-service/internal/service"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 
@@ -25,88 +26,127 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	shared.InitLogger("student-service", cfg.LogLevel)
+	lifecycle := shared.NewLifecycle(shared.ServerTimeouts{
+		Read:       cfg.ReadTimeout,
+		Write:      cfg.WriteTimeout,
+		Idle:       cfg.IdleTimeout,
+		Shutdown:   cfg.ShutdownTimeout,
+		DrainDelay: cfg.DrainDelay,
+	})
+
+	// Initialize tracing
+	shutdownTracing, err := shared.InitTracing(context.Background(), "student-service")
+	if err != nil {
+		log.Fatal("Failed to initialize tracing:", err)
+	}
+	lifecycle.OnShutdown("tracing", shutdownTracing)
 
 	// Connect to PostgreSQL
 	db, err := sql.Open("postgres", cfg.GetDSN())
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
+	lifecycle.OnShutdown("postgres", func(context.Context) error { return db.Close() })
 
 	// Test connection
 	if err := db.Ping(); err != nil {
 		log.Fatal("Failed to ping database:", err)
 	}
+	shared.RegisterDBStats(db, "studentdb")
+
+	// TLS for the service's listeners; they stay plain text without a certificate
+	serverTLS, err := shared.NewServerTLSConfig("student-service", shared.TLSFiles{
+		CertFile: cfg.TLSCertFile,
+		KeyFile:  cfg.TLSKeyFile,
+		CAFile:   cfg.TLSClientCAFile,
+	}, cfg.TLSReloadInterval)
+	if err != nil {
+		log.Fatal("Failed to load TLS certificates:", err)
+	}
+
+	// Calls to school-service use mutual TLS when upstream certificates are configured
+	upstreamTLS, err := shared.NewClientTLSConfig("student-service-upstream", shared.TLSFiles{
+		CertFile: cfg.UpstreamTLSCertFile,
+		KeyFile:  cfg.UpstreamTLSKeyFile,
+		CAFile:   cfg.UpstreamTLSCAFile,
+	}, cfg.TLSReloadInterval)
+	if err != nil {
+		log.Fatal("Failed to load upstream TLS certificates:", err)
+	}
+	schoolCredentials := insecure.NewCredentials()
+	if upstreamTLS != nil {
+		schoolCredentials = credentials.NewTLS(upstreamTLS)
+	}
 
 	// Connect to School Service gRPC
 	var schoolServiceConn *grpc.ClientConn
-	schoolServiceConn, err = grpc.Dial(cfg.SchoolServiceGRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	schoolServiceConn, err = grpc.Dial(cfg.SchoolServiceGRPC,
+		grpc.WithTransportCredentials(schoolCredentials),
+		shared.GRPCClientTracing(),
+		grpc.WithChainUnaryInterceptor(
+			shared.UnaryClientRequestIDInterceptor(),
+			shared.UnaryClientMetricsInterceptor(),
+		),
+	)
 	if err != nil {
-		shared.LogError("STUDENT_SERVICE", "school service gRPC connection", err)
-		shared.LogInfo("STUDENT_SERVICE", "Continuing without school service validation")
+		slog.Error("school service gRPC connection failed, continuing without school validation", "error", err)
 	} else {
-		defer schoolServiceConn.Close()
+		lifecycle.OnShutdown("school-service-grpc", func(context.Context) error { return schoolServiceConn.Close() })
 	}
 
 	// Initialize layers
 	studentRepo := repository.NewStudentRepository(db)
-	studentService := service.NewStudentService(studentRepo, schoolServiceConn)
+	events := shared.NewEventLog(shared.EventLogConfig{Name: "student-service", Size: cfg.EventLogSize})
+	studentService := service.NewStudentService(studentRepo, schoolServiceConn, events)
 	studentHandlers := handlers.NewStudentHandlers(studentService)
-	authMiddleware := middleware.AuthMiddleware(cfg.JWTSecret)
+	authMiddleware := middleware.AuthMiddleware(cfg.JWTSecret, shared.NewIdentitySigner(cfg.IdentitySecret, shared.DefaultIdentityMaxSkew))
 
 	// Start gRPC server (placeholder for future student-specific gRPC endpoints)
-	go func() {
-		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
-		if err != nil {
-			log.Fatal("Failed to listen on gRPC port:", err)
-		}
-
-		grpcServer := grpc.NewServer()
-		reflection.Register(grpcServer)
-
-		shared.LogInfo("STUDENT_SERVICE", fmt.Sprintf("Starting gRPC server on port %s", cfg.GRPCPort))
-		if err := grpcServer.Serve(lis); err != nil {
-			log.Fatal("Failed to serve gRPC:", err)
-		}
-	}()
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		log.Fatal("Failed to listen on gRPC port:", err)
+	}
 
-	// Setup HTTP routes
-	http.HandleFunc("/students", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			authMiddleware(studentHandlers.GetStudents)(w, r)
-		case "POST":
-			authMiddleware(studentHandlers.CreateStudent)(w, r)
-		default:
-			shared.WriteErrorResponse(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
-		}
-	})
+	grpcOptions := []grpc.ServerOption{
+		shared.GRPCServerTracing(),
+		grpc.ChainUnaryInterceptor(
+			shared.UnaryServerRequestIDInterceptor(),
+			shared.UnaryServerMetricsInterceptor(),
+		),
+	}
+	if serverTLS != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(serverTLS)))
+	}
+	grpcServer := grpc.NewServer(grpcOptions...)
+	reflection.Register(grpcServer)
 
-	http.HandleFunc("/students/", func(w http.ResponseWriter, r *http.Request) {
-		// Check if it's a school-specific endpoint
-		if strings.HasPrefix(r.URL.Path, "/students/school/") {
-			if r.Method == "GET" {
-				authMiddleware(studentHandlers.GetStudentsBySchool)(w, r)
-				return
-			}
-		} else {
-			// Regular student endpoints
-			switch r.Method {
-			case "GET":
-				authMiddleware(studentHandlers.GetStudent)(w, r)
-			case "PUT":
-				authMiddleware(studentHandlers.UpdateStudent)(w, r)
-			case "DELETE":
-				authMiddleware(studentHandlers.DeleteStudent)(w, r)
-			default:
-				shared.WriteErrorResponse(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
-			}
-		}
-	})
+	slog.Info("starting gRPC server", "port", cfg.GRPCPort, "tls", serverTLS != nil)
+	lifecycle.ServeGRPC(grpcServer, lis)
 
-	// Health check
-	http.HandleFunc("/health", studentHandlers.Health)
+	// Health checks: /livez only reports the process is up, /readyz and
+	// /health also verify dependencies
+	checks := []shared.HealthCheck{lifecycle.ReadinessCheck(), shared.SQLPingCheck("postgres", db)}
+	if schoolServiceConn != nil {
+		checks = append(checks, shared.GRPCConnCheck("school-service-grpc", schoolServiceConn))
+	}
+	readiness := shared.ReadinessHandler(checks...)
+	breakerAdmin := authMiddleware(shared.RequireRole(shared.RoleAdmin, shared.CircuitBreakerAdminHandler(shared.DefaultCircuitBreakerRegistry)))
+	eventStream := authMiddleware(shared.RequireRole(shared.RoleAdmin, shared.EventStreamHandler(events, shared.EventStreamConfig{Heartbeat: cfg.EventHeartbeat})))
 
-	shared.LogInfo("STUDENT_SERVICE", fmt.Sprintf("Starting HTTP server on port %s", cfg.HTTPPort))
-	log.Fatal(http.ListenAndServe(":"+cfg.HTTPPort, nil))
+	// Setup HTTP routes
+	shared.RegisterRoutes(http.DefaultServeMux, routes(studentHandlers, authMiddleware, readiness, breakerAdmin, eventStream))
+
+	slog.Info("starting HTTP server", "port", cfg.HTTPPort, "tls", serverTLS != nil)
+	httpServer := lifecycle.NewHTTPServer(":"+cfg.HTTPPort, shared.TraceHandler(shared.InstrumentHandler(shared.CompressHandler(shared.RequestIDHandler(shared.AccessLogHandler(shared.NewAPIVersioning(shared.APIVersionConfig{}).Handler(shared.RouteHandler(http.DefaultServeMux)))))), "student-service"))
+	httpServer.TLSConfig = serverTLS
+	// End the gateway's event streams as soon as draining starts
+	httpServer.RegisterOnShutdown(events.Close)
+	lifecycle.ServeHTTP(httpServer)
+
+	// Block until SIGINT/SIGTERM, then drain servers and close resources
+	if err := lifecycle.Wait(); err != nil {
+		log.Fatal("Server failed:", err)
+	}
+	slog.Info("student service stopped")
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+
+	"skool-management/shared"
+	"skool-management/student-service/internal/models"
+)
+
+// openAPISpec describes the student service's HTTP API
+func openAPISpec() *shared.OpenAPIDocument {
+	idParam := map[string]string{"id": "integer"}
+
+	return shared.NewOpenAPIDocument("Student Service", "1.0.0", "Manages students and validates their schools against the school service.").AddEndpoints(
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/students", Tag: "students", Authenticated: true,
+			Summary:     "List students",
+			Description: "Pass school_ids=1,2,3 to fetch only the students of those schools.",
+			QueryParams: map[string]string{"school_ids": "string"},
+			Response:    []models.Student{},
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/v2/students", Tag: "students", Authenticated: true,
+			Summary:     "List students a page at a time",
+			QueryParams: shared.PaginationQueryParams(),
+			Response:    models.StudentPage{},
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodPost, Path: "/students", Tag: "students", Authenticated: true,
+			Strict:     true,
+			Idempotent: true,
+			Summary:    "Create a student",
+			Request:    models.CreateStudentRequest{},
+			Response:   models.Student{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		},
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/students/{id}", Tag: "students", Authenticated: true,
+			Summary: "Get a student", PathParams: idParam,
+			Response: models.Student{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodPut, Path: "/students/{id}", Tag: "students", Authenticated: true,
+			Summary: "Update a student", PathParams: idParam,
+			Request:  models.UpdateStudentRequest{},
+			Response: models.Student{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		},
+		shared.Endpoint{
+			Method: http.MethodDelete, Path: "/students/{id}", Tag: "students", Authenticated: true,
+			Summary: "Delete a student", PathParams: idParam,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/students/school/{school_id}", Tag: "students", Authenticated: true,
+			Summary:    "List the students of a school",
+			PathParams: map[string]string{"school_id": "integer"},
+			Response:   []models.Student{},
+			Errors:     []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		},
+		shared.Endpoint{
+			Method: http.MethodGet, Path: "/v2/students/school/{school_id}", Tag: "students", Authenticated: true,
+			Summary:     "List the students of a school a page at a time",
+			PathParams:  map[string]string{"school_id": "integer"},
+			QueryParams: shared.PaginationQueryParams(),
+			Response:    models.StudentPage{},
+			Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		},
+		shared.EventStreamEndpoint(),
+	).AddEndpoints(shared.OperationalEndpoints()...)
+}
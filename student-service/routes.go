@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+
+	"skool-management/shared"
+	"skool-management/student-service/internal/handlers"
+)
+
+// routes lists every HTTP route the student service serves. Each one must be
+// described by openAPISpec, which routes_test.go enforces.
+func routes(h *handlers.StudentHandlers, auth func(http.HandlerFunc) http.HandlerFunc, readiness, breakerAdmin, events http.HandlerFunc) []shared.Route {
+	return append([]shared.Route{
+		{Method: http.MethodGet, Path: "/students", Handler: auth(h.GetStudents)},
+		{Method: http.MethodGet, Path: "/students", Handler: auth(h.ListStudents), Version: shared.APIVersion2},
+		{Method: http.MethodPost, Path: "/students", Handler: auth(h.CreateStudent)},
+		{Method: http.MethodGet, Path: "/students/{id}", Handler: auth(h.GetStudent)},
+		{Method: http.MethodPut, Path: "/students/{id}", Handler: auth(h.UpdateStudent)},
+		{Method: http.MethodDelete, Path: "/students/{id}", Handler: auth(h.DeleteStudent)},
+		{Method: http.MethodGet, Path: "/students/school/{school_id}", Handler: auth(h.GetStudentsBySchool)},
+		{Method: http.MethodGet, Path: "/students/school/{school_id}", Handler: auth(h.ListStudentsBySchool), Version: shared.APIVersion2},
+		{Method: http.MethodGet, Path: shared.EventStreamPath, Handler: events},
+	}, shared.OperationalRoutes(readiness, breakerAdmin, openAPISpec())...)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"skool-management/student-service/internal/handlers"
+	"skool-management/shared"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	passthrough := func(next http.HandlerFunc) http.HandlerFunc { return next }
+	for _, problem := range shared.CheckRouteCoverage(routes(nil, passthrough, nil, nil, nil), openAPISpec()) {
+		t.Error(problem)
+	}
+}
+
+func TestStudentsOutsideScopeAreForbidden(t *testing.T) {
+	// The handlers have no service, so any request that gets past the scope
+	// check would panic
+	scoped := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			identity := &shared.Identity{UserID: "u1", Roles: []string{shared.RoleUser}, SchoolIDs: []string{"1"}}
+			next(w, r.WithContext(shared.WithIdentity(r.Context(), identity)))
+		}
+	}
+	mux := http.NewServeMux()
+	shared.RegisterRoutes(mux, routes(handlers.NewStudentHandlers(nil), scoped, nil, nil, nil))
+
+	requests := []struct{ method, path, body string }{
+		{http.MethodGet, "/students/school/2", ""},
+		{http.MethodPost, "/students", `{"roll_number":"R1","first_name":"Ann","last_name":"Lee","school_id":2}`},
+	}
+	for _, req := range requests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(req.method, req.path, strings.NewReader(req.body)))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s status = %d, want 403", req.method, req.path, rec.Code)
+		}
+	}
+}